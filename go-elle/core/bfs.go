@@ -20,14 +20,6 @@ func NewBFSPath(graph *DirectedGraph, start Vertex, sccSet map[Vertex]struct{})
 	return &bfsPath
 }
 
-func toSet(sccSet []Vertex) map[Vertex]struct{} {
-	set := map[Vertex]struct{}{}
-	for _, v := range sccSet {
-		set[v] = struct{}{}
-	}
-	return set
-}
-
 func (path *BFSPath) bfs(start Vertex, set map[Vertex]struct{}) {
 	bfsQueue := make([]Vertex, 0)
 	bfsQueue = append(bfsQueue, start)
@@ -101,9 +93,3 @@ func getRelsFromEdges(es []Edge) (rs []Rel) {
 	}
 	return rs
 }
-func getVerticesFromTracePath(ts []CycleTrace) (vs []Vertex) {
-	for _, t := range ts {
-		vs = append(vs, t.from)
-	}
-	return vs
-}
@@ -4,6 +4,7 @@ var impliedAnomalies = MapToDirectedGraph(map[Vertex][]Vertex{
 	Vertex{"G0"}:                     {{"G1c"}},
 	Vertex{"G0-process"}:             {{"G1c-process"}, {"G0-realtime"}},
 	Vertex{"G0-realtime"}:            {{"G1c-realtime"}},
+	Vertex{"G0-time"}:                {{"G1c-time"}},
 	Vertex{"G1a"}:                    {{"G1"}},
 	Vertex{"G1b"}:                    {{"G1"}},
 	Vertex{"G1c"}:                    {{"G1"}},
@@ -11,17 +12,23 @@ var impliedAnomalies = MapToDirectedGraph(map[Vertex][]Vertex{
 	Vertex{"G-single"}:               {{"G-nonadjacent"}, {"GSIb"}},
 	Vertex{"G-single-process"}:       {{"G-nonadjacent-process"}, {"G-single-realtime"}},
 	Vertex{"G-single-realtime"}:      {{"G-nonadjacent-realtime"}},
+	Vertex{"G-single-time"}:          {{"G-nonadjacent-time"}},
 	Vertex{"G-nonadjacent"}:          {{"G2"}},
 	Vertex{"G-nonadjacent-process"}:  {{"G2-process"}, {"G-nonadjacent-realtime"}},
 	Vertex{"G-nonadjacent-realtime"}: {{"G2-realtime"}},
+	Vertex{"G-nonadjacent-time"}:     {{"G2-time"}},
 	Vertex{"G2-item"}:                {{"G2"}},
 	Vertex{"G2-item-process"}:        {{"G2-process"}, {"G2-item-realtime"}},
 	Vertex{"G2-item-realtime"}:       {{"G2-realtime"}},
+	Vertex{"G2-item-time"}:           {{"G2-time"}},
+	Vertex{"G2-predicate"}:           {{"G2"}},
 	Vertex{"G2-process"}:             {{"G2-realtime"}},
 	Vertex{"GSIa"}:                   {{"GSI"}},
 	Vertex{"GSIb"}:                   {{"GSI"}},
 	Vertex{"incompatible-order"}:     {{"G1a"}},
 	Vertex{"dirty-update"}:           {{"G1a"}},
+	Vertex{"lost-update"}:            {{"G-single"}},
+	Vertex{"write-skew"}:             {{"G2-item"}},
 })
 
 // ConsistencyModelName defines the consistency model name
@@ -2,7 +2,11 @@ package core
 
 import (
 	"log"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 )
 
 // Rel stands for relation in dependencies
@@ -44,8 +48,10 @@ const (
 	WW           Rel = "ww"
 	WR           Rel = "wr"
 	RW           Rel = "rw"
+	PredicateRW  Rel = "rw-predicate"
 	Process      Rel = "process"
 	Realtime     Rel = "realtime"
+	Time         Rel = "time"
 	ExtKey       Rel = "ext-key"
 	Version      Rel = "version"
 	InitialState Rel = "initial-state"
@@ -137,42 +143,91 @@ type Step struct {
 
 // RealtimeGraph analyzes real-time.
 func RealtimeGraph(history History, _ ...interface{}) (Anomalies, *DirectedGraph, DataExplainer) {
-	realtimeGraph := NewDirectedGraph()
+	pair := realtimePairs(history)
+	return nil, realtimeOrder(history, pair), RealtimeExplainer{pair: pair}
+}
 
-	pair := make(map[Op]Op)
-	{
-		invocations := map[int]Op{}
-		for _, v := range history {
-			process := v.Process.GetOr(AnonymousMagicNumber)
+// RealtimeGraphWithEpsilon returns the analyzer of the real-time order by the :time of the ops
+// instead of their position in the history, as the clocks of the clients may be off by epsilon:
+// T1 < T2 only if T1 completed more than epsilon before T2 was invoked.
+func RealtimeGraphWithEpsilon(epsilon time.Duration) Analyzer {
+	return func(history History, _ ...interface{}) (Anomalies, *DirectedGraph, DataExplainer) {
+		pair := realtimePairs(history)
 
-			switch v.Type {
+		type event struct {
+			op Op
+			t  time.Time
+		}
+		var events []event
+		for _, op := range history {
+			switch op.Type {
 			case OpTypeInvoke:
-				invocations[process] = v
-			case OpTypeInfo:
-				invocation, e := invocations[process]
-				if e {
-					pair[invocation] = v
-					pair[v] = invocation
-					delete(invocations, process)
-				} else {
-					invocations[process] = v
-				}
-			case OpTypeOk, OpTypeFail:
-				invocation, e := invocations[process]
-				if !e {
-					log.Fatalf("cannot find the invocation of %s, the code may has bug", v)
-				}
+				events = append(events, event{op: op, t: op.Time})
+			case OpTypeOk:
+				events = append(events, event{op: op, t: op.Time.Add(epsilon)})
+			default:
+				continue
+			}
+			if op.Time.IsZero() {
+				log.Fatalf("cannot order %s in real time without its :time", op)
+			}
+		}
+		// invocations go first on ties, so that an op completed exactly epsilon before is not ordered
+		sort.SliceStable(events, func(i, j int) bool {
+			if !events[i].t.Equal(events[j].t) {
+				return events[i].t.Before(events[j].t)
+			}
+			return events[i].op.Type == OpTypeInvoke && events[j].op.Type != OpTypeInvoke
+		})
+
+		ordered := make(History, len(events))
+		for i, e := range events {
+			ordered[i] = e.op
+		}
+		return nil, realtimeOrder(ordered, pair), RealtimeExplainer{pair: pair, epsilon: epsilon, byTime: true}
+	}
+}
+
+// realtimePairs pairs the invocations with their completions, in both directions
+func realtimePairs(history History) map[Op]Op {
+	pair := make(map[Op]Op)
+	invocations := map[int]Op{}
+	for _, v := range history {
+		process := v.Process.GetOr(AnonymousMagicNumber)
+
+		switch v.Type {
+		case OpTypeInvoke:
+			invocations[process] = v
+		case OpTypeInfo:
+			invocation, e := invocations[process]
+			if e {
 				pair[invocation] = v
 				pair[v] = invocation
 				delete(invocations, process)
+			} else {
+				invocations[process] = v
 			}
+		case OpTypeOk, OpTypeFail:
+			invocation, e := invocations[process]
+			if !e {
+				log.Fatalf("cannot find the invocation of %s, the code may has bug", v)
+			}
+			pair[invocation] = v
+			pair[v] = invocation
+			delete(invocations, process)
 		}
 	}
+	return pair
+}
+
+// realtimeOrder links the completed ops to the ops invoked after them, in the order of events
+func realtimeOrder(events History, pair map[Op]Op) *DirectedGraph {
+	realtimeGraph := NewDirectedGraph()
 
 	// build state machine
 	var doneEvents = map[Op]struct{}{}
-	for i := range history {
-		op := history[i]
+	for i := range events {
+		op := events[i]
 		switch op.Type {
 		case OpTypeInvoke:
 			pairOp := pair[op]
@@ -187,7 +242,7 @@ func RealtimeGraph(history History, _ ...interface{}) (Anomalies, *DirectedGraph
 			continue
 		}
 	}
-	return nil, realtimeGraph, RealtimeExplainer{pair: pair}
+	return realtimeGraph
 }
 
 func opSet(vertex []Vertex) map[Op]struct{} {
@@ -251,6 +306,50 @@ func ProcessGraph(history History, _ ...interface{}) (Anomalies, *DirectedGraph,
 	return nil, DigraphUnion(graphs...), ProcessExplainer{}
 }
 
+// CommitTimeFn returns the commit timestamp the database reported for an ok op, false if there is none
+type CommitTimeFn func(op Op) (time.Time, bool)
+
+// OpTime is the CommitTimeFn that takes the :time of the ok op as its commit timestamp
+func OpTime(op Op) (time.Time, bool) {
+	return op.Time, !op.Time.IsZero()
+}
+
+// TimeGraph returns the analyzer of the commit order by the timestamps of commitTime (OpTime if nil):
+// T1 < T2 if T1 committed more than skew before T2, as the clocks of the database may be off by skew.
+// Like RealtimeGraph, the edges implied by transitivity are left out.
+func TimeGraph(skew time.Duration, commitTime CommitTimeFn) Analyzer {
+	if commitTime == nil {
+		commitTime = OpTime
+	}
+	return func(history History, _ ...interface{}) (Anomalies, *DirectedGraph, DataExplainer) {
+		type commit struct {
+			op Op
+			t  time.Time
+		}
+		var commits []commit
+		for _, op := range history.FilterType(OpTypeOk) {
+			if t, ok := commitTime(op); ok {
+				commits = append(commits, commit{op: op, t: t})
+			}
+		}
+		sort.SliceStable(commits, func(i, j int) bool { return commits[i].t.Before(commits[j].t) })
+
+		g := NewDirectedGraph()
+		// last is the latest commit more than skew before the current one, the earlier commits
+		// within skew of it are ordered before the current one directly, the others through them
+		last := -1
+		for _, post := range commits {
+			for last+1 < len(commits) && commits[last+1].t.Add(skew).Before(post.t) {
+				last++
+			}
+			for i := last; i >= 0 && !commits[i].t.Add(skew).Before(commits[last].t); i-- {
+				g.Link(Vertex{Value: commits[i].op}, Vertex{Value: post.op}, Time)
+			}
+		}
+		return nil, g, TimeExplainer{Skew: skew, CommitTime: commitTime}
+	}
+}
+
 // MonotonicKeyOrder find dependencies of a process
 func MonotonicKeyOrder(history History, k string) *DirectedGraph {
 	var (
@@ -341,10 +440,12 @@ func Check(analyzer Analyzer, history History, opts ...interface{}) CheckResult
 func checkHelper(analyzer Analyzer, history History, opts ...interface{}) (*DirectedGraph, DataExplainer, []string, []SCC, Anomalies) {
 	// The sample program will first remove nemesis, but we will not leave nemesis here.
 	anomalies, g, exp := analyzer(history, opts...)
-	sccs := g.StronglyConnectedComponents()
+	// compacted once, for the SCCs and the cycle of each
+	cg := CompactGraph(g)
+	sccs := compactSCCs(cg)
 	var cycles []string
 	for _, scc := range sccs {
-		cycles = append(cycles, explainSCC(g, CycleExplainer{}, exp, scc))
+		cycles = append(cycles, explainSCC(cg, CycleExplainer{}, exp, scc))
 	}
 	if g.IsEmpty() {
 		anomalies["empty-transaction-graph"] = []Anomaly{}
@@ -353,8 +454,17 @@ func checkHelper(analyzer Analyzer, history History, opts ...interface{}) (*Dire
 	return g, exp, cycles, sccs, anomalies
 }
 
-// WriteCycles ...
-// TODO: implement it.
-func WriteCycles(cexp CycleExplainer, exp DataExplainer, dir, filename string, cycles []string) {
-	return
+// WriteCycles writes the rendered explanations of cycles to dir/filename (cycles.txt by default),
+// separated by blank lines as Elle does. Nothing is written without a directory.
+func WriteCycles(cexp CycleExplainer, exp DataExplainer, dir, filename string, cycles []string) error {
+	if dir == "" {
+		return nil
+	}
+	if filename == "" {
+		filename = "cycles.txt"
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, filename), []byte(strings.Join(cycles, "\n\n\n")+"\n"), 0644)
 }
@@ -2,7 +2,10 @@ package core
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -154,6 +157,74 @@ func TestRealtimeGraph(t *testing.T) {
 
 }
 
+func TestRealtimeGraphWithEpsilon(t *testing.T) {
+	// the clock of process 2 is behind: it is invoked after 1 completed in the history, 5ns before by time
+	history, err := ParseHistory(`{:index 0 :type :invoke :process 1 :f :read :value nil :time 0}
+{:index 1 :type :ok     :process 1 :f :read :value 1 :time 100}
+{:index 2 :type :invoke :process 2 :f :read :value nil :time 95}
+{:index 3 :type :ok     :process 2 :f :read :value 2 :time 120}
+{:index 4 :type :invoke :process 3 :f :read :value nil :time 130}
+{:index 5 :type :ok     :process 3 :f :read :value 3 :time 140}`)
+	assert.Equal(t, err, nil, "test realtime graph with epsilon, parse history")
+	resp1, resp2, resp3 := Vertex{history[1]}, Vertex{history[3]}, Vertex{history[5]}
+
+	_, g, _ := RealtimeGraph(history)
+	assert.Equal(t, map[Vertex]map[Vertex][]Rel{
+		resp1: {resp2: {Realtime}},
+		resp2: {resp3: {Realtime}},
+		resp3: {},
+	}, g.Outs)
+
+	// 2 completed exactly epsilon before 3 was invoked, which is not enough
+	_, g, explainer := RealtimeGraphWithEpsilon(10 * time.Nanosecond)(history)
+	assert.Equal(t, map[Vertex]map[Vertex][]Rel{
+		resp1: {resp3: {Realtime}},
+		resp3: {},
+	}, g.Outs)
+	assert.Nil(t, explainer.ExplainPairData(history[1], history[3]))
+	assert.Nil(t, explainer.ExplainPairData(history[3], history[5]))
+	assert.Equal(t, "T1 complete at index 1, 3e-08 seconds just before the invocation of T2 at index 4, more than the clock uncertainty of 10ns",
+		explainer.RenderExplanation(explainer.ExplainPairData(history[1], history[5]), "T1", "T2"))
+
+	_, g, _ = RealtimeGraphWithEpsilon(0)(history)
+	assert.Equal(t, map[Vertex]map[Vertex][]Rel{
+		resp1: {resp3: {Realtime}},
+		resp2: {resp3: {Realtime}},
+		resp3: {},
+	}, g.Outs)
+}
+
+func TestTimeGraph(t *testing.T) {
+	history, err := ParseHistory(`{:type :ok :process 1 :f :read :value 1 :time 0}
+{:type :ok :process 2 :f :read :value 2 :time 10}
+{:type :ok :process 3 :f :read :value 3 :time 15}
+{:type :ok :process 4 :f :read :value 4 :time 30}
+{:type :invoke :process 5 :f :read :value nil :time 40}`)
+	assert.Equal(t, err, nil, "test time graph, parse history")
+	t0, t10, t15, t30 := Vertex{history[0]}, Vertex{history[1]}, Vertex{history[2]}, Vertex{history[3]}
+
+	// with a skew of 5ns, 10 and 15 are not ordered and 0 < 30 is implied
+	_, g, explainer := TimeGraph(5*time.Nanosecond, nil)(history)
+	assert.Equal(t, map[Vertex]map[Vertex][]Rel{
+		t0:  {t10: {Time}, t15: {Time}},
+		t10: {t30: {Time}},
+		t15: {t30: {Time}},
+		t30: {},
+	}, g.Outs)
+	assert.NotNil(t, explainer.ExplainPairData(history[0], history[3]))
+	assert.Nil(t, explainer.ExplainPairData(history[1], history[2]))
+	assert.Equal(t, "T2 committed 2e-08 seconds after T1, more than the clock skew of 5ns",
+		explainer.RenderExplanation(explainer.ExplainPairData(history[1], history[3]), "T1", "T2"))
+
+	_, g, _ = TimeGraph(0, nil)(history)
+	assert.Equal(t, map[Vertex]map[Vertex][]Rel{
+		t0:  {t10: {Time}},
+		t10: {t15: {Time}},
+		t15: {t30: {Time}},
+		t30: {},
+	}, g.Outs)
+}
+
 func TestMopValueType(t *testing.T) {
 	history, err := ParseHistory(`
 {:type :invoke, :f :txn, :value [[:append 11 1] [:r 11 nil] [:r 11 nil]], :process 10 :time 14532933, :index 0}
@@ -225,3 +296,18 @@ func toJSON(v interface{}) string {
 //	res := Check(MonotonicKeyGraph, history)
 //	assert.Equal(t, 0, len(res.Sccs), "length of sccs should be zero")
 //}
+
+func TestWriteCycles(t *testing.T) {
+	assert.Nil(t, WriteCycles(CycleExplainer{}, nil, "", "", []string{"a"}), "no directory, nothing written")
+
+	dir := filepath.Join(t.TempDir(), "elle")
+	assert.Nil(t, WriteCycles(CycleExplainer{}, nil, dir, "", []string{"a", "b"}))
+	content, err := os.ReadFile(filepath.Join(dir, "cycles.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "a\n\n\nb\n", string(content))
+
+	assert.Nil(t, WriteCycles(CycleExplainer{}, nil, dir, "G0.txt", []string{"c"}))
+	content, err = os.ReadFile(filepath.Join(dir, "G0.txt"))
+	assert.Nil(t, err)
+	assert.Equal(t, "c\n", string(content))
+}
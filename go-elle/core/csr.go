@@ -0,0 +1,412 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// RelMask is a set of relations as a bitset, each Rel owns a bit (see RelBit)
+type RelMask uint64
+
+var (
+	relBitsMu sync.RWMutex
+	relBits   = map[Rel]RelMask{}
+	relNames  []Rel
+)
+
+func init() {
+	for _, rel := range []Rel{WW, WR, RW, Process, Realtime, Time} {
+		RelBit(rel)
+	}
+}
+
+// RelBit returns the bit of rel, a bit is allotted to a new relation on its first use
+func RelBit(rel Rel) RelMask {
+	relBitsMu.RLock()
+	bit, ok := relBits[rel]
+	relBitsMu.RUnlock()
+	if ok {
+		return bit
+	}
+
+	relBitsMu.Lock()
+	defer relBitsMu.Unlock()
+	if bit, ok := relBits[rel]; ok {
+		return bit
+	}
+	if len(relNames) == 64 {
+		panic(fmt.Sprintf("too many relations for a RelMask, cannot add %q", rel))
+	}
+	bit = 1 << len(relNames)
+	relBits[rel] = bit
+	relNames = append(relNames, rel)
+	return bit
+}
+
+// RelMaskOf returns the set of rels
+func RelMaskOf(rels ...Rel) RelMask {
+	var m RelMask
+	for _, rel := range rels {
+		m |= RelBit(rel)
+	}
+	return m
+}
+
+// Has returns true if rel is in the set
+func (m RelMask) Has(rel Rel) bool {
+	return m&RelBit(rel) != 0
+}
+
+// Rels returns the relations of the set, in the order their bits were allotted
+func (m RelMask) Rels() []Rel {
+	relBitsMu.RLock()
+	defer relBitsMu.RUnlock()
+	var rels []Rel
+	for i, rel := range relNames {
+		if m&(1<<i) != 0 {
+			rels = append(rels, rel)
+		}
+	}
+	return rels
+}
+
+// Graph is an immutable directed graph in compressed sparse row form: vertices are dense int32 IDs
+// with a payload of type V, the out edges of vertex i are outTo[outStart[i]:outStart[i+1]] sorted by
+// target with their relations in outRels, and the in edges are kept the same way without relations.
+// It is built by a GraphBuilder, or from a DirectedGraph by CompactGraph. The payloads are looked up
+// by value, so like the values of a Vertex they must be comparable.
+type Graph[V any] struct {
+	vertices []V
+	ids      map[any]int32
+
+	outStart []int32
+	outTo    []int32
+	outRels  []RelMask
+	inStart  []int32
+	inFrom   []int32
+}
+
+type csrEdge struct {
+	from, to int32
+	rels     RelMask
+}
+
+// GraphBuilder collects the vertices and edges of a Graph
+type GraphBuilder[V any] struct {
+	vertices []V
+	ids      map[any]int32
+	edges    []csrEdge
+}
+
+// NewGraphBuilder returns an empty GraphBuilder
+func NewGraphBuilder[V any]() *GraphBuilder[V] {
+	return &GraphBuilder[V]{ids: map[any]int32{}}
+}
+
+// AddVertex adds v if it is new and returns its ID, IDs are given in the order of addition
+func (b *GraphBuilder[V]) AddVertex(v V) int32 {
+	if id, ok := b.ids[v]; ok {
+		return id
+	}
+	id := int32(len(b.vertices))
+	b.vertices = append(b.vertices, v)
+	b.ids[v] = id
+	return id
+}
+
+// Link links from => to with rel, adding the vertices if they are new
+func (b *GraphBuilder[V]) Link(from, to V, rel Rel) {
+	b.LinkIDs(b.AddVertex(from), b.AddVertex(to), RelBit(rel))
+}
+
+// LinkIDs links the vertices from => to, which must have been added, with the relations of rels
+func (b *GraphBuilder[V]) LinkIDs(from, to int32, rels RelMask) {
+	b.edges = append(b.edges, csrEdge{from: from, to: to, rels: rels})
+}
+
+// Build returns the Graph, the relations of parallel edges are merged. The builder must not be used afterwards.
+func (b *GraphBuilder[V]) Build() *Graph[V] {
+	sort.Slice(b.edges, func(i, j int) bool {
+		if b.edges[i].from != b.edges[j].from {
+			return b.edges[i].from < b.edges[j].from
+		}
+		return b.edges[i].to < b.edges[j].to
+	})
+	merged := b.edges[:0]
+	for _, e := range b.edges {
+		if last := len(merged) - 1; last >= 0 && merged[last].from == e.from && merged[last].to == e.to {
+			merged[last].rels |= e.rels
+			continue
+		}
+		merged = append(merged, e)
+	}
+	g := &Graph[V]{vertices: b.vertices, ids: b.ids}
+	g.setEdges(merged)
+	b.edges = nil
+	return g
+}
+
+// setEdges fills the adjacency of g from edges sorted by (from, to)
+func (g *Graph[V]) setEdges(edges []csrEdge) {
+	n := len(g.vertices)
+	g.outStart = make([]int32, n+1)
+	g.inStart = make([]int32, n+1)
+	g.outTo = make([]int32, len(edges))
+	g.outRels = make([]RelMask, len(edges))
+	g.inFrom = make([]int32, len(edges))
+	for i, e := range edges {
+		g.outStart[e.from+1]++
+		g.inStart[e.to+1]++
+		g.outTo[i] = e.to
+		g.outRels[i] = e.rels
+	}
+	for i := 0; i < n; i++ {
+		g.outStart[i+1] += g.outStart[i]
+		g.inStart[i+1] += g.inStart[i]
+	}
+	// edges are sorted by source, so every in list is sorted too
+	next := append([]int32(nil), g.inStart[:n]...)
+	for _, e := range edges {
+		g.inFrom[next[e.to]] = e.from
+		next[e.to]++
+	}
+}
+
+// Len returns the number of vertices
+func (g *Graph[V]) Len() int {
+	return len(g.vertices)
+}
+
+// EdgeCount returns the number of edges, parallel edges count once
+func (g *Graph[V]) EdgeCount() int {
+	return len(g.outTo)
+}
+
+// Vertex returns the payload of vertex id
+func (g *Graph[V]) Vertex(id int32) V {
+	return g.vertices[id]
+}
+
+// ID returns the ID of v
+func (g *Graph[V]) ID(v V) (int32, bool) {
+	id, ok := g.ids[v]
+	return id, ok
+}
+
+// Out returns the targets of the out edges of id, sorted, and their relations
+func (g *Graph[V]) Out(id int32) ([]int32, []RelMask) {
+	lo, hi := g.outStart[id], g.outStart[id+1]
+	return g.outTo[lo:hi], g.outRels[lo:hi]
+}
+
+// In returns the sources of the in edges of id, sorted
+func (g *Graph[V]) In(id int32) []int32 {
+	return g.inFrom[g.inStart[id]:g.inStart[id+1]]
+}
+
+// Rels returns the relations of the edge from => to, empty if there is none
+func (g *Graph[V]) Rels(from, to int32) RelMask {
+	targets, rels := g.Out(from)
+	i := sort.Search(len(targets), func(i int) bool { return targets[i] >= to })
+	if i < len(targets) && targets[i] == to {
+		return rels[i]
+	}
+	return 0
+}
+
+// Filter returns the graph of the edges with a relation in mask, restricted to those relations.
+// The vertices and their IDs are shared with g.
+func (g *Graph[V]) Filter(mask RelMask) *Graph[V] {
+	var edges []csrEdge
+	for from := int32(0); from < int32(len(g.vertices)); from++ {
+		targets, rels := g.Out(from)
+		for i, to := range targets {
+			if r := rels[i] & mask; r != 0 {
+				edges = append(edges, csrEdge{from: from, to: to, rels: r})
+			}
+		}
+	}
+	fg := &Graph[V]{vertices: g.vertices, ids: g.ids}
+	fg.setEdges(edges)
+	return fg
+}
+
+// StronglyConnectedComponents returns the components of more than one vertex with Tarjan's algorithm,
+// the IDs of each component are sorted and the components are in the order they complete
+func (g *Graph[V]) StronglyConnectedComponents() [][]int32 {
+	n := int32(len(g.vertices))
+	const unvisited = -1
+	index := make([]int32, n)
+	low := make([]int32, n)
+	onStack := make([]bool, n)
+	for i := range index {
+		index[i] = unvisited
+	}
+	var stack []int32
+	type frame struct {
+		v    int32
+		next int32 // position of the next out edge to follow
+	}
+	var calls []frame
+	var sccs [][]int32
+	counter := int32(0)
+
+	for root := int32(0); root < n; root++ {
+		if index[root] != unvisited {
+			continue
+		}
+		calls = append(calls, frame{v: root, next: g.outStart[root]})
+		index[root], low[root] = counter, counter
+		counter++
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			v := f.v
+			if f.next < g.outStart[v+1] {
+				w := g.outTo[f.next]
+				f.next++
+				if index[w] == unvisited {
+					index[w], low[w] = counter, counter
+					counter++
+					stack = append(stack, w)
+					onStack[w] = true
+					calls = append(calls, frame{v: w, next: g.outStart[w]})
+				} else if onStack[w] && index[w] < low[v] {
+					low[v] = index[w]
+				}
+				continue
+			}
+
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				if parent := calls[len(calls)-1].v; low[v] < low[parent] {
+					low[parent] = low[v]
+				}
+			}
+			if low[v] != index[v] {
+				continue
+			}
+			i := len(stack) - 1
+			for stack[i] != v {
+				i--
+			}
+			for _, w := range stack[i:] {
+				onStack[w] = false
+			}
+			if len(stack)-i > 1 {
+				scc := append([]int32(nil), stack[i:]...)
+				sort.Slice(scc, func(a, b int) bool { return scc[a] < scc[b] })
+				sccs = append(sccs, scc)
+			}
+			stack = stack[:i]
+		}
+	}
+	return sccs
+}
+
+// FindCycle finds a shortest cycle in the component scc, see FindCycleWith
+func (g *Graph[V]) FindCycle(ctx context.Context, scc []int32) ([]int32, error) {
+	return g.FindCycleWith(ctx, scc, nil)
+}
+
+// FindCycleWith finds a shortest cycle within the vertices of scc that satisfies isWith, or any
+// shortest cycle if isWith is nil. The cycle is returned as a closed path [a, b, ..., a] and nil
+// if there is none. It gives up with ctx.Err() once ctx is done, ctx is checked for every start vertex.
+func (g *Graph[V]) FindCycleWith(ctx context.Context, scc []int32, isWith func(path []int32) bool) ([]int32, error) {
+	if len(scc) < 2 {
+		return nil, nil
+	}
+	n := len(g.vertices)
+	inScc := make([]bool, n)
+	for _, v := range scc {
+		inScc[v] = true
+	}
+	// dist and edgeTo are valid for the vertices stamped with the current search
+	stamp := make([]int32, n)
+	dist := make([]int32, n)
+	edgeTo := make([]int32, n)
+	queue := make([]int32, 0, len(scc))
+
+	length := int32(len(scc) + 1)
+	var dest []int32
+	for s, start := range scc {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		// distances from every vertex to start, searched backwards on the in edges
+		search := int32(s + 1)
+		stamp[start], dist[start] = search, 0
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			cur := queue[0]
+			queue = queue[1:]
+			for _, v := range g.In(cur) {
+				if !inScc[v] || stamp[v] == search {
+					continue
+				}
+				stamp[v], dist[v], edgeTo[v] = search, dist[cur]+1, cur
+				queue = append(queue, v)
+			}
+		}
+
+		targets, _ := g.Out(start)
+		for _, next := range targets {
+			if !inScc[next] || stamp[next] != search || dist[next]+1 >= length {
+				continue
+			}
+			path := make([]int32, 0, dist[next]+2)
+			path = append(path, start)
+			for v := next; v != start; v = edgeTo[v] {
+				path = append(path, v)
+			}
+			path = append(path, start)
+			if isWith == nil || isWith(path) {
+				dest = path
+				length = dist[next] + 1
+			}
+		}
+	}
+	return dest, nil
+}
+
+// CompactGraph converts g into a Graph, the IDs follow the order of g.Vertices()
+func CompactGraph(g *DirectedGraph) *Graph[Vertex] {
+	b := NewGraphBuilder[Vertex]()
+	vertices := g.Vertices()
+	for _, v := range vertices {
+		b.AddVertex(v)
+	}
+	for _, from := range vertices {
+		for to, rels := range g.Outs[from] {
+			b.LinkIDs(b.ids[from], b.AddVertex(to), RelMaskOf(rels...))
+		}
+	}
+	return b.Build()
+}
+
+// DirectedGraph converts g back into a DirectedGraph, vertex maps each payload to its Vertex
+func (g *Graph[V]) DirectedGraph(vertex func(V) Vertex) *DirectedGraph {
+	dg := NewDirectedGraph()
+	for from := int32(0); from < int32(len(g.vertices)); from++ {
+		targets, rels := g.Out(from)
+		for i, to := range targets {
+			for _, rel := range rels[i].Rels() {
+				dg.Link(vertex(g.vertices[from]), vertex(g.vertices[to]), rel)
+			}
+		}
+	}
+	return dg
+}
+
+// cycleTrace turns a closed path of g into the CycleTrace of a CyclePredicate, without the closing vertex
+func cycleTrace(g *Graph[Vertex], path []int32) []CycleTrace {
+	trace := make([]CycleTrace, len(path)-1)
+	for i := range trace {
+		trace[i] = CycleTrace{from: g.vertices[path[i]], Rels: g.Rels(path[i], path[i+1]).Rels()}
+	}
+	return trace
+}
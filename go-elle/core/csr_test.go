@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelMask(t *testing.T) {
+	m := RelMaskOf(WW, RW)
+	assert.True(t, m.Has(WW))
+	assert.False(t, m.Has(WR))
+	assert.Equal(t, []Rel{WW, RW}, m.Rels())
+	assert.Equal(t, RelMask(0), RelMaskOf())
+}
+
+func TestGraphBuilder(t *testing.T) {
+	b := NewGraphBuilder[string]()
+	b.Link("a", "b", WW)
+	b.Link("a", "b", WR)
+	b.Link("b", "c", RW)
+	b.Link("c", "a", WW)
+	b.Link("c", "d", WR)
+	g := b.Build()
+
+	assert.Equal(t, 4, g.Len())
+	assert.Equal(t, 4, g.EdgeCount())
+	a, _ := g.ID("a")
+	c, _ := g.ID("c")
+	d, ok := g.ID("d")
+	assert.True(t, ok)
+	assert.Equal(t, "d", g.Vertex(d))
+	_, ok = g.ID("e")
+	assert.False(t, ok)
+
+	targets, rels := g.Out(a)
+	assert.Len(t, targets, 1)
+	assert.Equal(t, RelMaskOf(WW, WR), rels[0])
+	assert.Equal(t, []int32{c}, g.In(d))
+	assert.Equal(t, RelMask(0), g.Rels(a, d))
+
+	assert.Equal(t, [][]int32{{0, 1, 2}}, g.StronglyConnectedComponents())
+	// without rw there is no cycle
+	fg := g.Filter(RelMaskOf(WW, WR))
+	assert.Equal(t, 3, fg.EdgeCount())
+	assert.Equal(t, RelMaskOf(WW, WR), fg.Rels(a, targets[0]))
+	assert.Empty(t, fg.StronglyConnectedComponents())
+}
+
+func TestGraphFindCycleWith(t *testing.T) {
+	b := NewGraphBuilder[int]()
+	b.Link(1, 2, WW)
+	b.Link(2, 1, RW)
+	b.Link(2, 3, WW)
+	b.Link(3, 1, WW)
+	g := b.Build()
+	scc := []int32{0, 1, 2}
+
+	cycle, err := g.FindCycle(context.Background(), scc)
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{0, 1, 0}, cycle)
+
+	// the shortest cycle of ww edges only
+	cycle, err = g.FindCycleWith(context.Background(), scc, func(path []int32) bool {
+		for i := 0; i < len(path)-1; i++ {
+			if g.Rels(path[i], path[i+1]).Has(RW) {
+				return false
+			}
+		}
+		return true
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int32{1, 2, 0, 1}, cycle)
+
+	cycle, err = g.FindCycleWith(context.Background(), scc, func([]int32) bool { return false })
+	assert.NoError(t, err)
+	assert.Nil(t, cycle)
+}
+
+func TestCompactGraph(t *testing.T) {
+	g := NewDirectedGraph()
+	g.Link(Vertex{1}, Vertex{2}, WW)
+	g.Link(Vertex{1}, Vertex{2}, WR)
+	g.Link(Vertex{2}, Vertex{1}, RW)
+	g.Link(Vertex{2}, Vertex{3}, Realtime)
+
+	cg := CompactGraph(g)
+	assert.Equal(t, 3, cg.Len())
+	assert.Equal(t, 3, cg.EdgeCount())
+
+	dg := cg.DirectedGraph(func(v Vertex) Vertex { return v })
+	assert.ElementsMatch(t, []Rel{WW, WR}, dg.Outs[Vertex{1}][Vertex{2}])
+	assert.Equal(t, []Rel{RW}, dg.Outs[Vertex{2}][Vertex{1}])
+	assert.Equal(t, []Rel{Realtime}, dg.Outs[Vertex{2}][Vertex{3}])
+
+	sccs := g.StronglyConnectedComponents()
+	assert.Len(t, sccs, 1)
+	assert.ElementsMatch(t, []Vertex{{1}, {2}}, sccs[0].Vertices)
+}
@@ -1,9 +1,12 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"sync"
+	"time"
 )
 
 // DataExplainer ...
@@ -32,6 +35,10 @@ const (
 	WRDepend DependType = "wr"
 	// RWDepend ...
 	RWDepend DependType = "rw"
+	// PredicateRWDepend ...
+	PredicateRWDepend DependType = "rw-predicate"
+	// TimeDepend ...
+	TimeDepend DependType = "time"
 )
 
 // ExplainResult is an interface, contains rwExplainerResult, wwExplainerResult wr ExplainerResult etc
@@ -39,9 +46,18 @@ type ExplainResult interface {
 	Type() DependType
 }
 
-// CombinedExplainer struct
+// KeyedExplainResult is an ExplainResult about a dependency on a single key.
+// MopIndexes returns the indexes of the involved mops in the two operations, -1 if unknown.
+type KeyedExplainResult interface {
+	ExplainResult
+	GetKey() string
+	MopIndexes() (int, int)
+}
+
+// CombinedExplainer struct, it is safe for concurrent use
 type CombinedExplainer struct {
 	Explainers []DataExplainer
+	mu         sync.RWMutex
 	store      map[ExplainResult]DataExplainer
 }
 
@@ -58,16 +74,55 @@ func (c *CombinedExplainer) ExplainPairData(p1, p2 PathType) ExplainResult {
 	for _, ex := range c.Explainers {
 		er := ex.ExplainPairData(p1, p2)
 		if er != nil {
+			c.mu.Lock()
+			c.store[er] = ex
+			c.mu.Unlock()
+			return er
+		}
+	}
+	return nil
+}
+
+// ExplainPairDataOf is ExplainPairData that only gives the results whose type is one of rels
+func (c *CombinedExplainer) ExplainPairDataOf(p1, p2 PathType, rels RelMask) ExplainResult {
+	for _, ex := range c.Explainers {
+		er := ex.ExplainPairData(p1, p2)
+		if er != nil && rels.Has(Rel(er.Type())) {
+			c.mu.Lock()
 			c.store[er] = ex
+			c.mu.Unlock()
 			return er
 		}
 	}
 	return nil
 }
 
+// RestrictExplainer returns the explainer of the dependencies of rels only, so that the steps
+// of a cycle found on the edges of rels are explained by those relations, e.g. a cycle of ww and
+// process edges is not explained by realtime when both the process and realtime graphs are combined.
+// Only a CombinedExplainer can be restricted, others are returned as is.
+func RestrictExplainer(explainer DataExplainer, rels RelMask) DataExplainer {
+	if c, ok := explainer.(*CombinedExplainer); ok && rels != 0 {
+		return restrictedExplainer{CombinedExplainer: c, rels: rels}
+	}
+	return explainer
+}
+
+type restrictedExplainer struct {
+	*CombinedExplainer
+	rels RelMask
+}
+
+func (r restrictedExplainer) ExplainPairData(p1, p2 PathType) ExplainResult {
+	return r.ExplainPairDataOf(p1, p2, r.rels)
+}
+
 // RenderExplanation render explanation result
 func (c *CombinedExplainer) RenderExplanation(result ExplainResult, p1, p2 string) string {
-	return c.store[result].RenderExplanation(result, p1, p2)
+	c.mu.RLock()
+	ex := c.store[result]
+	c.mu.RUnlock()
+	return ex.RenderExplanation(result, p1, p2)
 }
 
 // Combine composes multiple analyzers
@@ -127,6 +182,9 @@ func (e ProcessExplainer) RenderExplanation(result ExplainResult, preName, postN
 // RealtimeExplainer is Realtime order explainer
 type RealtimeExplainer struct {
 	pair map[Op]Op
+	// set by RealtimeGraphWithEpsilon, ops are ordered by their :time within epsilon
+	epsilon time.Duration
+	byTime  bool
 }
 
 // ExplainPairData ...
@@ -135,6 +193,17 @@ func (r RealtimeExplainer) ExplainPairData(preEnd, postEnd PathType) ExplainResu
 	if !ok {
 		log.Fatalf("cannot find the invocation of %s, the code may has bug", postEnd.String())
 	}
+	if r.byTime {
+		if preEnd.Time.Add(r.epsilon).Before(postStart.Time) {
+			return RealtimeExplainResult{
+				PreEnd:    preEnd,
+				PostStart: postStart,
+				Epsilon:   r.epsilon,
+				ByTime:    true,
+			}
+		}
+		return nil
+	}
 	if preEnd.Index.MustGet() < postStart.Index.MustGet() {
 		return RealtimeExplainResult{
 			PreEnd:    preEnd,
@@ -161,6 +230,9 @@ func (r RealtimeExplainer) RenderExplanation(result ExplainResult, preName, post
 	}
 
 	s += fmt.Sprintf("before the invocation of %s at index %d", postName, res.PostStart.Index.MustGet())
+	if res.ByTime {
+		s += fmt.Sprintf(", more than the clock uncertainty of %v", res.Epsilon)
+	}
 	return s
 }
 
@@ -168,6 +240,9 @@ func (r RealtimeExplainer) RenderExplanation(result ExplainResult, preName, post
 type RealtimeExplainResult struct {
 	PreEnd    Op
 	PostStart Op
+	// Epsilon is the clock uncertainty if the order was derived from the :time of the ops (ByTime)
+	Epsilon time.Duration
+	ByTime  bool
 }
 
 // Type ...
@@ -175,6 +250,46 @@ func (RealtimeExplainResult) Type() DependType {
 	return RealtimeDepend
 }
 
+// TimeExplainer explains the commit order by timestamps of TimeGraph
+type TimeExplainer struct {
+	Skew       time.Duration
+	CommitTime CommitTimeFn
+}
+
+// ExplainPairData ...
+func (e TimeExplainer) ExplainPairData(p1, p2 PathType) ExplainResult {
+	t1, ok1 := e.CommitTime(p1)
+	t2, ok2 := e.CommitTime(p2)
+	if ok1 && ok2 && t1.Add(e.Skew).Before(t2) {
+		return TimeExplainResult{Pre: p1, Post: p2, PreTime: t1, PostTime: t2, Skew: e.Skew}
+	}
+	return nil
+}
+
+// RenderExplanation ...
+func (e TimeExplainer) RenderExplanation(result ExplainResult, preName, postName string) string {
+	if result.Type() != TimeDepend {
+		log.Fatalf("result type is not %s, type error", TimeDepend)
+	}
+	res := result.(TimeExplainResult)
+	return fmt.Sprintf("%s committed %v seconds after %s, more than the clock skew of %v",
+		postName, res.PostTime.Sub(res.PreTime).Seconds(), preName, res.Skew)
+}
+
+// TimeExplainResult records a commit order explain result
+type TimeExplainResult struct {
+	Pre      Op
+	Post     Op
+	PreTime  time.Time
+	PostTime time.Time
+	Skew     time.Duration
+}
+
+// Type ...
+func (TimeExplainResult) Type() DependType {
+	return TimeDepend
+}
+
 // MonotonicKeyExplainer ...
 // Note: MonotonicKey is used on rw_register, so I don't explain it currently.
 type MonotonicKeyExplainer struct{}
@@ -284,8 +399,9 @@ func explainCycleOps(pairExplainer DataExplainer, bindings []OpBinding, steps []
 	return strings.Join(explainitions, "\n")
 }
 
-func explainSCC(g *DirectedGraph, cycleExplainer CycleExplainer, pairExplainer DataExplainer, scc SCC) string {
-	cycle := NewCircle(FindCycle(g, scc))
+func explainSCC(cg *Graph[Vertex], cycleExplainer CycleExplainer, pairExplainer DataExplainer, scc SCC) string {
+	path, _ := FindCompactCycleWith(context.Background(), cg, scc, nil)
+	cycle := NewCircle(path)
 	if cycle == nil {
 		panic("don't find a cycle, the code may has bug")
 	}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strconv"
@@ -340,91 +341,21 @@ type SCC struct {
 	Vertices []Vertex
 }
 
-// StronglyConnectedComponents finds all strongly connected components, greater than 1 element
+// StronglyConnectedComponents finds all strongly connected components, greater than 1 element.
+// The vertices of each component follow the order of g.Vertices()
 func (g *DirectedGraph) StronglyConnectedComponents() []SCC {
-	dfn := make(map[Vertex]int)
-	low := make(map[Vertex]int)
-	cnt := 0
-	belong := make(map[Vertex]int)
-	in := make(map[Vertex]bool)
-	tag := make(map[Vertex]map[Vertex]bool)
-	var s2 []Vertex
-	vertices := g.Vertices()
-	index := 0
-	for _, v := range vertices {
-		if dfn[v] == 0 {
-			s1 := []Vertex{v}
-			for len(s1) > 0 {
-				x := s1[len(s1)-1]
-				s1 = s1[:len(s1)-1]
-				if dfn[x] == 0 {
-					index = index + 1
-					dfn[x] = index
-					low[x] = index
-					s2 = append(s2, x)
-					in[x] = true
-				}
-				finish := true
-				for next := range g.Outs[x] {
-					if dfn[next] == 0 {
-						s1 = append(s1, x)
-						s1 = append(s1, next)
-						_, ok := tag[x]
-						if !ok {
-							tag[x] = make(map[Vertex]bool)
-						}
-						tag[x][next] = true
-						finish = false
-						break
-					} else {
-						if in[next] == true {
-							if low[x] > dfn[next] {
-								low[x] = dfn[next]
-							}
-						}
-					}
-				}
-				if finish == true {
-					for next := range g.Outs[x] {
-						_, ok := tag[x]
-						if !ok {
-							tag[x] = make(map[Vertex]bool)
-						}
-						if tag[x][next] == true {
-							if low[x] > low[next] {
-								low[x] = low[next]
-							}
-						}
-					}
-					if low[x] == dfn[x] {
-						var t Vertex
-						cnt = cnt + 1
-						for {
-							t = s2[len(s2)-1]
-							s2 = s2[:len(s2)-1]
-							in[t] = false
-							belong[t] = cnt
-							if t == x {
-								break
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-
-	scc := make([]SCC, cnt)
-	for _, v := range vertices {
-		id := belong[v]
-		scc[id-1].Vertices = append(scc[id-1].Vertices, v)
-	}
+	return compactSCCs(CompactGraph(g))
+}
 
+// compactSCCs is StronglyConnectedComponents of a graph already in compact form
+func compactSCCs(cg *Graph[Vertex]) []SCC {
 	var sccs []SCC
-	for _, sc := range scc {
-		if len(sc.Vertices) >= 2 {
-			sccs = append(sccs, sc)
+	for _, ids := range cg.StronglyConnectedComponents() {
+		scc := SCC{Vertices: make([]Vertex, len(ids))}
+		for i, id := range ids {
+			scc.Vertices[i] = cg.Vertex(id)
 		}
+		sccs = append(sccs, scc)
 	}
 	return sccs
 }
@@ -550,31 +481,30 @@ type CyclePredicate func(trace []CycleTrace) bool
 
 // FindCycle receives a graph and a scc, finds a short cycle in that component
 func FindCycle(graph *DirectedGraph, scc SCC) []Vertex {
-	if len(scc.Vertices) == 1 {
-		return []Vertex{}
-	}
-	length := len(scc.Vertices) + 1
-	sccSet := toSet(scc.Vertices)
+	cycle, _ := FindCycleContext(context.Background(), graph, scc)
+	return cycle
+}
 
-	var destCycle []CycleTrace
-	for _, start := range scc.Vertices {
-		bfs := NewBFSPath(graph, start, sccSet)
-		for _, next := range graph.Out(start) {
-			if _, e := sccSet[next]; !e {
-				continue
-			}
-			if bfs.HasPathFrom(next) && (bfs.DistFrom(next)+1) < length {
-				length = bfs.DistFrom(next) + 1
-				destCycle = append([]CycleTrace{{from: start, Rels: getRelsFromEdges(graph.Edges(start, next))}}, bfs.PathFrom(next)...)
-			}
-		}
-	}
-	return getVerticesFromTracePath(destCycle)
+// FindCycleContext is FindCycle, it gives up with ctx.Err() once ctx is done.
+func FindCycleContext(ctx context.Context, graph *DirectedGraph, scc SCC) ([]Vertex, error) {
+	return FindCycleWithContext(ctx, graph, scc, nil)
 }
 
 // FindCycleStartingWith ...
 func FindCycleStartingWith(graph *DirectedGraph, scc SCC, first Rel, rest []Rel) []Vertex {
-	return FindCycleWith(graph, scc, func(trace []CycleTrace) bool {
+	cycle, _ := FindCycleStartingWithContext(context.Background(), graph, scc, first, rest)
+	return cycle
+}
+
+// FindCycleStartingWithContext is FindCycleStartingWith, it gives up with ctx.Err() once ctx is done.
+func FindCycleStartingWithContext(ctx context.Context, graph *DirectedGraph, scc SCC, first Rel, rest []Rel) ([]Vertex, error) {
+	return FindCycleWithContext(ctx, graph, scc, StartingWith(first, rest))
+}
+
+// StartingWith is the CyclePredicate of the cycles whose first edge has the relation first
+// and whose other edges have a relation in rest
+func StartingWith(first Rel, rest []Rel) CyclePredicate {
+	return func(trace []CycleTrace) bool {
 		if len(trace) < 2 {
 			return false
 		}
@@ -587,38 +517,49 @@ func FindCycleStartingWith(graph *DirectedGraph, scc SCC, first Rel, rest []Rel)
 			}
 		}
 		return true
-	})
+	}
 }
 
 // FindCycleWith ...
 func FindCycleWith(graph *DirectedGraph, scc SCC, isWith CyclePredicate) []Vertex {
+	cycle, _ := FindCycleWithContext(context.Background(), graph, scc, isWith)
+	return cycle
+}
+
+// FindCycleWithContext is FindCycleWith, it gives up with ctx.Err() once ctx is done.
+// The search runs on the compact form of graph (see Graph.FindCycleWith), isWith may be nil.
+// It compacts graph on every call, FindCompactCycleWith searches many SCCs of a graph compacted once.
+func FindCycleWithContext(ctx context.Context, graph *DirectedGraph, scc SCC, isWith CyclePredicate) ([]Vertex, error) {
 	if len(scc.Vertices) == 1 {
-		return []Vertex{}
+		return []Vertex{}, nil
 	}
-	length := len(scc.Vertices) + 1
-	sccSet := toSet(scc.Vertices)
+	return FindCompactCycleWith(ctx, CompactGraph(graph), scc, isWith)
+}
 
-	var destCycle []CycleTrace
-	for _, start := range scc.Vertices {
-		bfs := NewBFSPath(graph, start, sccSet)
-		out, ok := graph.Outs[start]
-		if !ok {
-			continue
+// FindCompactCycleWith is FindCycleWithContext on a graph already in compact form
+func FindCompactCycleWith(ctx context.Context, cg *Graph[Vertex], scc SCC, isWith CyclePredicate) ([]Vertex, error) {
+	if len(scc.Vertices) == 1 {
+		return []Vertex{}, nil
+	}
+	ids := make([]int32, 0, len(scc.Vertices))
+	for _, v := range scc.Vertices {
+		if id, ok := cg.ID(v); ok {
+			ids = append(ids, id)
 		}
-		for next := range out {
-			if _, e := sccSet[next]; !e {
-				continue
-			}
-			if bfs.HasPathFrom(next) && (bfs.DistFrom(next)+1) < length {
-				cycle := append([]CycleTrace{{from: start, Rels: getRelsFromEdges(graph.Edges(start, next))}}, bfs.PathFrom(next)...)
-				// cycle: t1(rels of t1 and t2) -> t2(rels of t2 and t1) -> t1(rels of t1 and t2),
-				// so we need remove the last element when we invoke isWith
-				if isWith(cycle[:len(cycle)-1]) {
-					destCycle = cycle
-					length = bfs.DistFrom(next) + 1
-				}
-			}
+	}
+	var pred func(path []int32) bool
+	if isWith != nil {
+		pred = func(path []int32) bool {
+			return isWith(cycleTrace(cg, path))
 		}
 	}
-	return getVerticesFromTracePath(destCycle)
+	path, err := cg.FindCycleWith(ctx, ids, pred)
+	if err != nil || path == nil {
+		return nil, err
+	}
+	cycle := make([]Vertex, len(path))
+	for i, id := range path {
+		cycle[i] = cg.Vertex(id)
+	}
+	return cycle, nil
 }
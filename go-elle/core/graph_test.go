@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -116,3 +117,23 @@ func TestFindCycleStartingWith3(t *testing.T) {
 		Vertices: []Vertex{{1}, {2}, {3}, {4}, {5}, {6}}}, "", []Rel{"", "start1", "start4"})
 	assert.Equal(t, []Vertex{{5}, {6}, {4}, {5}}, cycle)
 }
+
+func TestFindCycleWithContextCanceled(t *testing.T) {
+	var g DirectedGraph
+	g.Ins = make(map[Vertex][]Vertex)
+	g.Outs = make(map[Vertex]map[Vertex][]Rel)
+
+	g.Link(Vertex{1}, Vertex{2}, "")
+	g.Link(Vertex{2}, Vertex{1}, "")
+	scc := SCC{Vertices: []Vertex{{1}, {2}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cycle, err := FindCycleContext(ctx, &g, scc)
+	assert.NoError(t, err)
+	assert.Equal(t, []Vertex{{1}, {2}, {1}}, cycle)
+
+	cancel()
+	cycle, err = FindCycleWithContext(ctx, &g, scc, func([]CycleTrace) bool { return true })
+	assert.Equal(t, context.Canceled, err)
+	assert.Nil(t, cycle)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -20,6 +21,8 @@ var (
 	opValuePattern   = regexp.MustCompile(`:value\s+\[(.*)\]`)
 	mopPattern       = regexp.MustCompile(`(\[:(append|r|w)\s+(\w+)\s+(\[.*?\]|.*?)\])+`)
 	mopValuePattern  = regexp.MustCompile(`\[(.*)\]`)
+	// a scan, [:scan [lo hi] {k v ...}], or one of the mops of mopPattern
+	rwMopPattern = regexp.MustCompile(`\[:scan\s+\[\s*(\w+)\s+(\w+)\s*\]\s+(\{.*?\}|nil)\]|\[:(append|r|w)\s+(\w+)\s+(\[.*?\]|.*?)\]`)
 )
 
 // NemesisProcessMagicNumber is a magic number to stand for nemesis related event on a history
@@ -52,6 +55,7 @@ const (
 	MopTypeAppend  MopType = "append"
 	MopTypeRead    MopType = "read"
 	MopTypeWrite   MopType = "write"
+	MopTypeScan    MopType = "scan"
 	MopTypeUnknown MopType = "unknown"
 )
 
@@ -88,14 +92,54 @@ func (m Mop) IsWrite() bool {
 	return m.T == MopTypeWrite
 }
 
+// IsScan ...
+func (m Mop) IsScan() bool {
+	return m.T == MopTypeScan
+}
+
 // GetMopType ...
 func (m Mop) GetMopType() MopType {
 	return m.T
 }
 
-// GetKey ...
+// GetKey returns the key of the mop, scans have no key and return ""
 func (m Mop) GetKey() string {
-	return m.M["key"].(string)
+	key, _ := m.M["key"].(string)
+	return key
+}
+
+// GetRange returns the inclusive key range of a scan
+func (m Mop) GetRange() (lo, hi string) {
+	return m.M["lo"].(string), m.M["hi"].(string)
+}
+
+// Covers tells whether key is in the range of a scan, see KeyInRange
+func (m Mop) Covers(key string) bool {
+	if !m.IsScan() {
+		return false
+	}
+	lo, hi := m.GetRange()
+	return KeyInRange(key, lo, hi)
+}
+
+// KeyInRange tells whether key is in the inclusive range [lo, hi], keys are compared as numbers if both are ones
+func KeyInRange(key, lo, hi string) bool {
+	return compareKeys(lo, key) <= 0 && compareKeys(key, hi) <= 0
+}
+
+func compareKeys(a, b string) int {
+	x, errX := strconv.Atoi(a)
+	y, errY := strconv.Atoi(b)
+	if errX == nil && errY == nil {
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(a, b)
 }
 
 // GetValue ...
@@ -160,6 +204,27 @@ func (m Mop) String() string {
 		}
 		fmt.Fprint(&b, "]")
 		return b.String()
+	case MopTypeScan:
+		lo, hi := m.GetRange()
+		value := reflect.ValueOf(m.M["value"])
+		if !value.IsValid() || value.IsNil() {
+			return fmt.Sprintf("[:scan [%s %s] nil]", lo, hi)
+		}
+		var keys []string
+		for _, k := range value.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Slice(keys, func(i, j int) bool { return compareKeys(keys[i], keys[j]) < 0 })
+		var b strings.Builder
+		fmt.Fprintf(&b, "[:scan [%s %s] {", lo, hi)
+		for i, k := range keys {
+			if i > 0 {
+				fmt.Fprint(&b, " ")
+			}
+			fmt.Fprintf(&b, "%s %v", k, value.MapIndex(reflect.ValueOf(k)).Interface())
+		}
+		fmt.Fprint(&b, "}]")
+		return b.String()
 	default:
 		panic("unreachable")
 	}
@@ -227,6 +292,28 @@ func ReadRW(key string, value int) Mop {
 	}
 }
 
+// Scan is a predicate read of the keys in [lo, hi], values holds the keys it observed (for R-W registers)
+func Scan(lo, hi string, values map[string]int) Mop {
+	if values == nil {
+		return Mop{
+			T: MopTypeScan,
+			M: map[string]interface{}{
+				"lo":    lo,
+				"hi":    hi,
+				"value": nil,
+			},
+		}
+	}
+	return Mop{
+		T: MopTypeScan,
+		M: map[string]interface{}{
+			"lo":    lo,
+			"hi":    hi,
+			"value": values,
+		},
+	}
+}
+
 // Op is operation
 type Op struct {
 	Index   IntOptional `json:"index,omitempty"`
@@ -560,14 +647,26 @@ func ParseOpRW(opString string) (Op, error) {
 	if len(opValueMatch) == 2 {
 		mopContent := strings.Trim(opValueMatch[1], " ")
 		if mopContent != "" {
-			mopMatches := mopPattern.FindAllStringSubmatch(mopContent, -1)
+			mopMatches := rwMopPattern.FindAllStringSubmatch(mopContent, -1)
 			for _, mopMatch := range mopMatches {
-				if len(mopMatch) != 5 {
+				if len(mopMatch) != 7 {
 					break
 				}
-				key := strings.Trim(mopMatch[3], " ")
+				if mopMatch[1] != "" {
+					mop, err := parseScan(mopMatch[1], mopMatch[2], mopMatch[3])
+					if err != nil {
+						return empty, err
+					}
+					if op.Value == nil {
+						destArray := make([]Mop, 0)
+						op.Value = &destArray
+					}
+					*op.Value = append(*op.Value, mop)
+					continue
+				}
+				key := strings.Trim(mopMatch[5], " ")
 				var value MopValueType
-				mopValueMatches := mopValuePattern.FindStringSubmatch(mopMatch[4])
+				mopValueMatches := mopValuePattern.FindStringSubmatch(mopMatch[6])
 				if len(mopValueMatches) == 2 {
 					values := []int{}
 					trimVal := strings.Trim(mopValueMatches[1], "[")
@@ -583,7 +682,7 @@ func ParseOpRW(opString string) (Op, error) {
 					}
 					value = values
 				} else {
-					trimVal := strings.Trim(mopMatch[4], " ")
+					trimVal := strings.Trim(mopMatch[6], " ")
 					if trimVal == "nil" {
 						value = nil
 					} else {
@@ -596,7 +695,7 @@ func ParseOpRW(opString string) (Op, error) {
 				}
 
 				var mop Mop
-				switch mopMatch[2] {
+				switch mopMatch[4] {
 				case "w":
 					mop = Write(key, value.(int))
 				case "r":
@@ -620,6 +719,26 @@ func ParseOpRW(opString string) (Op, error) {
 	return op, nil
 }
 
+// parseScan parses the observed keys and values of [:scan [lo hi] {k v ...}], nil if the scan crashed
+func parseScan(lo, hi, content string) (Mop, error) {
+	if content == "nil" {
+		return Scan(lo, hi, nil), nil
+	}
+	fields := strings.Fields(strings.ReplaceAll(strings.Trim(content, "{}"), ",", " "))
+	if len(fields)%2 != 0 {
+		return Mop{}, errors.Errorf("scan values should be key value pairs, %s", content)
+	}
+	values := make(map[string]int, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		val, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			return Mop{}, err
+		}
+		values[fields[i]] = val
+	}
+	return Scan(lo, hi, values), nil
+}
+
 // FilterType filter by type
 func (h History) FilterType(t OpType) History {
 	var filterHistory History
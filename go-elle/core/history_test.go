@@ -64,3 +64,29 @@ func TestParseHistory(t *testing.T) {
 	assert.Equal(t, history[6], Op{Index: IntOptional{6}, Type: OpTypeInvoke, Value: &txn5MopsInvoke}, "parse history, history[6]")
 	assert.Equal(t, history[7], Op{Index: IntOptional{7}, Type: OpTypeOk, Value: &txn5MopsOk}, "parse history, history[7]")
 }
+
+func TestParseOpRWScan(t *testing.T) {
+	op, err := ParseOpRW(`{:index 3, :type :ok, :process 1, :value [[:r 1 2] [:scan [1 10] {2 5, 10 1}] [:w 4 1] [:scan [a c] {}]]}`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []Mop{
+		ReadRW("1", 2),
+		Scan("1", "10", map[string]int{"2": 5, "10": 1}),
+		Write("4", 1),
+		Scan("a", "c", map[string]int{}),
+	}, *op.Value)
+	assert.Equal(t, "[:scan [1 10] {2 5 10 1}]", (*op.Value)[1].String())
+	assert.Equal(t, "", (*op.Value)[1].GetKey())
+
+	// keys are compared as numbers when both are ones
+	assert.True(t, (*op.Value)[1].Covers("2"))
+	assert.True(t, (*op.Value)[1].Covers("10"))
+	assert.False(t, (*op.Value)[1].Covers("11"))
+	assert.True(t, (*op.Value)[3].Covers("b"))
+	assert.False(t, (*op.Value)[3].Covers("d"))
+	assert.False(t, (*op.Value)[2].Covers("4"))
+
+	op, err = ParseOpRW(`{:index 2, :type :invoke, :value [[:scan [1 10] nil]]}`)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, []Mop{Scan("1", "10", nil)}, *op.Value)
+	assert.Equal(t, "[:scan [1 10] nil]", (*op.Value)[0].String())
+}
@@ -0,0 +1,293 @@
+// Package generator simulates concurrent clients running list-append or rw-register txns against an
+// in-memory store and records the complete histories (invoke/ok/fail/info ops, processes and times).
+// On request, it injects txns exhibiting a specific anomaly on fresh keys, so that every generated
+// history comes with its ground truth: without injections it is strict serializable, and each
+// injection is labelled with the ops it added.
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+// Workload is the data model of the txns
+type Workload string
+
+const (
+	// ListAppend txns append unique values to lists and read whole lists
+	ListAppend Workload = "list-append"
+	// RWRegister txns write unique values to registers and read them
+	RWRegister Workload = "rw-register"
+)
+
+// Opts configures a simulation
+type Opts struct {
+	Workload Workload
+	// Clients run concurrently, each with at most one txn in flight
+	Clients int
+	// Txns is the number of workload txns, injected txns come on top of them
+	Txns         int
+	KeyCount     int
+	MinTxnLength int
+	MaxTxnLength int
+	// ReadRatio is the probability of a micro-op to be a read
+	ReadRatio float64
+	// FailRate and InfoRate are the probabilities of a txn to abort or to time out;
+	// a timed out txn takes effect or not at random, and its process crashes
+	FailRate float64
+	InfoRate float64
+	// Inject lists the anomalies to inject, each one once per occurrence
+	Inject []Anomaly
+	Seed   int64
+}
+
+// DefaultOpts returns default opts
+func DefaultOpts() Opts {
+	return Opts{
+		Workload:     ListAppend,
+		Clients:      5,
+		Txns:         100,
+		KeyCount:     5,
+		MinTxnLength: 1,
+		MaxTxnLength: 4,
+		ReadRatio:    0.5,
+		FailRate:     0.05,
+		InfoRate:     0.02,
+		Seed:         1,
+	}
+}
+
+// Label records an injected anomaly: the indexes of the completions of its txns and its keys
+type Label struct {
+	Anomaly Anomaly  `json:"anomaly"`
+	Ops     []int    `json:"ops"`
+	Keys    []string `json:"keys"`
+}
+
+// Result is a generated history with its labels and the version order of each key,
+// i.e. the values in the order they were installed
+type Result struct {
+	History  core.History
+	Labels   []Label
+	Versions map[string][]int
+}
+
+// store is the in-memory store the clients run against, it executes txns atomically
+type store struct {
+	workload  Workload
+	lists     map[string][]int
+	registers map[string]int
+	versions  map[string][]int
+}
+
+func newStore(workload Workload) *store {
+	return &store{workload: workload, lists: map[string][]int{}, registers: map[string]int{}, versions: map[string][]int{}}
+}
+
+// execute applies the mops of a txn and returns them with the values read
+func (s *store) execute(mops []core.Mop) []core.Mop {
+	done := make([]core.Mop, 0, len(mops))
+	for _, mop := range mops {
+		k := mop.GetKey()
+		switch {
+		case mop.IsAppend():
+			v := mop.GetValue().(int)
+			s.lists[k] = append(s.lists[k], v)
+			s.versions[k] = append(s.versions[k], v)
+			done = append(done, mop)
+		case mop.IsWrite():
+			v := mop.GetValue().(int)
+			s.registers[k] = v
+			s.versions[k] = append(s.versions[k], v)
+			done = append(done, mop)
+		case s.workload == RWRegister:
+			done = append(done, core.ReadRW(k, s.registers[k]))
+		default:
+			done = append(done, core.Read(k, append([]int{}, s.lists[k]...)))
+		}
+	}
+	return done
+}
+
+type client struct {
+	process int
+	// the invocation in flight, nil if idle
+	invoke *core.Op
+}
+
+type generator struct {
+	opts      Opts
+	rnd       *rand.Rand
+	store     *store
+	history   core.History
+	now       int64
+	nextValue map[string]int
+	nextKey   int
+	// processes of crashed clients and injected txns
+	nextProcess int
+}
+
+// Generate runs the simulation
+func Generate(opts Opts) (Result, error) {
+	if opts.Workload != ListAppend && opts.Workload != RWRegister {
+		return Result{}, fmt.Errorf("invalid workload %s", opts.Workload)
+	}
+	if opts.Clients < 1 || opts.KeyCount < 1 || opts.MinTxnLength < 1 || opts.MaxTxnLength < opts.MinTxnLength {
+		return Result{}, fmt.Errorf("invalid opts %+v", opts)
+	}
+	for _, a := range opts.Inject {
+		if _, ok := scripts[a]; !ok {
+			return Result{}, fmt.Errorf("unknown anomaly %s", a)
+		}
+		if !Injectable(opts.Workload, a) {
+			return Result{}, fmt.Errorf("%s cannot be injected into %s histories, its version order is not observable", a, opts.Workload)
+		}
+	}
+	g := &generator{
+		opts:        opts,
+		rnd:         rand.New(rand.NewSource(opts.Seed)),
+		store:       newStore(opts.Workload),
+		nextValue:   map[string]int{},
+		nextKey:     opts.KeyCount,
+		nextProcess: opts.Clients,
+	}
+
+	// injections happen right before the n-th workload invocation
+	injectAt := map[int][]Anomaly{}
+	for _, a := range opts.Inject {
+		n := 0
+		if opts.Txns > 0 {
+			n = g.rnd.Intn(opts.Txns)
+		}
+		injectAt[n] = append(injectAt[n], a)
+	}
+
+	var labels []Label
+	clients := make([]*client, opts.Clients)
+	for i := range clients {
+		clients[i] = &client{process: i}
+	}
+	started := 0
+	for {
+		for _, a := range injectAt[started] {
+			labels = append(labels, g.inject(a))
+		}
+		delete(injectAt, started)
+
+		var ready []*client
+		for _, c := range clients {
+			if c.invoke != nil || started < opts.Txns {
+				ready = append(ready, c)
+			}
+		}
+		if len(ready) == 0 {
+			break
+		}
+		c := ready[g.rnd.Intn(len(ready))]
+		if c.invoke == nil {
+			invoke := g.emit(core.OpTypeInvoke, c.process, g.workloadMops())
+			c.invoke = &invoke
+			started++
+		} else {
+			g.complete(c)
+		}
+	}
+	return Result{History: g.history, Labels: labels, Versions: g.store.versions}, nil
+}
+
+// emit appends an op to the history at the next point in time
+func (g *generator) emit(tp core.OpType, process int, mops []core.Mop) core.Op {
+	g.now += 1 + g.rnd.Int63n(1000)
+	op := core.Op{
+		Index:   core.NewOptInt(len(g.history)),
+		Process: core.NewOptInt(process),
+		Time:    time.Unix(0, g.now),
+		Type:    tp,
+		Value:   &mops,
+	}
+	g.history = append(g.history, op)
+	return op
+}
+
+func (g *generator) complete(c *client) {
+	mops := *c.invoke.Value
+	c.invoke = nil
+	r := g.rnd.Float64()
+	switch {
+	case r < g.opts.FailRate:
+		g.emit(core.OpTypeFail, c.process, mops)
+	case r < g.opts.FailRate+g.opts.InfoRate:
+		if g.rnd.Intn(2) == 0 {
+			g.store.execute(mops)
+		}
+		g.emit(core.OpTypeInfo, c.process, mops)
+		c.process = g.nextProcess
+		g.nextProcess++
+	default:
+		g.emit(core.OpTypeOk, c.process, g.store.execute(mops))
+	}
+}
+
+func (g *generator) value(k string) int {
+	g.nextValue[k]++
+	return g.nextValue[k]
+}
+
+// the mops of the invocation of a workload txn, reads have nil values
+func (g *generator) workloadMops() []core.Mop {
+	n := g.opts.MinTxnLength + g.rnd.Intn(g.opts.MaxTxnLength-g.opts.MinTxnLength+1)
+	mops := make([]core.Mop, 0, n)
+	for i := 0; i < n; i++ {
+		k := strconv.Itoa(g.rnd.Intn(g.opts.KeyCount))
+		read := g.rnd.Float64() < g.opts.ReadRatio
+		switch {
+		case read:
+			mops = append(mops, core.Read(k, nil))
+		case g.opts.Workload == ListAppend:
+			mops = append(mops, core.Append(k, g.value(k)))
+		default:
+			mops = append(mops, core.Write(k, g.value(k)))
+		}
+	}
+	return mops
+}
+
+// inject runs the txns of an anomaly on fresh keys, all of them concurrently
+func (g *generator) inject(a Anomaly) Label {
+	keys := []string{strconv.Itoa(g.nextKey), strconv.Itoa(g.nextKey + 1)}
+	g.nextKey += 2
+	txns, versions := scripts[a](g.opts.Workload, keys[0], keys[1])
+
+	label := Label{Anomaly: a, Keys: keys}
+	processes := make([]int, len(txns))
+	for i, t := range txns {
+		processes[i] = g.nextProcess
+		g.nextProcess++
+		g.emit(core.OpTypeInvoke, processes[i], invocation(t.mops))
+	}
+	for i, t := range txns {
+		op := g.emit(t.tp, processes[i], t.mops)
+		label.Ops = append(label.Ops, op.Index.MustGet())
+	}
+	for k, vs := range versions {
+		g.store.versions[k] = vs
+	}
+	return label
+}
+
+// the mops of a txn as invoked, without the values read
+func invocation(mops []core.Mop) []core.Mop {
+	invoked := make([]core.Mop, 0, len(mops))
+	for _, mop := range mops {
+		if mop.IsRead() {
+			invoked = append(invoked, core.Read(mop.GetKey(), nil))
+		} else {
+			invoked = append(invoked, mop.Copy())
+		}
+	}
+	return invoked
+}
@@ -0,0 +1,105 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-elle/list_append"
+	rwregister "github.com/grail/anti-pattern-graph-checker-single/go-elle/rw_register"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+)
+
+func edn(history core.History) string {
+	var b strings.Builder
+	for _, op := range history {
+		b.WriteString(op.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func check(t *testing.T, w Workload, history core.History) txn.CheckResult {
+	// the histories go through EDN, as the checkers read them
+	if w == ListAppend {
+		parsed, err := core.ParseHistory(edn(history))
+		if err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		return listappend.Check(txn.Opts{}, parsed)
+	}
+	parsed, err := core.ParseHistoryRW(edn(history))
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	return rwregister.Check(txn.Opts{}, rwregister.ConvertHistory(parsed), rwregister.GraphOption{})
+}
+
+func TestGenerateValid(t *testing.T) {
+	for _, w := range []Workload{ListAppend, RWRegister} {
+		for seed := int64(1); seed <= 5; seed++ {
+			opts := DefaultOpts()
+			opts.Workload, opts.Seed = w, seed
+			r, err := Generate(opts)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if len(r.History) != 2*opts.Txns {
+				t.Fatalf("expect %d ops, got %d", 2*opts.Txns, len(r.History))
+			}
+			for i, op := range r.History {
+				if op.Index.MustGet() != i || !op.Process.Present() || (i > 0 && !op.Time.After(r.History[i-1].Time)) {
+					t.Fatalf("malformed op %d: %v", i, op)
+				}
+			}
+			if res := check(t, w, r.History); !res.Valid {
+				t.Fatalf("%s seed %d: expect a strict serializable history, got %v", w, seed, res.AnomalyTypes)
+			}
+		}
+	}
+}
+
+func TestGenerateInject(t *testing.T) {
+	for _, w := range []Workload{ListAppend, RWRegister} {
+		for _, a := range Anomalies {
+			opts := DefaultOpts()
+			opts.Workload, opts.Inject = w, []Anomaly{a}
+			r, err := Generate(opts)
+			if !Injectable(w, a) {
+				if err == nil {
+					t.Fatalf("%s %s: expect an error for an unobservable anomaly", w, a)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			if len(r.Labels) != 1 || r.Labels[0].Anomaly != a || len(r.Labels[0].Ops) == 0 {
+				t.Fatalf("%s %s: unexpected labels %v", w, a, r.Labels)
+			}
+			for _, i := range r.Labels[0].Ops {
+				if r.History[i].Type == core.OpTypeInvoke {
+					t.Fatalf("%s %s: label op %d is an invocation", w, a, i)
+				}
+			}
+			res := check(t, w, r.History)
+			if _, ok := res.Anomalies[AnomalyTypes[a]]; ok {
+				continue
+			}
+			t.Fatalf("%s %s: expect %s, got %v", w, a, AnomalyTypes[a], res.AnomalyTypes)
+		}
+	}
+}
+
+func TestGenerateOpts(t *testing.T) {
+	opts := DefaultOpts()
+	opts.Inject = []Anomaly{"G3"}
+	if _, err := Generate(opts); err == nil {
+		t.Fatal("expect an error for an unknown anomaly")
+	}
+	opts = DefaultOpts()
+	opts.Workload = "bank"
+	if _, err := Generate(opts); err == nil {
+		t.Fatal("expect an error for an unknown workload")
+	}
+}
@@ -0,0 +1,137 @@
+package generator
+
+import "github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+
+// Anomaly is an anomaly the generator can inject
+type Anomaly string
+
+const (
+	// G0 is a write cycle
+	G0 Anomaly = "G0"
+	// G1a is an aborted read
+	G1a Anomaly = "G1a"
+	// G1b is an intermediate read
+	G1b Anomaly = "G1b"
+	// G1c is a cycle of ww and wr edges with at least one wr edge
+	G1c Anomaly = "G1c"
+	// GSingle is a cycle with exactly one rw edge
+	GSingle Anomaly = "G-single"
+	// WriteSkew is a cycle of two adjacent rw edges
+	WriteSkew Anomaly = "write-skew"
+	// LongFork is a cycle of two nonadjacent rw edges: two readers observe two writes in different orders
+	LongFork Anomaly = "long-fork"
+	// LostUpdate is two txns reading the same version and both writing the key
+	LostUpdate Anomaly = "lost-update"
+)
+
+// Anomalies lists every anomaly the generator can inject
+var Anomalies = []Anomaly{G0, G1a, G1b, G1c, GSingle, WriteSkew, LongFork, LostUpdate}
+
+// unobservable are the anomalies whose cycles need a version order that no rw-register history shows:
+// their writes are blind or follow stale reads, so checkers ordering the versions they observe miss them
+var unobservable = map[Anomaly]bool{G0: true, WriteSkew: true, LostUpdate: true}
+
+// Injectable tells if a can be injected into histories of w, Generate rejects the other combinations
+func Injectable(w Workload, a Anomaly) bool {
+	return w != RWRegister || !unobservable[a]
+}
+
+type scriptedTxn struct {
+	tp   core.OpType
+	mops []core.Mop
+}
+
+// a script builds the completed txns of an anomaly on the keys x and y, and their version orders
+type script func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int)
+
+// helpers for both workloads: a write of v, a read of vs (a list, or the last one for a register)
+func write(w Workload, k string, v int) core.Mop {
+	if w == ListAppend {
+		return core.Append(k, v)
+	}
+	return core.Write(k, v)
+}
+
+func read(w Workload, k string, vs ...int) core.Mop {
+	if w == ListAppend {
+		return core.Read(k, append([]int{}, vs...))
+	}
+	if len(vs) == 0 {
+		return core.ReadRW(k, 0)
+	}
+	return core.ReadRW(k, vs[len(vs)-1])
+}
+
+func ok(mops ...core.Mop) scriptedTxn {
+	return scriptedTxn{tp: core.OpTypeOk, mops: mops}
+}
+
+var scripts = map[Anomaly]script{
+	G0: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			ok(write(w, x, 1), write(w, y, 1)),
+			ok(write(w, x, 2), write(w, y, 2)),
+			ok(read(w, x, 1, 2), read(w, y, 2, 1)),
+		}, map[string][]int{x: {1, 2}, y: {2, 1}}
+	},
+	G1a: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			{tp: core.OpTypeFail, mops: []core.Mop{write(w, x, 1)}},
+			ok(read(w, x, 1)),
+		}, map[string][]int{}
+	},
+	G1b: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			ok(write(w, x, 1), write(w, x, 2)),
+			ok(read(w, x, 1)),
+		}, map[string][]int{x: {1, 2}}
+	},
+	G1c: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			ok(write(w, x, 1), read(w, y, 1)),
+			ok(write(w, y, 1), read(w, x, 1)),
+		}, map[string][]int{x: {1}, y: {1}}
+	},
+	GSingle: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			ok(write(w, x, 1), write(w, y, 1)),
+			ok(read(w, x, 1), read(w, y)),
+			ok(read(w, y, 1)),
+		}, map[string][]int{x: {1}, y: {1}}
+	},
+	WriteSkew: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			ok(read(w, x), write(w, y, 1)),
+			ok(read(w, y), write(w, x, 1)),
+			ok(read(w, x, 1), read(w, y, 1)),
+		}, map[string][]int{x: {1}, y: {1}}
+	},
+	LongFork: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			ok(write(w, x, 1)),
+			ok(write(w, y, 1)),
+			ok(read(w, x, 1), read(w, y)),
+			ok(read(w, x), read(w, y, 1)),
+			ok(read(w, x, 1), read(w, y, 1)),
+		}, map[string][]int{x: {1}, y: {1}}
+	},
+	LostUpdate: func(w Workload, x string, y string) ([]scriptedTxn, map[string][]int) {
+		return []scriptedTxn{
+			ok(read(w, x), write(w, x, 1)),
+			ok(read(w, x), write(w, x, 2)),
+			ok(read(w, x, 1, 2)),
+		}, map[string][]int{x: {1, 2}}
+	},
+}
+
+// AnomalyTypes are the names go-elle reports an injected anomaly under, given the version order
+var AnomalyTypes = map[Anomaly]string{
+	G0:         "G0",
+	G1a:        "G1a",
+	G1b:        "G1b",
+	G1c:        "G1c",
+	GSingle:    "G-single",
+	WriteSkew:  "G2-item",
+	LongFork:   "G-nonadjacent",
+	LostUpdate: "G-single",
+}
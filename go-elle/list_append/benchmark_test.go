@@ -1,6 +1,7 @@
 package listappend
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"testing"
@@ -77,3 +78,127 @@ func BenchmarkListAppendPSI(b *testing.B) {
 		}
 	}
 }
+
+func hugeSccGraph(b *testing.B) *core.DirectedGraph {
+	content, err := ioutil.ReadFile("../histories/list-append/huge-scc.edn")
+	if err != nil {
+		b.Fatal(err)
+	}
+	history, err := core.ParseHistory(string(content))
+	if err != nil {
+		b.Fatal(err)
+	}
+	// the scc only forms with the realtime edges
+	_, g, _ := core.Combine(graph, core.RealtimeGraph)(preProcessHistory(history))
+	return g
+}
+
+// BenchmarkGraph compares the map based DirectedGraph with its compact form on the graph of a huge SCC
+func BenchmarkGraph(b *testing.B) {
+	g := hugeSccGraph(b)
+	cg := core.CompactGraph(g)
+	sccs := cg.StronglyConnectedComponents()
+	if len(sccs) == 0 {
+		b.Fatal("expect a scc")
+	}
+	largest := sccs[0]
+	for _, scc := range sccs {
+		if len(scc) > len(largest) {
+			largest = scc
+		}
+	}
+	b.Logf("%d vertices, %d edges, largest scc of %d vertices", cg.Len(), cg.EdgeCount(), len(largest))
+
+	// ignore the prep work
+	b.ResetTimer()
+
+	b.Run("fork/map", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			g.Fork()
+		}
+	})
+	b.Run("fork/compact", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			core.CompactGraph(g)
+		}
+	})
+	b.Run("filter/map", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			g.FilterRelationships([]core.Rel{core.WW, core.WR})
+		}
+	})
+	b.Run("filter/compact", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			cg.Filter(core.RelMaskOf(core.WW, core.WR))
+		}
+	})
+	b.Run("scc/compact", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			cg.StronglyConnectedComponents()
+		}
+	})
+	b.Run("find-cycle/compact", func(b *testing.B) {
+		b.ReportAllocs()
+		for n := 0; n < b.N; n++ {
+			if _, err := cg.FindCycle(context.Background(), largest); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkCycleSearchParallelism checks a history with a huge SCC by strict serializability
+// with the cycle search of the anomaly specs done by more and more workers
+func BenchmarkCycleSearchParallelism(b *testing.B) {
+	content, err := ioutil.ReadFile("../histories/list-append/huge-scc.edn")
+	if err != nil {
+		b.Fatal(err)
+	}
+	history, err := core.ParseHistory(string(content))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// ignore the prep work
+	b.ResetTimer()
+
+	for _, parallelism := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", parallelism), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				Check(txn.Opts{
+					ConsistencyModels: []core.ConsistencyModelName{"strict-serializable"},
+					Parallelism:       parallelism,
+				}, history)
+			}
+		})
+	}
+}
+
+// BenchmarkCheck runs core.Check as a whole (analysis, SCCs and the cycle explanation of each),
+// on the example history and on the history with a huge SCC
+func BenchmarkCheck(b *testing.B) {
+	for _, name := range []string{"list-append", "huge-scc"} {
+		content, err := ioutil.ReadFile(fmt.Sprintf("../histories/list-append/%s.edn", name))
+		if err != nil {
+			b.Fatal(err)
+		}
+		history, err := core.ParseHistory(string(content))
+		if err != nil {
+			b.Fatal(err)
+		}
+		history = preProcessHistory(history)
+		analyzer := core.Combine(graph, core.RealtimeGraph)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				core.Check(analyzer, history)
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package listappend
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/ngaut/log"
@@ -59,6 +60,16 @@ func (w wwExplainResult) Type() core.DependType {
 	return core.WWDepend
 }
 
+// GetKey returns the key of the dependency
+func (w wwExplainResult) GetKey() string {
+	return w.Key
+}
+
+// MopIndexes returns the indexes of the involved mops
+func (w wwExplainResult) MopIndexes() (int, int) {
+	return w.AMopIndex, w.BMopIndex
+}
+
 // wwExplainer explains write-write dependencies
 type wwExplainer struct {
 	appendIdx
@@ -146,6 +157,16 @@ func (w wrExplainResult) Type() core.DependType {
 	return core.WRDepend
 }
 
+// GetKey returns the key of the dependency
+func (w wrExplainResult) GetKey() string {
+	return w.Key
+}
+
+// MopIndexes returns the indexes of the involved mops
+func (w wrExplainResult) MopIndexes() (int, int) {
+	return w.AMopIndex, w.BMopIndex
+}
+
 // wrExplainer explains write-read dependencies
 type wrExplainer struct {
 	appendIdx
@@ -233,6 +254,16 @@ func (w rwExplainResult) Type() core.DependType {
 	return core.RWDepend
 }
 
+// GetKey returns the key of the dependency
+func (w rwExplainResult) GetKey() string {
+	return w.Key
+}
+
+// MopIndexes returns the indexes of the involved mops
+func (w rwExplainResult) MopIndexes() (int, int) {
+	return w.AMopIndex, w.BMopIndex
+}
+
 // rwExplainer explains read-write anti-dependencies
 type rwExplainer struct {
 	appendIdx
@@ -329,6 +360,12 @@ func graph(history core.History, _ ...interface{}) (core.Anomalies, *core.Direct
 	return a, b, c
 }
 
+// Graph returns the ww, wr and rw dependency graph of a history and the explainer of its edges
+func Graph(history core.History) (*core.DirectedGraph, core.DataExplainer) {
+	_, g, explainer := graph(preProcessHistory(history))
+	return g, explainer
+}
+
 // GCaseTp type aliases []core.Anomaly
 type GCaseTp []core.Anomaly
 
@@ -575,8 +612,36 @@ func dirtyUpdateCases(appendIndexResult map[string][]core.MopValueType, history
 	return cases
 }
 
+func analysis(ctx context.Context, opts txn.Opts, history core.History) core.CheckResult {
+	var analyzer core.Analyzer = graph
+	additionalGraphs := txn.AdditionalGraphs(opts)
+	if len(additionalGraphs) != 0 {
+		analyzer = core.Combine(append([]core.Analyzer{analyzer}, additionalGraphs...)...)
+	}
+	// the cycles are written on a best-effort basis, the check goes on without them
+	if err := txn.ValidatePlotFormat(opts.PlotFormat); opts.Directory != "" && err != nil {
+		log.Warnf("not writing cycles to %s: %v", opts.Directory, err)
+		opts.Directory = ""
+	}
+	checkResult, err := txn.CyclesWithDrawContext(ctx, opts, analyzer, history)
+	if err != nil {
+		log.Warnf("failed to write cycles to %s: %v", opts.Directory, err)
+	}
+	return checkResult
+}
+
+// Analysis returns the cycle analysis of Check with its graph, explainer and cycle anomalies
+func Analysis(opts txn.Opts, history core.History) core.CheckResult {
+	return analysis(context.Background(), opts, preProcessHistory(history))
+}
+
 // Check checks append and read history for list_append
 func Check(opts txn.Opts, history core.History) txn.CheckResult {
+	return CheckContext(context.Background(), opts, history)
+}
+
+// CheckContext is Check with the cycle search bounded by ctx, see txn.Opts for the time budgets
+func CheckContext(ctx context.Context, opts txn.Opts, history core.History) txn.CheckResult {
 	history = preProcessHistory(history)
 	g1a := g1aCases(history)
 	g1b := g1bCases(history)
@@ -586,13 +651,7 @@ func Check(opts txn.Opts, history core.History) txn.CheckResult {
 	dups := duplicates(historyOKOrInfo)
 	sortedValues := sortedValues(historyOKOrInfo)
 	incmpOrder := incompatibleOrders(sortedValues)
-	var analyzer core.Analyzer = graph
-	additionalGraphs := txn.AdditionalGraphs(opts)
-	if len(additionalGraphs) != 0 {
-		analyzer = core.Combine(append([]core.Analyzer{analyzer}, additionalGraphs...)...)
-	}
-
-	checkResult := txn.Cycles(analyzer, history)
+	checkResult := analysis(ctx, opts, history)
 	anomalies := checkResult.Anomalies
 	if len(dups) != 0 {
 		anomalies["duplicate-elements"] = dups
@@ -1,9 +1,12 @@
 package listappend
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -628,91 +631,48 @@ func TestChecker(t *testing.T) {
 			Anomalies:         []string{"read-committed"},
 		}, h))
 
-		require.Equal(t, txn.CheckResult{
+		g2Item := core.CycleExplainerResult{
+			Circle: core.Circle{
+				Path: []core.Op{withIndex(t1, 0), withIndex(t2, 1), withIndex(t1, 0)},
+			},
+			Steps: []core.Step{{RWExplainResult(
+				"y",
+				initMagicNumber,
+				core.MopValueType(1),
+				1,
+				0,
+			)}, {RWExplainResult(
+				"x",
+				initMagicNumber,
+				core.MopValueType(1),
+				1,
+				0,
+			)}},
+			Typ: "G2-item",
+		}
+		// the two rw edges between T1 and T2 are a write skew too
+		writeSkew := g2Item
+		writeSkew.Typ = "write-skew"
+		expected := txn.CheckResult{
 			Valid:        false,
-			AnomalyTypes: []string{"G2-item"},
+			AnomalyTypes: []string{"G2-item", "write-skew"},
 			Anomalies: core.Anomalies{
-				"G2-item": []core.Anomaly{core.CycleExplainerResult{
-					Circle: core.Circle{
-						Path: []core.Op{withIndex(t1, 0), withIndex(t2, 1), withIndex(t1, 0)},
-					},
-					Steps: []core.Step{{RWExplainResult(
-						"y",
-						initMagicNumber,
-						core.MopValueType(1),
-						1,
-						0,
-					)}, {RWExplainResult(
-						"x",
-						initMagicNumber,
-						core.MopValueType(1),
-						1,
-						0,
-					)}},
-					Typ: "G2-item",
-				}},
+				"G2-item":    []core.Anomaly{g2Item},
+				"write-skew": []core.Anomaly{writeSkew},
 			},
 			Not: []string{"repeatable-read"},
-		}, check(txn.Opts{
+		}
+
+		require.Equal(t, expected, check(txn.Opts{
 			ConsistencyModels: []string{},
 			Anomalies:         []string{"G2"},
 		}, h))
 
-		require.Equal(t, txn.CheckResult{
-			Valid:        false,
-			AnomalyTypes: []string{"G2-item"},
-			Anomalies: core.Anomalies{
-				"G2-item": []core.Anomaly{core.CycleExplainerResult{
-					Circle: core.Circle{
-						Path: []core.Op{withIndex(t1, 0), withIndex(t2, 1), withIndex(t1, 0)},
-					},
-					Steps: []core.Step{{RWExplainResult(
-						"y",
-						initMagicNumber,
-						core.MopValueType(1),
-						1,
-						0,
-					)}, {RWExplainResult(
-						"x",
-						initMagicNumber,
-						core.MopValueType(1),
-						1,
-						0,
-					)}},
-					Typ: "G2-item",
-				}},
-			},
-			Not: []string{"repeatable-read"},
-		}, check(txn.Opts{
+		require.Equal(t, expected, check(txn.Opts{
 			ConsistencyModels: []string{"serializable"},
 		}, h))
 
-		require.Equal(t, txn.CheckResult{
-			Valid:        false,
-			AnomalyTypes: []string{"G2-item"},
-			Anomalies: core.Anomalies{
-				"G2-item": []core.Anomaly{core.CycleExplainerResult{
-					Circle: core.Circle{
-						Path: []core.Op{withIndex(t1, 0), withIndex(t2, 1), withIndex(t1, 0)},
-					},
-					Steps: []core.Step{{RWExplainResult(
-						"y",
-						initMagicNumber,
-						core.MopValueType(1),
-						1,
-						0,
-					)}, {RWExplainResult(
-						"x",
-						initMagicNumber,
-						core.MopValueType(1),
-						1,
-						0,
-					)}},
-					Typ: "G2-item",
-				}},
-			},
-			Not: []string{"repeatable-read"},
-		}, check(txn.Opts{
+		require.Equal(t, expected, check(txn.Opts{
 			ConsistencyModels: []string{"repeatable-read"},
 		}, h))
 	}
@@ -950,31 +910,33 @@ func TestRepeatableRead(t *testing.T) {
 	t2 := mustParseOp(`{:type :ok, :value [[:r y nil] [:append x 1]]}`)
 	h := []core.Op{t1, t2}
 
+	g2Item := core.CycleExplainerResult{
+		Circle: core.Circle{
+			Path: []core.Op{withIndex(t1, 0), withIndex(t2, 1), withIndex(t1, 0)},
+		},
+		Steps: []core.Step{{RWExplainResult(
+			"x",
+			initMagicNumber,
+			1,
+			0,
+			1,
+		)}, {RWExplainResult(
+			"y",
+			initMagicNumber,
+			core.MopValueType(1),
+			0,
+			1,
+		)}},
+		Typ: "G2-item",
+	}
+	writeSkew := g2Item
+	writeSkew.Typ = "write-skew"
 	require.Equal(t, txn.CheckResult{
 		Valid:        false,
-		AnomalyTypes: []string{"G2-item"},
+		AnomalyTypes: []string{"G2-item", "write-skew"},
 		Anomalies: core.Anomalies{
-			"G2-item": []core.Anomaly{
-				core.CycleExplainerResult{
-					Circle: core.Circle{
-						Path: []core.Op{withIndex(t1, 0), withIndex(t2, 1), withIndex(t1, 0)},
-					},
-					Steps: []core.Step{{RWExplainResult(
-						"x",
-						initMagicNumber,
-						1,
-						0,
-						1,
-					)}, {RWExplainResult(
-						"y",
-						initMagicNumber,
-						core.MopValueType(1),
-						0,
-						1,
-					)}},
-					Typ: "G2-item",
-				},
-			},
+			"G2-item":    []core.Anomaly{g2Item},
+			"write-skew": []core.Anomaly{writeSkew},
 		},
 		Not: []string{"repeatable-read"},
 	}, check(txn.Opts{
@@ -982,16 +944,24 @@ func TestRepeatableRead(t *testing.T) {
 	}, h))
 }
 
+// gNonadjacentHistory is the invocations and completions of T1 to T4, where T2 observes the append of T1
+// but not that of T3, and T4 that of T3 but not that of T1
+func gNonadjacentHistory() []core.Op {
+	return []core.Op{
+		mustParseOp(`{:index 0, :type :invoke, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 1, :type :ok, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 2, :type :invoke, :value [[:r x [1]] [:r y nil]]}`),
+		mustParseOp(`{:index 3, :type :ok, :value [[:r x [1]] [:r y nil]]}`),
+		mustParseOp(`{:index 4, :type :invoke, :value [[:append y 1]]}`),
+		mustParseOp(`{:index 5, :type :ok, :value [[:append y 1]]}`),
+		mustParseOp(`{:index 6, :type :invoke, :value [[:r y [1]] [:r x nil]]}`),
+		mustParseOp(`{:index 7, :type :ok, :value [[:r y [1]] [:r x nil]]}`),
+	}
+}
+
 func TestGNonadjacent(t *testing.T) {
-	t1 := mustParseOp(`{:index 0, :type :invoke, :value [[:append x 1]]}`)
-	t1p := mustParseOp(`{:index 1, :type :ok, :value [[:append x 1]]}`)
-	t2 := mustParseOp(`{:index 2, :type :invoke, :value [[:r x [1]] [:r y nil]]}`)
-	t2p := mustParseOp(`{:index 3, :type :ok, :value [[:r x [1]] [:r y nil]]}`)
-	t3 := mustParseOp(`{:index 4, :type :invoke, :value [[:append y 1]]}`)
-	t3p := mustParseOp(`{:index 5, :type :ok, :value [[:append y 1]]}`)
-	t4 := mustParseOp(`{:index 6, :type :invoke, :value [[:r y [1]] [:r x nil]]}`)
-	t4p := mustParseOp(`{:index 7, :type :ok, :value [[:r y [1]] [:r x nil]]}`)
-	h := []core.Op{t1, t1p, t2, t2p, t3, t3p, t4, t4p}
+	h := gNonadjacentHistory()
+	t1p, t2p, t3p, t4p := h[1], h[3], h[5], h[7]
 
 	got := check(txn.Opts{}, h)
 
@@ -1036,6 +1006,166 @@ func TestGNonadjacent(t *testing.T) {
 
 }
 
+func TestCheckWritesCycles(t *testing.T) {
+	h := gNonadjacentHistory()
+
+	for _, format := range []string{"svg", "dot"} {
+		dir := t.TempDir()
+		result := Check(txn.Opts{Directory: dir, PlotFormat: format}, h)
+		require.Equal(t, []string{"G-nonadjacent"}, result.AnomalyTypes)
+
+		cycles, err := os.ReadFile(filepath.Join(dir, "cycles.txt"))
+		require.NoError(t, err)
+		require.Contains(t, string(cycles), "a contradiction!")
+
+		explanation, err := os.ReadFile(filepath.Join(dir, "G-nonadjacent.txt"))
+		require.NoError(t, err)
+		require.Contains(t, string(explanation), "Let:\n  T1 = ")
+		require.Contains(t, string(explanation), "T1 < T2, because T2 observed T1's append of 1 to key x")
+
+		plot, err := os.ReadFile(filepath.Join(dir, "G-nonadjacent", "0."+format))
+		require.NoError(t, err)
+		require.Contains(t, string(plot), "T7")
+	}
+
+	// nothing is written without a directory
+	require.Equal(t, Check(txn.Opts{}, h), Check(txn.Opts{PlotFormat: "dot"}, h))
+
+	// the cycles are not written with an invalid format or an unwritable directory, the result is the same
+	dir := t.TempDir()
+	require.Equal(t, Check(txn.Opts{}, h), Check(txn.Opts{Directory: dir, PlotFormat: "jpg"}, h))
+	_, err := os.Stat(filepath.Join(dir, "cycles.txt"))
+	require.True(t, os.IsNotExist(err))
+	file := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(file, nil, 0644))
+	require.Equal(t, []string{"G-nonadjacent"}, Check(txn.Opts{Directory: file, PlotFormat: "dot"}, h).AnomalyTypes)
+}
+
+func TestCheckCycleSearchTimeout(t *testing.T) {
+	h := gNonadjacentHistory()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	for _, result := range []txn.CheckResult{
+		CheckContext(canceled, txn.Opts{}, h),
+		CheckContext(expired, txn.Opts{CycleSearchTimeout: time.Minute}, h),
+	} {
+		require.True(t, result.IsUnknown)
+		require.False(t, result.Valid)
+		require.Equal(t, []string{"cycle-search-timeout"}, result.AnomalyTypes)
+		timeout := result.Anomalies["cycle-search-timeout"][0].(txn.CycleSearchTimeout)
+		require.Equal(t, 4, timeout.SccSize)
+		require.Empty(t, timeout.DoesNotContain)
+	}
+
+	// the cycle is found within a generous budget
+	result := Check(txn.Opts{CycleSearchTimeout: time.Minute, CycleSearchBudget: time.Minute}, h)
+	require.Equal(t, []string{"G-nonadjacent"}, result.AnomalyTypes)
+}
+
+func TestCheckParallel(t *testing.T) {
+	content, err := ioutil.ReadFile("../histories/list-append/list-append.edn")
+	require.NoError(t, err)
+	history, err := core.ParseHistory(string(content))
+	require.NoError(t, err)
+
+	opts := txn.Opts{ConsistencyModels: []core.ConsistencyModelName{"strict-serializable"}}
+	serial := Check(opts, history)
+	require.False(t, serial.Valid)
+	for _, parallelism := range []int{2, 8} {
+		opts.Parallelism = parallelism
+		require.Equal(t, serial, Check(opts, history))
+	}
+}
+
+func TestCheckAdditionalGraphs(t *testing.T) {
+	// T2 misses the append of T1 by the same process, T4 misses the append of T3 by another process
+	h := []core.Op{
+		mustParseOp(`{:index 0, :type :invoke, :process 0, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 1, :type :ok, :process 0, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 2, :type :invoke, :process 0, :value [[:r x nil]]}`),
+		mustParseOp(`{:index 3, :type :ok, :process 0, :value [[:r x []]]}`),
+		mustParseOp(`{:index 4, :type :invoke, :process 1, :value [[:append y 1]]}`),
+		mustParseOp(`{:index 5, :type :ok, :process 1, :value [[:append y 1]]}`),
+		mustParseOp(`{:index 6, :type :invoke, :process 2, :value [[:r y nil]]}`),
+		mustParseOp(`{:index 7, :type :ok, :process 2, :value [[:r y []]]}`),
+	}
+	models := []core.ConsistencyModelName{"strict-serializable"}
+
+	// only the realtime graph is inferred, the process order of T1 and T2 is a realtime order too
+	result := Check(txn.Opts{ConsistencyModels: models}, h)
+	require.Equal(t, []string{"G-single-realtime"}, result.AnomalyTypes)
+	require.Len(t, result.Anomalies["G-single-realtime"], 2)
+
+	result = Check(txn.Opts{ConsistencyModels: models, Graphs: txn.ProcessGraph | txn.RealtimeGraph}, h)
+	require.ElementsMatch(t, []string{"G-single-process", "G-single-realtime"}, result.AnomalyTypes)
+	require.Len(t, result.Anomalies["G-single-process"], 1)
+	require.Len(t, result.Anomalies["G-single-realtime"], 2)
+}
+
+func TestCheckTimeGraph(t *testing.T) {
+	// T2 misses the append of T1, which committed 100ns before
+	h := []core.Op{
+		mustParseOp(`{:index 0, :type :invoke, :process 0, :time 0, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 1, :type :invoke, :process 1, :time 10, :value [[:r x nil]]}`),
+		mustParseOp(`{:index 2, :type :ok, :process 0, :time 100, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 3, :type :ok, :process 1, :time 200, :value [[:r x []]]}`),
+	}
+	models := []core.ConsistencyModelName{"serializable"}
+
+	require.True(t, Check(txn.Opts{ConsistencyModels: models}, h).Valid)
+
+	result := Check(txn.Opts{ConsistencyModels: models, Graphs: txn.TimeGraph}, h)
+	require.False(t, result.Valid)
+	require.Equal(t, []string{"G-single-time"}, result.AnomalyTypes)
+
+	// the commits are within the clock skew
+	require.True(t, Check(txn.Opts{ConsistencyModels: models, Graphs: txn.TimeGraph, ClockSkew: 100}, h).Valid)
+}
+
+func TestCheckTimeGraphWeakModel(t *testing.T) {
+	// T2 misses the append of T1, which committed 100ns before
+	h := []core.Op{
+		mustParseOp(`{:index 0, :type :invoke, :process 0, :time 0, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 1, :type :invoke, :process 1, :time 10, :value [[:r x nil]]}`),
+		mustParseOp(`{:index 2, :type :ok, :process 0, :time 100, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 3, :type :ok, :process 1, :time 200, :value [[:r x []]]}`),
+	}
+
+	// read-committed allows G-single, so it allows G-single-time too
+	require.True(t, Check(txn.Opts{ConsistencyModels: []core.ConsistencyModelName{"read-committed"}, Graphs: txn.TimeGraph}, h).Valid)
+
+	// asking for G-single-time prohibits it whatever the models
+	result := Check(txn.Opts{ConsistencyModels: []core.ConsistencyModelName{"read-committed"}, Anomalies: []string{"G-single-time"}, Graphs: txn.TimeGraph}, h)
+	require.False(t, result.Valid)
+	require.Equal(t, []string{"G-single-time"}, result.AnomalyTypes)
+}
+
+func TestCheckRealtimeEpsilon(t *testing.T) {
+	// T2 misses the append of T1, it is invoked after T1 completed by the history but 5ns before by its :time
+	h := []core.Op{
+		mustParseOp(`{:index 0, :type :invoke, :process 0, :time 0, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 1, :type :ok, :process 0, :time 100, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 2, :type :invoke, :process 1, :time 95, :value [[:r x nil]]}`),
+		mustParseOp(`{:index 3, :type :ok, :process 1, :time 200, :value [[:r x []]]}`),
+	}
+	models := []core.ConsistencyModelName{"strict-serializable"}
+
+	result := Check(txn.Opts{ConsistencyModels: models}, h)
+	require.False(t, result.Valid)
+	require.Equal(t, []string{"G-single-realtime"}, result.AnomalyTypes)
+
+	require.True(t, Check(txn.Opts{ConsistencyModels: models, RealtimeEpsilon: 10}, h).Valid)
+
+	// a gap beyond the uncertainty is still a realtime order
+	h[2] = mustParseOp(`{:index 2, :type :invoke, :process 1, :time 150, :value [[:r x nil]]}`)
+	result = Check(txn.Opts{ConsistencyModels: models, RealtimeEpsilon: 10}, h)
+	require.False(t, result.Valid)
+	require.Equal(t, []string{"G-single-realtime"}, result.AnomalyTypes)
+}
+
 func TestCheck(t *testing.T) {
 	var history = core.History{
 		core.Op{Type: core.OpTypeOk,
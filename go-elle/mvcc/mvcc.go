@@ -0,0 +1,496 @@
+// Package mvcc is an in-memory key-value store with list-append and register semantics and a
+// selectable concurrency control, used as a reference database: the histories it records under an
+// isolation level satisfy exactly that level, which makes end-to-end tests of the checkers
+// meaningful without ArangoDB.
+//
+// Txns run one micro-op at a time, so that a scheduler can interleave them deterministically:
+//
+//	txn := db.Begin(process)
+//	mop, err := txn.Do(core.Append("1", 3)) // ErrWait: retry later, ErrAbort: the txn is over
+//	err = txn.Commit()
+package mvcc
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+)
+
+// Isolation is a concurrency control of the database
+type Isolation string
+
+const (
+	// Serializable2PL is strict two-phase locking with shared and exclusive locks
+	Serializable2PL Isolation = "serializable-2pl"
+	// SSI is snapshot isolation aborting the pivots of dangerous structures (Cahill et al.)
+	SSI Isolation = "ssi"
+	// SnapshotIsolation reads from a snapshot taken at begin, the first committer wins
+	SnapshotIsolation Isolation = "si"
+	// PSI runs txns on replicated sites that apply remote commits asynchronously in causal order,
+	// conflicting writes are aborted globally (as in Walter)
+	PSI Isolation = "psi"
+	// ReadCommitted reads the latest committed values, writes hold exclusive locks until commit
+	ReadCommitted Isolation = "read-committed"
+	// ReadUncommitted writes in place under exclusive locks held until commit, reads take no locks
+	ReadUncommitted Isolation = "read-uncommitted"
+)
+
+// Isolations lists every isolation from the strongest to the weakest
+var Isolations = []Isolation{Serializable2PL, SSI, SnapshotIsolation, PSI, ReadCommitted, ReadUncommitted}
+
+// Guarantees are the GRAIL levels the histories of an isolation satisfy
+var Guarantees = map[Isolation][]string{
+	Serializable2PL:   {"pl-1", "pl-2", "psi", "si", "ser"},
+	SSI:               {"pl-1", "pl-2", "psi", "si", "ser"},
+	SnapshotIsolation: {"pl-1", "pl-2", "psi", "si"},
+	PSI:               {"pl-1", "pl-2", "psi"},
+	ReadCommitted:     {"pl-1", "pl-2"},
+	ReadUncommitted:   {"pl-1"},
+}
+
+var (
+	// ErrWait means that the micro-op waits for a lock, it should be retried later
+	ErrWait = errors.New("waiting for a lock")
+	// ErrAbort means that the txn was aborted, it has no effect
+	ErrAbort = errors.New("aborted")
+)
+
+type lock struct {
+	shared    map[*Txn]bool
+	exclusive *Txn
+}
+
+type commit struct {
+	seq    int
+	txn    *Txn
+	writes []core.Mop
+	// the commits visible to the txn, for PSI
+	deps map[int]bool
+}
+
+// site is a replica of the PSI database
+type site struct {
+	state   map[string][]int
+	applied map[int]bool
+}
+
+type delivery struct {
+	commit *commit
+	site   int
+}
+
+// DB is the database, it is not safe for concurrent use: txns are interleaved by the caller
+type DB struct {
+	isolation Isolation
+	workload  generator.Workload
+	// the latest committed state (dirty under read uncommitted)
+	state    map[string][]int
+	versions map[string][]int
+	locks    map[string]*lock
+	nextID   int
+	seq      int
+	commits  []*commit
+	writers  map[string][]*commit
+	active   map[*Txn]bool
+	sites    []*site
+	pending  []delivery
+}
+
+// New creates an empty database; sites is the number of replicas under PSI
+func New(isolation Isolation, workload generator.Workload, sites int) (*DB, error) {
+	if _, ok := Guarantees[isolation]; !ok {
+		return nil, fmt.Errorf("invalid isolation %s", isolation)
+	}
+	if workload != generator.ListAppend && workload != generator.RWRegister {
+		return nil, fmt.Errorf("invalid workload %s", workload)
+	}
+	if sites < 1 {
+		sites = 1
+	}
+	db := &DB{
+		isolation: isolation,
+		workload:  workload,
+		state:     map[string][]int{},
+		versions:  map[string][]int{},
+		locks:     map[string]*lock{},
+		writers:   map[string][]*commit{},
+		active:    map[*Txn]bool{},
+	}
+	if isolation == PSI {
+		for i := 0; i < sites; i++ {
+			db.sites = append(db.sites, &site{state: map[string][]int{}, applied: map[int]bool{}})
+		}
+	}
+	return db, nil
+}
+
+// Versions returns the values of each key in the order they were installed
+func (db *DB) Versions() map[string][]int {
+	return db.versions
+}
+
+// Txn is a txn in flight
+type Txn struct {
+	db *DB
+	// begin order, older txns have smaller ids
+	id    int
+	start int
+	site  int
+	// the snapshot under SI, SSI and PSI, and the commits it holds under PSI
+	snapshot map[string][]int
+	applied  map[int]bool
+	// the values written by the txn, overlaid on the reads
+	buffer map[string][]int
+	writes []core.Mop
+	reads  map[string]bool
+	held   map[string]bool
+	// before-images of the keys written in place under read uncommitted
+	undo map[string][]int
+	// rw-antidependencies with concurrent txns, under SSI
+	in, out bool
+	done    bool
+}
+
+// Begin starts a txn, on site s % sites under PSI
+func (db *DB) Begin(s int) *Txn {
+	db.nextID++
+	t := &Txn{
+		db:     db,
+		id:     db.nextID,
+		start:  db.seq,
+		buffer: map[string][]int{},
+		reads:  map[string]bool{},
+		held:   map[string]bool{},
+		undo:   map[string][]int{},
+	}
+	switch db.isolation {
+	case SnapshotIsolation, SSI:
+		t.snapshot = copyState(db.state)
+	case PSI:
+		t.site = s % len(db.sites)
+		t.snapshot = copyState(db.sites[t.site].state)
+		t.applied = make(map[int]bool, len(db.sites[t.site].applied))
+		for seq := range db.sites[t.site].applied {
+			t.applied[seq] = true
+		}
+	}
+	db.active[t] = true
+	return t
+}
+
+func copyState(state map[string][]int) map[string][]int {
+	c := make(map[string][]int, len(state))
+	for k, v := range state {
+		c[k] = v
+	}
+	return c
+}
+
+// Do executes a micro-op, reads return their values
+func (t *Txn) Do(mop core.Mop) (core.Mop, error) {
+	if t.done {
+		return mop, ErrAbort
+	}
+	k := mop.GetKey()
+	if mop.IsRead() {
+		if t.db.isolation == Serializable2PL {
+			if err := t.lock(k, false); err != nil {
+				return mop, err
+			}
+		}
+		return t.read(k), nil
+	}
+
+	switch t.db.isolation {
+	case Serializable2PL, ReadCommitted, ReadUncommitted:
+		if err := t.lock(k, true); err != nil {
+			return mop, err
+		}
+	}
+	v := mop.GetValue().(int)
+	if t.db.isolation == ReadUncommitted {
+		// in place, visible to every reader
+		if _, ok := t.undo[k]; !ok {
+			t.undo[k] = t.db.state[k]
+		}
+		t.db.state[k] = t.db.apply(t.db.state[k], v)
+		t.db.versions[k] = append(t.db.versions[k], v)
+	} else {
+		t.buffer[k] = t.db.apply(t.view(k), v)
+	}
+	t.writes = append(t.writes, mop)
+	return mop, nil
+}
+
+// the value of a key after a write of v
+func (db *DB) apply(current []int, v int) []int {
+	if db.workload == generator.RWRegister {
+		return []int{v}
+	}
+	return append(append([]int{}, current...), v)
+}
+
+// the value of a key as the txn sees it
+func (t *Txn) view(k string) []int {
+	if v, ok := t.buffer[k]; ok {
+		return v
+	}
+	if t.snapshot != nil {
+		return t.snapshot[k]
+	}
+	return t.db.state[k]
+}
+
+func (t *Txn) read(k string) core.Mop {
+	if _, ok := t.buffer[k]; !ok {
+		t.reads[k] = true
+	}
+	v := t.view(k)
+	if t.db.workload == generator.RWRegister {
+		if len(v) == 0 {
+			return core.ReadRW(k, 0)
+		}
+		return core.ReadRW(k, v[len(v)-1])
+	}
+	return core.Read(k, append([]int{}, v...))
+}
+
+// lock acquires a lock on k, with wait-die: a txn only waits for younger ones, else it aborts
+func (t *Txn) lock(k string, exclusive bool) error {
+	l, ok := t.db.locks[k]
+	if !ok {
+		l = &lock{shared: map[*Txn]bool{}}
+		t.db.locks[k] = l
+	}
+	var holders []*Txn
+	if l.exclusive != nil && l.exclusive != t {
+		holders = append(holders, l.exclusive)
+	}
+	if exclusive {
+		for h := range l.shared {
+			if h != t {
+				holders = append(holders, h)
+			}
+		}
+	}
+	for _, h := range holders {
+		if h.id < t.id {
+			t.Abort()
+			return ErrAbort
+		}
+	}
+	if len(holders) > 0 {
+		return ErrWait
+	}
+	if exclusive {
+		l.exclusive = t
+	} else {
+		l.shared[t] = true
+	}
+	t.held[k] = true
+	return nil
+}
+
+func (t *Txn) release() {
+	for k := range t.held {
+		l := t.db.locks[k]
+		delete(l.shared, t)
+		if l.exclusive == t {
+			l.exclusive = nil
+		}
+	}
+	t.held = map[string]bool{}
+	delete(t.db.active, t)
+	t.done = true
+}
+
+// Abort rolls the txn back
+func (t *Txn) Abort() {
+	if t.done {
+		return
+	}
+	for k, before := range t.undo {
+		t.db.state[k] = before
+	}
+	for _, mop := range t.writes {
+		if t.db.isolation == ReadUncommitted {
+			t.db.versions[mop.GetKey()] = remove(t.db.versions[mop.GetKey()], mop.GetValue().(int))
+		}
+	}
+	t.release()
+}
+
+func remove(vs []int, v int) []int {
+	kept := make([]int, 0, len(vs))
+	for _, x := range vs {
+		if x != v {
+			kept = append(kept, x)
+		}
+	}
+	return kept
+}
+
+// Commit commits the txn, or aborts it with ErrAbort
+func (t *Txn) Commit() error {
+	if t.done {
+		return ErrAbort
+	}
+	db := t.db
+	switch db.isolation {
+	case SnapshotIsolation, SSI:
+		if t.writeConflict(func(c *commit) bool { return c.seq > t.start }) {
+			t.Abort()
+			return ErrAbort
+		}
+		if db.isolation == SSI && !t.checkPivots() {
+			t.Abort()
+			return ErrAbort
+		}
+	case PSI:
+		if t.writeConflict(func(c *commit) bool { return !t.applied[c.seq] }) {
+			t.Abort()
+			return ErrAbort
+		}
+	}
+
+	db.seq++
+	c := &commit{seq: db.seq, txn: t, writes: t.writes, deps: t.applied}
+	db.commits = append(db.commits, c)
+	keys := map[string]bool{}
+	for _, mop := range t.writes {
+		keys[mop.GetKey()] = true
+	}
+	for k := range keys {
+		db.writers[k] = append(db.writers[k], c)
+	}
+	switch db.isolation {
+	case ReadUncommitted:
+		// already in place
+	case PSI:
+		db.sites[t.site].install(db, c)
+		for i := range db.sites {
+			if i != t.site {
+				db.pending = append(db.pending, delivery{commit: c, site: i})
+			}
+		}
+	default:
+		db.install(c, db.state)
+	}
+	if db.isolation != ReadUncommitted {
+		for _, mop := range t.writes {
+			db.versions[mop.GetKey()] = append(db.versions[mop.GetKey()], mop.GetValue().(int))
+		}
+	}
+	t.release()
+	return nil
+}
+
+func (db *DB) install(c *commit, state map[string][]int) {
+	for _, mop := range c.writes {
+		k := mop.GetKey()
+		state[k] = db.apply(state[k], mop.GetValue().(int))
+	}
+}
+
+func (s *site) install(db *DB, c *commit) {
+	db.install(c, s.state)
+	s.applied[c.seq] = true
+}
+
+// whether a commit the filter holds for wrote a key the txn writes
+func (t *Txn) writeConflict(concurrent func(c *commit) bool) bool {
+	for _, mop := range t.writes {
+		for _, c := range t.db.writers[mop.GetKey()] {
+			if concurrent(c) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func intersects(reads map[string]bool, writes []core.Mop) bool {
+	for _, mop := range writes {
+		if reads[mop.GetKey()] {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPivots records the rw-antidependencies between the txn and the concurrent ones, it fails
+// if the txn or a committed txn would have both an incoming and an outgoing one
+func (t *Txn) checkPivots() bool {
+	in, out := t.in, t.out
+	var outTo, inFrom []*Txn
+	for _, c := range t.db.commits {
+		if c.seq <= t.start {
+			continue
+		}
+		// t did not see c
+		if intersects(t.reads, c.writes) {
+			out = true
+			outTo = append(outTo, c.txn)
+		}
+		// c did not see t
+		if intersects(c.txn.reads, t.writes) {
+			in = true
+			inFrom = append(inFrom, c.txn)
+		}
+	}
+	for u := range t.db.active {
+		if u != t && intersects(u.reads, t.writes) {
+			in = true
+			inFrom = append(inFrom, u)
+		}
+	}
+	if in && out {
+		return false
+	}
+	for _, u := range outTo {
+		if u.out {
+			return false
+		}
+	}
+	for _, u := range inFrom {
+		if u.done && u.in {
+			return false
+		}
+	}
+	t.in, t.out = in, out
+	for _, u := range outTo {
+		u.in = true
+	}
+	for _, u := range inFrom {
+		u.out = true
+	}
+	return true
+}
+
+// Deliver applies a pending remote commit whose dependencies the site holds, under PSI;
+// pick chooses among the deliverable ones, it returns false if there is none
+func (db *DB) Deliver(pick func(n int) int) bool {
+	var ready []int
+	for i, d := range db.pending {
+		deliverable := true
+		for seq := range d.commit.deps {
+			if !db.sites[d.site].applied[seq] {
+				deliverable = false
+				break
+			}
+		}
+		if deliverable {
+			ready = append(ready, i)
+		}
+	}
+	if len(ready) == 0 {
+		return false
+	}
+	i := ready[pick(len(ready))]
+	d := db.pending[i]
+	db.pending = append(db.pending[:i], db.pending[i+1:]...)
+	db.sites[d.site].install(db, d.commit)
+	return true
+}
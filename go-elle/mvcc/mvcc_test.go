@@ -0,0 +1,162 @@
+package mvcc
+
+import (
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-elle/list_append"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+)
+
+func mustNew(t *testing.T, isolation Isolation, sites int) *DB {
+	db, err := New(isolation, generator.ListAppend, sites)
+	if err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	return db
+}
+
+func mustDo(t *testing.T, txn *Txn, mop core.Mop) core.Mop {
+	done, err := txn.Do(mop)
+	if err != nil {
+		t.Fatalf("expect no error for %s, got %v", mop, err)
+	}
+	return done
+}
+
+func expectRead(t *testing.T, txn *Txn, k string, expected string) {
+	if read := mustDo(t, txn, core.Read(k, nil)); read.String() != expected {
+		t.Fatalf("expect %s, got %s", expected, read)
+	}
+}
+
+func TestWaitDie(t *testing.T) {
+	db := mustNew(t, Serializable2PL, 1)
+	older, younger := db.Begin(0), db.Begin(1)
+	mustDo(t, younger, core.Append("x", 1))
+	// the older txn waits for the younger one
+	if _, err := older.Do(core.Read("x", nil)); err != ErrWait {
+		t.Fatalf("expect ErrWait, got %v", err)
+	}
+	mustDo(t, older, core.Append("y", 1))
+	// the younger txn dies
+	if _, err := younger.Do(core.Read("y", nil)); err != ErrAbort {
+		t.Fatalf("expect ErrAbort, got %v", err)
+	}
+	expectRead(t, older, "x", "[:r x []]")
+	if err := older.Commit(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+}
+
+func TestFirstCommitterWins(t *testing.T) {
+	db := mustNew(t, SnapshotIsolation, 1)
+	t1, t2 := db.Begin(0), db.Begin(1)
+	mustDo(t, t1, core.Append("x", 1))
+	mustDo(t, t2, core.Append("x", 2))
+	if err := t1.Commit(); err != nil {
+		t.Fatalf("expect no error, got %v", err)
+	}
+	if err := t2.Commit(); err != ErrAbort {
+		t.Fatalf("expect ErrAbort, got %v", err)
+	}
+}
+
+func TestWriteSkew(t *testing.T) {
+	for _, c := range []struct {
+		isolation Isolation
+		abort     bool
+	}{{SnapshotIsolation, false}, {SSI, true}} {
+		db := mustNew(t, c.isolation, 1)
+		t1, t2 := db.Begin(0), db.Begin(1)
+		expectRead(t, t1, "x", "[:r x []]")
+		expectRead(t, t2, "y", "[:r y []]")
+		mustDo(t, t1, core.Append("y", 1))
+		mustDo(t, t2, core.Append("x", 1))
+		if err := t1.Commit(); err != nil {
+			t.Fatalf("expect no error, got %v", err)
+		}
+		if err := t2.Commit(); (err == ErrAbort) != c.abort {
+			t.Fatalf("%s: expect the second commit to abort: %v, got %v", c.isolation, c.abort, err)
+		}
+	}
+}
+
+func TestLongFork(t *testing.T) {
+	db := mustNew(t, PSI, 2)
+	t1, t2 := db.Begin(0), db.Begin(1)
+	mustDo(t, t1, core.Append("x", 1))
+	mustDo(t, t2, core.Append("y", 1))
+	if t1.Commit() != nil || t2.Commit() != nil {
+		t.Fatal("expect both commits to succeed")
+	}
+	// each site only holds its own commit
+	t3, t4 := db.Begin(0), db.Begin(1)
+	expectRead(t, t3, "x", "[:r x [1]]")
+	expectRead(t, t3, "y", "[:r y []]")
+	expectRead(t, t4, "x", "[:r x []]")
+	expectRead(t, t4, "y", "[:r y [1]]")
+	for db.Deliver(func(n int) int { return 0 }) {
+	}
+	expectRead(t, db.Begin(0), "y", "[:r y [1]]")
+}
+
+func TestDirtyRead(t *testing.T) {
+	db := mustNew(t, ReadUncommitted, 1)
+	t1, t2 := db.Begin(0), db.Begin(1)
+	mustDo(t, t1, core.Append("x", 1))
+	expectRead(t, t2, "x", "[:r x [1]]")
+	t1.Abort()
+	expectRead(t, t2, "x", "[:r x []]")
+	if len(db.Versions()["x"]) != 0 {
+		t.Fatalf("expect the aborted append to be rolled back, got %v", db.Versions())
+	}
+}
+
+func TestRunConsistencyModels(t *testing.T) {
+	models := map[Isolation]core.ConsistencyModelName{
+		Serializable2PL:   "strict-serializable",
+		SSI:               "serializable",
+		SnapshotIsolation: "snapshot-isolation",
+		PSI:               "parallel-snapshot-isolation",
+		ReadCommitted:     "read-committed",
+		ReadUncommitted:   "read-uncommitted",
+	}
+	for _, isolation := range Isolations {
+		anomalous := false
+		for seed := int64(1); seed <= 10; seed++ {
+			opts := DefaultOpts(isolation)
+			opts.Txns, opts.Seed = 30, seed
+			r, err := Run(opts)
+			if err != nil {
+				t.Fatalf("expect no error, got %v", err)
+			}
+			res := listappend.Check(txn.Opts{ConsistencyModels: []core.ConsistencyModelName{models[isolation]}}, r.History)
+			if !res.Valid {
+				t.Fatalf("%s seed %d: expect a %s history, got %v", isolation, seed, models[isolation], res.AnomalyTypes)
+			}
+			anomalous = anomalous || !listappend.Check(txn.Opts{}, r.History).Valid
+		}
+		// only the serializable isolations never violate strict serializability
+		if serializable := isolation == Serializable2PL || isolation == SSI; anomalous == serializable {
+			t.Fatalf("%s: expect anomalies: %v, got %v", isolation, !serializable, anomalous)
+		}
+	}
+}
+
+func TestRunInvalidOpts(t *testing.T) {
+	for _, update := range []func(*Opts){
+		func(o *Opts) { o.Workload = "bank" },
+		func(o *Opts) { o.Clients = 0 },
+		func(o *Opts) { o.KeyCount = 0 },
+		func(o *Opts) { o.MinTxnLength = 0 },
+		func(o *Opts) { o.MinTxnLength, o.MaxTxnLength = 4, 2 },
+	} {
+		opts := DefaultOpts(SnapshotIsolation)
+		update(&opts)
+		if _, err := Run(opts); err == nil {
+			t.Fatalf("expect an error for %+v", opts)
+		}
+	}
+}
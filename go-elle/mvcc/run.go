@@ -0,0 +1,175 @@
+package mvcc
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+)
+
+// Recorder records the ops of clients as a Jepsen-style history, with logical times
+type Recorder struct {
+	history core.History
+}
+
+// Invoke records the invocation of a txn, reads have nil values
+func (r *Recorder) Invoke(process int, mops []core.Mop) core.Op {
+	invoked := make([]core.Mop, 0, len(mops))
+	for _, mop := range mops {
+		if mop.IsRead() {
+			invoked = append(invoked, core.Read(mop.GetKey(), nil))
+		} else {
+			invoked = append(invoked, mop)
+		}
+	}
+	return r.record(core.OpTypeInvoke, process, invoked)
+}
+
+// Complete records the completion of a txn
+func (r *Recorder) Complete(tp core.OpType, process int, mops []core.Mop) core.Op {
+	return r.record(tp, process, mops)
+}
+
+func (r *Recorder) record(tp core.OpType, process int, mops []core.Mop) core.Op {
+	op := core.Op{
+		Index:   core.NewOptInt(len(r.history)),
+		Process: core.NewOptInt(process),
+		Time:    time.Unix(0, int64(len(r.history)+1)),
+		Type:    tp,
+		Value:   &mops,
+	}
+	r.history = append(r.history, op)
+	return op
+}
+
+// History returns the ops recorded so far
+func (r *Recorder) History() core.History {
+	return r.history
+}
+
+// Opts configures a run of concurrent clients against the database
+type Opts struct {
+	Isolation Isolation
+	Workload  generator.Workload
+	// Clients run concurrently, each with at most one txn in flight
+	Clients      int
+	Txns         int
+	KeyCount     int
+	MinTxnLength int
+	MaxTxnLength int
+	ReadRatio    float64
+	// Sites are the replicas under PSI, clients are spread over them;
+	// DeliveryRate is the probability of a scheduling step to replicate a commit
+	Sites        int
+	DeliveryRate float64
+	Seed         int64
+}
+
+// DefaultOpts returns default opts
+func DefaultOpts(isolation Isolation) Opts {
+	return Opts{
+		Isolation:    isolation,
+		Workload:     generator.ListAppend,
+		Clients:      5,
+		Txns:         100,
+		KeyCount:     6,
+		MinTxnLength: 1,
+		MaxTxnLength: 4,
+		ReadRatio:    0.5,
+		Sites:        2,
+		DeliveryRate: 0.05,
+		Seed:         1,
+	}
+}
+
+type client struct {
+	process int
+	txn     *Txn
+	mops    []core.Mop
+	done    []core.Mop
+}
+
+// Run interleaves the micro-ops of concurrent clients at random and records their history;
+// a txn is invoked when it begins and completes when it commits (:ok) or aborts (:fail)
+func Run(opts Opts) (generator.Result, error) {
+	if opts.Clients < 1 || opts.KeyCount < 1 || opts.MinTxnLength < 1 || opts.MaxTxnLength < opts.MinTxnLength {
+		return generator.Result{}, fmt.Errorf("invalid opts %+v", opts)
+	}
+	db, err := New(opts.Isolation, opts.Workload, opts.Sites)
+	if err != nil {
+		return generator.Result{}, err
+	}
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	recorder := &Recorder{}
+	nextValue := map[string]int{}
+	clients := make([]*client, opts.Clients)
+	for i := range clients {
+		clients[i] = &client{process: i}
+	}
+
+	started := 0
+	for {
+		if len(db.pending) > 0 && rnd.Float64() < opts.DeliveryRate && db.Deliver(rnd.Intn) {
+			continue
+		}
+		var ready []*client
+		for _, c := range clients {
+			if c.txn != nil || started < opts.Txns {
+				ready = append(ready, c)
+			}
+		}
+		if len(ready) == 0 {
+			break
+		}
+		c := ready[rnd.Intn(len(ready))]
+		switch {
+		case c.txn == nil:
+			c.mops = workloadMops(rnd, opts, nextValue)
+			c.done = nil
+			recorder.Invoke(c.process, c.mops)
+			c.txn = db.Begin(c.process)
+			started++
+		case len(c.done) < len(c.mops):
+			mop, err := c.txn.Do(c.mops[len(c.done)])
+			switch err {
+			case ErrWait:
+			case ErrAbort:
+				recorder.Complete(core.OpTypeFail, c.process, c.mops)
+				c.txn = nil
+			default:
+				c.done = append(c.done, mop)
+			}
+		default:
+			if c.txn.Commit() == nil {
+				recorder.Complete(core.OpTypeOk, c.process, c.done)
+			} else {
+				recorder.Complete(core.OpTypeFail, c.process, c.mops)
+			}
+			c.txn = nil
+		}
+	}
+	return generator.Result{History: recorder.History(), Versions: db.Versions()}, nil
+}
+
+// the mops of a txn, with unique values per key
+func workloadMops(rnd *rand.Rand, opts Opts, nextValue map[string]int) []core.Mop {
+	n := opts.MinTxnLength + rnd.Intn(opts.MaxTxnLength-opts.MinTxnLength+1)
+	mops := make([]core.Mop, 0, n)
+	for i := 0; i < n; i++ {
+		k := strconv.Itoa(rnd.Intn(opts.KeyCount))
+		switch {
+		case rnd.Float64() < opts.ReadRatio:
+			mops = append(mops, core.Read(k, nil))
+		case opts.Workload == generator.ListAppend:
+			nextValue[k]++
+			mops = append(mops, core.Append(k, nextValue[k]))
+		default:
+			nextValue[k]++
+			mops = append(mops, core.Write(k, nextValue[k]))
+		}
+	}
+	return mops
+}
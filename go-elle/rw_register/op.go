@@ -121,6 +121,44 @@ func Pair(op core.Op) (core.Op, core.Op) {
 		if mop.IsRead() {
 			mop.M["value"] = NewNil()
 		}
+		if mop.IsScan() {
+			mop.M["value"] = nil
+		}
 	}
 	return invoke, op
 }
+
+// ConvertHistory converts the plain int (or nil) values of a history, as core.ParseHistoryRW
+// reads them, to Int values, and the observed values of scans to maps of Int values
+func ConvertHistory(history core.History) core.History {
+	converted := make(core.History, 0, len(history))
+	for _, op := range history {
+		if op.Value != nil {
+			op = op.Copy()
+			for _, mop := range *op.Value {
+				switch v := mop.M["value"].(type) {
+				case int:
+					mop.M["value"] = NewInt(v)
+				case *int:
+					if v == nil {
+						mop.M["value"] = NewNil()
+					} else {
+						mop.M["value"] = NewInt(*v)
+					}
+				case nil:
+					if !mop.IsScan() {
+						mop.M["value"] = NewNil()
+					}
+				case map[string]int:
+					values := make(map[string]Int, len(v))
+					for k, val := range v {
+						values[k] = NewInt(val)
+					}
+					mop.M["value"] = values
+				}
+			}
+		}
+		converted = append(converted, op)
+	}
+	return converted
+}
@@ -1,6 +1,7 @@
 package rwregister
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sort"
@@ -205,6 +206,16 @@ func (w wrExplainResult) Type() core.DependType {
 	return core.WRDepend
 }
 
+// GetKey returns the key of the dependency
+func (w wrExplainResult) GetKey() string {
+	return w.Key
+}
+
+// MopIndexes returns -1, -1 as the mops are not recorded
+func (w wrExplainResult) MopIndexes() (int, int) {
+	return -1, -1
+}
+
 // wwExplainer explains write-write dependencies
 type wrExplainer struct{}
 
@@ -281,6 +292,10 @@ func getKeys(op core.Op) []string {
 		if op.Type == core.OpTypeInfo && mop.IsRead() {
 			continue
 		}
+		// scans are predicate reads, they do not tell the versions of keys
+		if mop.IsScan() {
+			continue
+		}
 
 		k := mop.GetKey()
 		if _, ok := dup[k]; !ok {
@@ -678,6 +693,16 @@ func (wwExplainResult) Type() core.DependType {
 	return core.WWDepend
 }
 
+// GetKey returns the key of the dependency
+func (w wwExplainResult) GetKey() string {
+	return w.key
+}
+
+// MopIndexes returns -1, -1 as the mops are not recorded
+func (wwExplainResult) MopIndexes() (int, int) {
+	return -1, -1
+}
+
 // wwExplainer explains write-write dependencies
 type wwExplainer struct {
 	versionGraphs map[string]*core.DirectedGraph
@@ -725,6 +750,16 @@ func (rwExplainResult) Type() core.DependType {
 	return core.RWDepend
 }
 
+// GetKey returns the key of the dependency
+func (w rwExplainResult) GetKey() string {
+	return w.key
+}
+
+// MopIndexes returns -1, -1 as the mops are not recorded
+func (rwExplainResult) MopIndexes() (int, int) {
+	return -1, -1
+}
+
 // wwExplainer explains write-write dependencies
 type rwExplainer struct {
 	versionGraphs map[string]*core.DirectedGraph
@@ -748,13 +783,124 @@ func (*rwExplainer) RenderExplanation(result core.ExplainResult, a, b string) st
 	)
 }
 
+// predicateGraph links the ops which scanned a range and did not observe a key in it to the
+// writers of the first versions of the key, these rw anti-dependencies are on the predicate
+// instead of an item, a phantom
+func predicateGraph(history core.History, versionGraphs map[string]*core.DirectedGraph) *core.DirectedGraph {
+	var (
+		extWriteIndex = extIndex(extWriteKeys, history)
+		g             = core.NewDirectedGraph()
+		keys          []string
+	)
+	for k := range versionGraphs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, op := range core.FilterOkHistory(history) {
+		if !op.HasMopType(core.MopTypeScan) {
+			continue
+		}
+		for k := range scanMisses(op, keys) {
+			for next := range versionGraphs[k].Outs[core.Vertex{Value: NewNil()}] {
+				for _, w := range extWriteIndex[k][next.Value.(Int)] {
+					if w != op {
+						g.Link(core.Vertex{Value: op}, core.Vertex{Value: w}, core.PredicateRW)
+					}
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+type predicateExplainResult struct {
+	Typ   core.DependType
+	lo    string
+	hi    string
+	key   string
+	value Int
+}
+
+// PredicateExplainResult creates a predicateExplainResult
+func PredicateExplainResult(lo, hi, key string, value Int) predicateExplainResult {
+	return predicateExplainResult{
+		Typ:   core.PredicateRWDepend,
+		lo:    lo,
+		hi:    hi,
+		key:   key,
+		value: value,
+	}
+}
+
+func (predicateExplainResult) Type() core.DependType {
+	return core.PredicateRWDepend
+}
+
+// GetKey returns the key the scan did not observe
+func (p predicateExplainResult) GetKey() string {
+	return p.key
+}
+
+// MopIndexes returns -1, -1 as the mops are not recorded
+func (predicateExplainResult) MopIndexes() (int, int) {
+	return -1, -1
+}
+
+// predicateExplainer explains predicate read-write dependencies
+type predicateExplainer struct {
+	versionGraphs map[string]*core.DirectedGraph
+}
+
+func (p *predicateExplainer) ExplainPairData(a, b core.PathType) core.ExplainResult {
+	var (
+		writes = extWriteKeys(b)
+		keys   []string
+	)
+	for k := range writes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	misses := scanMisses(a, keys)
+	for _, k := range keys {
+		scan, ok := misses[k]
+		if !ok {
+			continue
+		}
+		graph, ok := p.versionGraphs[k]
+		if !ok {
+			continue
+		}
+		if _, ok := graph.Outs[core.Vertex{Value: NewNil()}][core.Vertex{Value: writes[k]}]; ok {
+			lo, hi := scan.GetRange()
+			return PredicateExplainResult(lo, hi, k, writes[k])
+		}
+	}
+	return nil
+}
+
+func (*predicateExplainer) RenderExplanation(result core.ExplainResult, a, b string) string {
+	if result.Type() != core.PredicateRWDepend {
+		log.Fatalf("result type is not %s, type error", core.PredicateRWDepend)
+	}
+	er := result.(predicateExplainResult)
+	return fmt.Sprintf("%s scanned [%s %s] without observing %v, which %s wrote with value %s",
+		a, er.lo, er.hi, er.key, b, er.value,
+	)
+}
+
 // WWRWGraph ...
 func WWRWGraph(history core.History, opts ...interface{}) (core.Anomalies, *core.DirectedGraph, core.DataExplainer) {
 	anomalies, _, versionGraphs := versionGraphs(history, opts...)
-	txnGraph := versionGraphs2TransactionGraph(history, versionGraphs)
+	txnGraph := core.DigraphUnion(
+		versionGraphs2TransactionGraph(history, versionGraphs),
+		predicateGraph(history, versionGraphs),
+	)
 	return anomalies, txnGraph, core.NewCombineExplainer([]core.DataExplainer{
 		&wwExplainer{versionGraphs: versionGraphs},
 		&rwExplainer{versionGraphs: versionGraphs},
+		&predicateExplainer{versionGraphs: versionGraphs},
 	})
 }
 
@@ -763,19 +909,47 @@ func graph(history core.History, opts ...interface{}) (core.Anomalies, *core.Dir
 	return core.Combine(wrGraph, WWRWGraph)(history, opts...)
 }
 
-// Check checks append and read history for list_append
-func Check(opts txn.Opts, history core.History, graphOpt GraphOption) txn.CheckResult {
-	history = preProcessHistory(history)
-	g1a := g1aCases(history)
-	g1b := g1bCases(history)
-	internal := internal(history)
+// Graph returns the ww, wr and rw dependency graph of a history and the explainer of its edges
+func Graph(history core.History, graphOpt GraphOption) (*core.DirectedGraph, core.DataExplainer) {
+	_, g, explainer := graph(preProcessHistory(history), graphOpt)
+	return g, explainer
+}
+
+func analysis(ctx context.Context, opts txn.Opts, history core.History, graphOpt GraphOption) core.CheckResult {
 	var analyzer core.Analyzer = graph
 	additionalGraphs := txn.AdditionalGraphs(opts)
 	if len(additionalGraphs) != 0 {
 		analyzer = core.Combine(append([]core.Analyzer{analyzer}, additionalGraphs...)...)
 	}
+	// the cycles are written on a best-effort basis, the check goes on without them
+	if err := txn.ValidatePlotFormat(opts.PlotFormat); opts.Directory != "" && err != nil {
+		log.Printf("not writing cycles to %s: %v", opts.Directory, err)
+		opts.Directory = ""
+	}
+	checkResult, err := txn.CyclesWithDrawContext(ctx, opts, analyzer, history, graphOpt)
+	if err != nil {
+		log.Printf("failed to write cycles to %s: %v", opts.Directory, err)
+	}
+	return checkResult
+}
 
-	checkResult := txn.Cycles(analyzer, history, graphOpt)
+// Analysis returns the cycle analysis of Check with its graph, explainer and cycle anomalies
+func Analysis(opts txn.Opts, history core.History, graphOpt GraphOption) core.CheckResult {
+	return analysis(context.Background(), opts, preProcessHistory(history), graphOpt)
+}
+
+// Check checks append and read history for list_append
+func Check(opts txn.Opts, history core.History, graphOpt GraphOption) txn.CheckResult {
+	return CheckContext(context.Background(), opts, history, graphOpt)
+}
+
+// CheckContext is Check with the cycle search bounded by ctx, see txn.Opts for the time budgets
+func CheckContext(ctx context.Context, opts txn.Opts, history core.History, graphOpt GraphOption) txn.CheckResult {
+	history = preProcessHistory(history)
+	g1a := g1aCases(history)
+	g1b := g1bCases(history)
+	internal := internal(history)
+	checkResult := analysis(ctx, opts, history, graphOpt)
 	anomalies := checkResult.Anomalies
 	if len(g1a) != 0 {
 		anomalies["G1a"] = g1a
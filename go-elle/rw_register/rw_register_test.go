@@ -1,6 +1,7 @@
 package rwregister
 
 import (
+	"context"
 	"fmt"
 	"testing"
 
@@ -693,30 +694,33 @@ func TestChecker(t *testing.T) {
 		}))
 
 		// But repeatable read will!
+		g2Item := core.CycleExplainerResult{
+			Circle: core.Circle{
+				Path: []core.PathType{
+					t3Ok.WithIndex(4),
+					t2Ok.WithIndex(5),
+					t3Ok.WithIndex(4),
+				},
+			},
+			Steps: []core.Step{
+				{
+					Result: RWExplainResult("y", core.MopValueType(NewInt(1)), core.MopValueType(NewInt(2))),
+				},
+				{
+					Result: RWExplainResult("x", core.MopValueType(NewInt(1)), core.MopValueType(NewInt(2))),
+				},
+			},
+			Typ: "G2-item",
+		}
+		// which is a write skew of T2 and T3
+		writeSkew := g2Item
+		writeSkew.Typ = "write-skew"
 		expectRR := txn.CheckResult{
 			Valid:        false,
-			AnomalyTypes: []string{"G2-item"},
+			AnomalyTypes: []string{"G2-item", "write-skew"},
 			Anomalies: core.Anomalies{
-				"G2-item": []core.Anomaly{
-					core.CycleExplainerResult{
-						Circle: core.Circle{
-							Path: []core.PathType{
-								t3Ok.WithIndex(4),
-								t2Ok.WithIndex(5),
-								t3Ok.WithIndex(4),
-							},
-						},
-						Steps: []core.Step{
-							{
-								Result: RWExplainResult("y", core.MopValueType(NewInt(1)), core.MopValueType(NewInt(2))),
-							},
-							{
-								Result: RWExplainResult("x", core.MopValueType(NewInt(1)), core.MopValueType(NewInt(2))),
-							},
-						},
-						Typ: "G2-item",
-					},
-				},
+				"G2-item":    []core.Anomaly{g2Item},
+				"write-skew": []core.Anomaly{writeSkew},
 			},
 			Not: []string{"repeatable-read"},
 		}
@@ -946,3 +950,119 @@ func TestRotate(t *testing.T) {
 	rotate(scc2)
 	require.Equal(t, []Int{inil, i1, i2, i6, i5}, scc2)
 }
+
+func TestCheckPredicate(t *testing.T) {
+	// write skew on a predicate: each txn scans [1 3], finds nothing and inserts a key into the range
+	history, err := core.ParseHistoryRW(`{:index 0, :type :invoke, :process 0, :value [[:scan [1 3] nil] [:w 2 1]]}
+{:index 1, :type :invoke, :process 1, :value [[:scan [1 3] nil] [:w 3 1]]}
+{:index 2, :type :ok, :process 0, :value [[:scan [1 3] {}] [:w 2 1]]}
+{:index 3, :type :ok, :process 1, :value [[:scan [1 3] {}] [:w 3 1]]}`)
+	require.NoError(t, err)
+	history = ConvertHistory(history)
+
+	result := check(txn.Opts{ConsistencyModels: []string{"serializable"}}, history, GraphOption{})
+	require.False(t, result.Valid)
+	require.Equal(t, []string{"G2-predicate"}, result.AnomalyTypes)
+	cycle := result.Anomalies["G2-predicate"][0].(core.CycleExplainerResult)
+	require.Equal(t, []core.Step{
+		{Result: PredicateExplainResult("1", "3", "3", NewInt(1))},
+		{Result: PredicateExplainResult("1", "3", "2", NewInt(1))},
+	}, cycle.Steps)
+	require.Equal(t, "T1 scanned [1 3] without observing 3, which T2 wrote with value 1",
+		(&predicateExplainer{}).RenderExplanation(cycle.Steps[0].Result, "T1", "T2"))
+
+	// phantoms are allowed by repeatable read, which only proscribes G2-item
+	require.True(t, check(txn.Opts{ConsistencyModels: []string{"repeatable-read"}}, history, GraphOption{}).Valid)
+
+	// the keys a scan observed are item reads
+	history, err = core.ParseHistoryRW(`{:index 0, :type :ok, :process 0, :value [[:w 2 1] [:w 3 1]]}
+{:index 1, :type :ok, :process 0, :value [[:scan [1 3] {2 1, 3 1}] [:w 2 2]]}
+{:index 2, :type :ok, :process 1, :value [[:scan [1 3] {2 1, 3 1}] [:w 3 2]]}`)
+	require.NoError(t, err)
+	result = check(txn.Opts{ConsistencyModels: []string{"repeatable-read"}}, ConvertHistory(history), GraphOption{WfrKeys: true})
+	require.Equal(t, []string{"G2-item", "write-skew"}, result.AnomalyTypes)
+}
+
+func TestCheckLostUpdate(t *testing.T) {
+	var (
+		t0, t0Ok = Pair(MustParseOp("wx0").WithProcess(0))
+		t1, t1Ok = Pair(MustParseOp("rx0wx1").WithProcess(0))
+		t2, t2Ok = Pair(MustParseOp("rx0wx2").WithProcess(1))
+		t3, t3Ok = Pair(MustParseOp("rx1").WithProcess(2))
+		t4, t4Ok = Pair(MustParseOp("rx2").WithProcess(2))
+		history  = core.History{t0, t0Ok, t1, t2, t1Ok, t2Ok, t3, t3Ok, t4, t4Ok}
+	)
+
+	// snapshot isolation prohibits the lost update, which is a G-single too
+	result := check(txn.Opts{ConsistencyModels: []string{"snapshot-isolation"}}, history, GraphOption{LinearizableKeys: true})
+	require.False(t, result.Valid)
+	require.Equal(t, []string{"G-single", "lost-update"}, result.AnomalyTypes)
+	// T2 read x 0 and overwrote x 1 of T1, which read x 0 too
+	lost := result.Anomalies["lost-update"][0].(core.CycleExplainerResult)
+	require.Equal(t, []core.PathType{t2Ok.WithIndex(5), t1Ok.WithIndex(4), t2Ok.WithIndex(5)}, lost.Circle.Path)
+	require.Equal(t, []core.Step{
+		{Result: RWExplainResult("x", core.MopValueType(NewInt(0)), core.MopValueType(NewInt(1)))},
+		{Result: WWExplainResult("x", core.MopValueType(NewInt(1)), core.MopValueType(NewInt(2)))},
+	}, lost.Steps)
+
+	require.Equal(t, []txn.PairReport{{
+		Type:     "lost-update",
+		Count:    1,
+		Keys:     []string{"x"},
+		Examples: []string{fmt.Sprintf("T1 = %s, T2 = %s", t2Ok.WithIndex(5), t1Ok.WithIndex(4))},
+	}}, txn.PairReports(result.Anomalies, 3))
+}
+
+func TestCheckLostUpdateKeys(t *testing.T) {
+	var (
+		t0, t0Ok = Pair(MustParseOp("wy0wz0").WithProcess(0))
+		t1, t1Ok = Pair(MustParseOp("wy1wz1").WithProcess(0))
+		t2, t2Ok = Pair(MustParseOp("ry0rz0wz2").WithProcess(1))
+		t3, t3Ok = Pair(MustParseOp("ry1rz1").WithProcess(2))
+		t4, t4Ok = Pair(MustParseOp("rz2").WithProcess(2))
+		history  = core.History{t0, t0Ok, t1, t2, t1Ok, t2Ok, t3, t3Ok, t4, t4Ok}
+	)
+
+	// T2 read y 0 and z 0 that T1 overwrote, then overwrote z 1 of T1: the rw dependency may be explained
+	// on y, the lost update is on z
+	result := check(txn.Opts{ConsistencyModels: []string{"snapshot-isolation"}}, history, GraphOption{LinearizableKeys: true})
+	require.False(t, result.Valid)
+	require.Contains(t, result.AnomalyTypes, "lost-update")
+	lost := result.Anomalies["lost-update"][0].(core.CycleExplainerResult)
+	require.Equal(t, []core.PathType{t2Ok.WithIndex(5), t1Ok.WithIndex(4), t2Ok.WithIndex(5)}, lost.Circle.Path)
+
+	// T2 read z 1 of T1 before overwriting it, the rw and ww dependencies are on different keys
+	t2, t2Ok = Pair(MustParseOp("ry0rz1wz2").WithProcess(1))
+	t3, t3Ok = Pair(MustParseOp("ry1").WithProcess(2))
+	history = core.History{t0, t0Ok, t1, t2, t1Ok, t2Ok, t3, t3Ok, t4, t4Ok}
+	result = check(txn.Opts{ConsistencyModels: []string{"snapshot-isolation"}}, history, GraphOption{LinearizableKeys: true})
+	require.Equal(t, []string{"G-single"}, result.AnomalyTypes)
+}
+
+func TestPairCasesTimeout(t *testing.T) {
+	var (
+		t0, t0Ok = Pair(MustParseOp("wx0").WithProcess(0))
+		t1, t1Ok = Pair(MustParseOp("rx0wx1").WithProcess(0))
+		t2, t2Ok = Pair(MustParseOp("rx0wx2").WithProcess(1))
+		t3, t3Ok = Pair(MustParseOp("rx1").WithProcess(2))
+		t4, t4Ok = Pair(MustParseOp("rx2").WithProcess(2))
+		history  = core.History{t0, t0Ok, t1, t2, t1Ok, t2Ok, t3, t3Ok, t4, t4Ok}
+	)
+	g, explainer := Graph(history, GraphOption{LinearizableKeys: true})
+	sccs := g.StronglyConnectedComponents()
+	require.Len(t, sccs, 1)
+
+	for _, parallelism := range []int{1, 2} {
+		opts := txn.Opts{Parallelism: parallelism}
+		cases := txn.PairCasesContext(context.Background(), opts, *g, explainer, sccs)
+		require.Len(t, cases[txn.LostUpdate], 1)
+		require.Empty(t, cases["cycle-search-timeout"])
+
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+		cases = txn.PairCasesContext(canceled, opts, *g, explainer, sccs)
+		require.Empty(t, cases[txn.LostUpdate])
+		require.Equal(t, []core.Anomaly{txn.CycleSearchTimeout{AnomalySpecType: "lost-update, write-skew", SccSize: len(sccs[0].Vertices)}},
+			cases["cycle-search-timeout"])
+	}
+}
@@ -13,6 +13,17 @@ func extReadKeys(op core.Op) map[string]Int {
 	)
 
 	for _, mop := range *op.Value {
+		if mop.IsScan() {
+			// the keys a scan observed are read like single keys
+			values, _ := scanValues(mop)
+			for k, v := range values {
+				if _, ok := ignore[k]; !ok {
+					res[k] = v
+					ignore[k] = struct{}{}
+				}
+			}
+			continue
+		}
 		k, v := mop.GetKey(), mop.GetValue()
 		i := v.(Int)
 		_, ok := ignore[k]
@@ -42,6 +53,44 @@ func extWriteKeys(op core.Op) map[string]Int {
 	return res
 }
 
+// scanValues returns the keys and values a scan observed, false if the scan crashed
+func scanValues(mop core.Mop) (map[string]Int, bool) {
+	values, ok := mop.GetValue().(map[string]Int)
+	return values, ok && values != nil
+}
+
+// scanMisses returns the keys, among keys, which are in the range of a scan of op but were not
+// observed by it, with the scan. Keys op touched before the scan are left out, the scan should
+// have observed them.
+func scanMisses(op core.Op, keys []string) map[string]core.Mop {
+	var (
+		misses = make(map[string]core.Mop)
+		seen   = make(map[string]struct{})
+	)
+	for _, mop := range *op.Value {
+		if !mop.IsScan() {
+			seen[mop.GetKey()] = struct{}{}
+			continue
+		}
+		values, ok := scanValues(mop)
+		if !ok {
+			continue
+		}
+		for _, k := range keys {
+			_, touched := seen[k]
+			_, observed := values[k]
+			_, missed := misses[k]
+			if !touched && !observed && !missed && mop.Covers(k) {
+				misses[k] = mop
+			}
+		}
+		for k := range values {
+			seen[k] = struct{}{}
+		}
+	}
+	return misses
+}
+
 func isExtIndexRel(rel core.Rel) (string, bool) {
 	if strings.HasPrefix(string(rel), string(core.ExtKey)) && len(rel) > 8 {
 		return string(rel[8:]), true
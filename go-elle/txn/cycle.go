@@ -25,6 +25,9 @@ type CycleAnomalySpecType struct {
 
 	// A predicate over a cycle
 	With core.CyclePredicate
+
+	// The type reported for found cycles instead of the explained one, used by specs defined outside this package.
+	Typ string
 }
 
 // CycleAnomalySpecs defines anomaly specs
@@ -42,6 +45,20 @@ var RealtimeAnalysisTypes map[string]struct{}
 // ProcessAnalysisTypes saves types involving process edges
 var ProcessAnalysisTypes map[string]struct{}
 
+// TimeAnalysisTypes saves types involving the commit order by timestamps
+var TimeAnalysisTypes map[string]struct{}
+
+// GraphSet is a set of the graphs of txn orders to combine with the data dependencies, e.g.
+// ProcessGraph|RealtimeGraph looks for the -process and -realtime anomalies in one run
+type GraphSet uint8
+
+// GraphSet enums
+const (
+	ProcessGraph GraphSet = 1 << iota
+	RealtimeGraph
+	TimeGraph
+)
+
 func fromRels(rels ...core.Rel) CycleAnomalySpecType {
 	return fromRelsWithFilter(nil, rels...)
 }
@@ -108,6 +125,18 @@ func nonadjacentRW(trace []core.CycleTrace) bool {
 	return rwCount > 1
 }
 
+// hasPredicateRW ensures that the cycle has an rw-predicate edge, cycles of item dependencies only are G2-item
+func hasPredicateRW(trace []core.CycleTrace) bool {
+	for _, path := range trace {
+		for _, rel := range path.Rels {
+			if rel == core.PredicateRW {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func init() {
 	CycleAnomalySpecs = map[string]CycleAnomalySpecType{
 		"G0":               fromRels(core.WW),
@@ -115,6 +144,7 @@ func init() {
 		"G-single":         fromFirstRelAndRest(core.RW, core.WW, core.WR),
 		"G-nonadjacent":    fromRelsAndWith(nonadjacentRW, core.WW, core.WR, core.RW),
 		"G2-item":          fromFirstRelAndRestWithFilter(buildFilterExByType("G2-item"), core.RW, core.WR, core.RW, core.WW),
+		"G2-predicate":     fromRelsAndWith(hasPredicateRW, core.WW, core.WR, core.RW, core.PredicateRW),
 		"G0-process":       fromRelsWithFilter(buildFilterExByType("G0-process"), core.WW, core.Process),
 		"G1c-process":      fromFirstRelAndRestWithFilter(buildFilterExByType("G1c-process"), core.WR, core.WW, core.WR, core.Process),
 		"G-single-process": fromFirstRelAndRestWithFilter(buildFilterExByType("G-single-process"), core.RW, core.WW, core.WR, core.Process),
@@ -124,11 +154,17 @@ func init() {
 		"G1c-realtime":      fromFirstRelAndRestWithFilter(buildFilterExByType("G1c-realtime"), core.WR, core.WW, core.WR, core.Realtime),
 		"G-single-realtime": fromFirstRelAndRestWithFilter(buildFilterExByType("G-single-realtime"), core.RW, core.WW, core.WR, core.Realtime),
 		"G2-item-realtime":  fromFirstRelAndRestWithFilter(buildFilterExByType("G2-item-realtime"), core.RW, core.WW, core.WR, core.Realtime, core.RW),
+		// commit timestamps
+		"G0-time":       fromRelsWithFilter(buildFilterExByType("G0-time"), core.WW, core.Time),
+		"G1c-time":      fromFirstRelAndRestWithFilter(buildFilterExByType("G1c-time"), core.WR, core.WW, core.WR, core.Time),
+		"G-single-time": fromFirstRelAndRestWithFilter(buildFilterExByType("G-single-time"), core.RW, core.WW, core.WR, core.Time),
+		"G2-item-time":  fromFirstRelAndRestWithFilter(buildFilterExByType("G2-item-time"), core.RW, core.WW, core.WR, core.Time, core.RW),
 	}
 
 	CycleTypeNames = map[string]struct{}{
 		"G-nonadjacent-process":  {},
 		"G-nonadjacent-realtime": {},
+		"G-nonadjacent-time":     {},
 	}
 
 	for k := range CycleAnomalySpecs {
@@ -142,6 +178,7 @@ func init() {
 
 	ProcessAnalysisTypes = map[string]struct{}{}
 	RealtimeAnalysisTypes = map[string]struct{}{}
+	TimeAnalysisTypes = map[string]struct{}{}
 	for k := range CycleTypeNames {
 		if strings.Contains(k, "process") {
 			ProcessAnalysisTypes[k] = struct{}{}
@@ -149,6 +186,9 @@ func init() {
 		if strings.Contains(k, "realtime") {
 			RealtimeAnalysisTypes[k] = struct{}{}
 		}
+		if strings.HasSuffix(k, "-time") {
+			TimeAnalysisTypes[k] = struct{}{}
+		}
 	}
 }
 
@@ -170,9 +210,11 @@ func (c CycleExplainerWrapper) ExplainCycle(pairExplainer core.DataExplainer, ci
 	}
 	realtime := typeFrequencies[core.RealtimeDepend]
 	process := typeFrequencies[core.ProcessDepend]
+	commitOrder := typeFrequencies[core.TimeDepend]
 	ww := typeFrequencies[core.WWDepend]
 	wr := typeFrequencies[core.WRDepend]
 	rw := typeFrequencies[core.RWDepend]
+	predicate := typeFrequencies[core.PredicateRWDepend]
 	var rwAdj bool
 	var lastType = steps[len(steps)-1].Result.Type()
 	for _, step := range steps {
@@ -183,7 +225,9 @@ func (c CycleExplainerWrapper) ExplainCycle(pairExplainer core.DataExplainer, ci
 		lastType = step.Result.Type()
 	}
 	var dataDepType string
-	if rw == 1 {
+	if 0 < predicate {
+		dataDepType = "G2-predicate"
+	} else if rw == 1 {
 		dataDepType = "G-single"
 	} else if 1 < rw {
 		if rwAdj {
@@ -201,6 +245,8 @@ func (c CycleExplainerWrapper) ExplainCycle(pairExplainer core.DataExplainer, ci
 	var subtype string
 	if 0 < realtime {
 		subtype = "-realtime"
+	} else if 0 < commitOrder {
+		subtype = "-time"
 	} else if 0 < process {
 		subtype = "-process"
 	}
@@ -218,17 +264,40 @@ func (c CycleExplainerWrapper) RenderCycleExplanation(explainer core.DataExplain
 }
 
 // AdditionalGraphs determines what additional graphs we'll need to consider for this analysis.
+// Without opts.Graphs, they follow the reportable anomaly types: the realtime graph if there are realtime
+// ones, otherwise the process graph if there are process ones, and the time graph if there are time ones.
 func AdditionalGraphs(opts Opts) []core.Analyzer {
-	ats := reportableAnomalyTypes(opts.ConsistencyModels, opts.Anomalies)
-	var graphFn core.Analyzer
-	if hasIntersection(ats, RealtimeAnalysisTypes) {
-		graphFn = core.RealtimeGraph
-	} else if hasIntersection(ats, ProcessAnalysisTypes) {
-		graphFn = core.ProcessGraph
-	} else {
-		return opts.AdditionalGraphs
+	graphs := opts.Graphs
+	if graphs == 0 {
+		ats := reportableAnomalyTypes(opts.ConsistencyModels, opts.Anomalies)
+		if hasIntersection(ats, RealtimeAnalysisTypes) {
+			graphs |= RealtimeGraph
+		} else if hasIntersection(ats, ProcessAnalysisTypes) {
+			graphs |= ProcessGraph
+		}
+		if hasIntersection(ats, TimeAnalysisTypes) {
+			graphs |= TimeGraph
+		}
+	}
+	analyzers := opts.AdditionalGraphs
+	if graphs&ProcessGraph != 0 {
+		analyzers = append(analyzers, core.ProcessGraph)
+	}
+	if graphs&RealtimeGraph != 0 {
+		analyzers = append(analyzers, RealtimeAnalyzer(opts))
+	}
+	if graphs&TimeGraph != 0 {
+		analyzers = append(analyzers, core.TimeGraph(opts.ClockSkew, opts.CommitTime))
+	}
+	return analyzers
+}
+
+// RealtimeAnalyzer is the analyzer of the realtime graph, by the :time of the ops if opts.RealtimeEpsilon is set
+func RealtimeAnalyzer(opts Opts) core.Analyzer {
+	if opts.RealtimeEpsilon > 0 {
+		return core.RealtimeGraphWithEpsilon(opts.RealtimeEpsilon)
 	}
-	return append(opts.AdditionalGraphs, graphFn)
+	return core.RealtimeGraph
 }
 
 // Anomalies worth reporting on, even if they don't cause the test to fail.
@@ -249,6 +318,18 @@ func prohibitedAnomalyTypes(cm []core.ConsistencyModelName, anomalies []string)
 	return dest
 }
 
+// timeAnomalyTypes yields the time anomalies whose anomaly without the commit order by timestamps is prohibited,
+// along with the time anomalies implying them
+func timeAnomalyTypes(prohibited map[string]struct{}) map[string]struct{} {
+	var types []string
+	for k := range TimeAnalysisTypes {
+		if _, ok := prohibited[strings.TrimSuffix(k, "-time")]; ok {
+			types = append(types, k)
+		}
+	}
+	return compactAnomalies(core.AllAnomaliesImplying(types)...)
+}
+
 func compactAnomalies(anomalies ...string) map[string]struct{} {
 	ret := map[string]struct{}{}
 	for _, v := range anomalies {
@@ -5,6 +5,7 @@ import (
 	"hash/fnv"
 	"sort"
 	"strconv"
+	"sync"
 
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
 )
@@ -107,8 +108,15 @@ func mustAtoi(s string) int {
 
 // ResultMap takes opts and processes anomalies
 func ResultMap(opts Opts, anomalies core.Anomalies) CheckResult {
-	bad := anomalies.SelectKeys(prohibitedAnomalyTypes(opts.ConsistencyModels, opts.Anomalies))
-	reportable := anomalies.SelectKeys(reportableAnomalyTypes(opts.ConsistencyModels, opts.Anomalies))
+	prohibited := prohibitedAnomalyTypes(opts.ConsistencyModels, opts.Anomalies)
+	reportableTypes := reportableAnomalyTypes(opts.ConsistencyModels, opts.Anomalies)
+	if opts.Graphs&TimeGraph != 0 {
+		timeTypes := timeAnomalyTypes(prohibited)
+		union(prohibited, timeTypes)
+		union(reportableTypes, timeTypes)
+	}
+	bad := anomalies.SelectKeys(prohibited)
+	reportable := anomalies.SelectKeys(reportableTypes)
 
 	if len(reportable) == 0 {
 		return CheckResult{
@@ -175,3 +183,22 @@ func FilteredGraphs(graph core.DirectedGraph) FilterGraphFn {
 		return g
 	}
 }
+
+// CompactFilteredGraphs receives a compact graph, returns the FilteredGraphs function on it.
+// The filtered graphs are cached per set of relations, the function is safe for concurrent use.
+func CompactFilteredGraphs(graph *core.Graph[core.Vertex]) CompactFilterGraphFn {
+	memo := map[core.RelMask]*core.Graph[core.Vertex]{}
+	var mu sync.Mutex
+
+	return func(rels []core.Rel) *core.Graph[core.Vertex] {
+		mask := core.RelMaskOf(rels...)
+		mu.Lock()
+		defer mu.Unlock()
+		if g, e := memo[mask]; e {
+			return g
+		}
+		g := graph.Filter(mask)
+		memo[mask] = g
+		return g
+	}
+}
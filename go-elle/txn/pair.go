@@ -0,0 +1,273 @@
+package txn
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+// The anomalies of two txns that PairCases looks for besides the cycles of CycleAnomalySpecs
+const (
+	// LostUpdate (P4) is T1 -rw-> T2 -ww-> T1 on the same key: T1 read a version of the key that T2 overwrote,
+	// then overwrote T2's write
+	LostUpdate = "lost-update"
+	// WriteSkew is T1 -rw-> T2 -rw-> T1 without ww between them: each one wrote what the other read before
+	WriteSkew = "write-skew"
+)
+
+// PairCases finds the lost updates and write skews between two txns of the graph, and group them by there name.
+// The cases are CycleExplainerResults of the cycle T1 -> T2 -> T1, in the order of the index of T1 and T2,
+// their steps are explained by the rw and ww dependencies only.
+func PairCases(graph core.DirectedGraph, explainer core.DataExplainer) map[string][]core.Anomaly {
+	return PairCasesContext(context.Background(), Opts{}, graph, explainer, graph.StronglyConnectedComponents())
+}
+
+// PairCasesContext is PairCases within the time budgets of opts, the search stops when ctx is done.
+// The two txns of a case are in the same SCC, so the SCCs of the graph are searched like CycleCasesContext
+// does, by opts.Parallelism workers. Every SCC not fully searched adds a CycleSearchTimeout to the
+// cycle-search-timeout cases.
+func PairCasesContext(ctx context.Context, opts Opts, graph core.DirectedGraph, explainer core.DataExplainer, sccs []core.SCC) map[string][]core.Anomaly {
+	if opts.CycleSearchBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.CycleSearchBudget)
+		defer cancel()
+	}
+	rwExplainer := core.RestrictExplainer(explainer, core.RelMaskOf(core.RW))
+	wwExplainer := core.RestrictExplainer(explainer, core.RelMaskOf(core.WW))
+
+	results := make([]pairSearch, len(sccs))
+	search := func(i int) {
+		sccCtx, cancel := ctx, context.CancelFunc(func() {})
+		if opts.CycleSearchTimeout > 0 {
+			sccCtx, cancel = context.WithTimeout(ctx, opts.CycleSearchTimeout)
+		}
+		results[i] = searchPairs(sccCtx, graph, rwExplainer, wwExplainer, sccs[i])
+		cancel()
+	}
+	if opts.Parallelism > 1 {
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < opts.Parallelism; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					search(i)
+				}
+			}()
+		}
+		for i := range sccs {
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+	} else {
+		for i := range sccs {
+			search(i)
+		}
+	}
+
+	var found []core.CycleExplainerResult
+	cases := map[string][]core.Anomaly{}
+	for i, r := range results {
+		found = append(found, r.found...)
+		if r.err != nil {
+			cases["cycle-search-timeout"] = append(cases["cycle-search-timeout"],
+				CycleSearchTimeout{AnomalySpecType: pairSpecType, SccSize: len(sccs[i].Vertices)})
+		}
+	}
+	// in the order of the index of T1 and T2, as if the whole graph was searched at once
+	index := func(cr core.CycleExplainerResult, i int) int {
+		return opIndex(core.Vertex{Value: cr.Circle.Path[i]})
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if index(found[i], 0) != index(found[j], 0) {
+			return index(found[i], 0) < index(found[j], 0)
+		}
+		return index(found[i], 1) < index(found[j], 1)
+	})
+	for _, cr := range found {
+		cases[cr.Typ] = append(cases[cr.Typ], cr)
+	}
+	return cases
+}
+
+// the AnomalySpecType of the CycleSearchTimeout of an SCC whose pair cases were not all searched
+const pairSpecType = LostUpdate + ", " + WriteSkew
+
+// pairSearch is the outcome of the search of an SCC for the pair cases
+type pairSearch struct {
+	found []core.CycleExplainerResult
+	err   error
+}
+
+// searchPairs finds the pair cases of the txns of scc, in the order of their indexes
+func searchPairs(ctx context.Context, graph core.DirectedGraph, rwExplainer, wwExplainer core.DataExplainer, scc core.SCC) pairSearch {
+	vertices := append([]core.Vertex(nil), scc.Vertices...)
+	sort.Slice(vertices, func(i, j int) bool {
+		return opIndex(vertices[i]) < opIndex(vertices[j])
+	})
+
+	var found []core.CycleExplainerResult
+	for _, a := range vertices {
+		if err := ctx.Err(); err != nil {
+			return pairSearch{found: found, err: err}
+		}
+		outs := graph.Outs[a]
+		succs := make([]core.Vertex, 0, len(outs))
+		for b := range outs {
+			succs = append(succs, b)
+		}
+		sort.Slice(succs, func(i, j int) bool {
+			return opIndex(succs[i]) < opIndex(succs[j])
+		})
+		for _, b := range succs {
+			if a == b || !hasRel(outs[b], core.RW) {
+				continue
+			}
+			back := graph.Outs[b][a]
+			t1, t2 := a.Value.(core.Op), b.Value.(core.Op)
+			if hasRel(back, core.WW) && len(lostUpdateKeys(t1, t2)) > 0 {
+				found = append(found, pairCase(LostUpdate, t1, t2, rwExplainer, wwExplainer))
+			}
+			// a write skew is found once, from the txn of the lower index
+			if hasRel(back, core.RW) && !hasRel(back, core.WW) && !hasRel(outs[b], core.WW) && opIndex(a) < opIndex(b) {
+				found = append(found, pairCase(WriteSkew, t1, t2, rwExplainer, rwExplainer))
+			}
+		}
+	}
+	return pairSearch{found: found}
+}
+
+func pairCase(typ string, t1, t2 core.Op, there, back core.DataExplainer) core.CycleExplainerResult {
+	return core.CycleExplainerResult{
+		Circle: core.Circle{Path: []core.PathType{t1, t2, t1}},
+		Steps: []core.Step{
+			{Result: there.ExplainPairData(t1, t2)},
+			{Result: back.ExplainPairData(t2, t1)},
+		},
+		Typ: typ,
+	}
+}
+
+// PairReport summarizes the cases of an anomaly type of PairCases
+type PairReport struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+	// Keys are the keys of the dependencies between T1 and T2 in all the cases
+	Keys []string `json:"keys"`
+	// Examples are up to maxExamples pairs, e.g. "T1 = {:type :ok ...}, T2 = {:type :ok ...}"
+	Examples []string `json:"examples"`
+}
+
+// PairReports summarizes the lost updates and write skews in anomalies, with at most maxExamples pairs each
+func PairReports(anomalies core.Anomalies, maxExamples int) []PairReport {
+	var reports []PairReport
+	for _, typ := range []string{LostUpdate, WriteSkew} {
+		cases := anomalies[typ]
+		if len(cases) == 0 {
+			continue
+		}
+		report := PairReport{Type: typ, Count: len(cases)}
+		keys := map[string]struct{}{}
+		for _, anomaly := range cases {
+			cr, ok := anomaly.(core.CycleExplainerResult)
+			if !ok {
+				continue
+			}
+			for _, step := range cr.Steps {
+				if kr, ok := step.Result.(core.KeyedExplainResult); ok {
+					keys[kr.GetKey()] = struct{}{}
+				}
+			}
+			if len(report.Examples) < maxExamples {
+				report.Examples = append(report.Examples, fmt.Sprintf("T1 = %s, T2 = %s", cr.Circle.Path[0], cr.Circle.Path[1]))
+			}
+		}
+		for k := range keys {
+			report.Keys = append(report.Keys, k)
+		}
+		sort.Strings(report.Keys)
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// lostUpdateKeys are the keys of both the rw dependencies of t1 -> t2 and the ww dependencies of t2 -> t1:
+// the keys t1 read without observing the write of t2 and then overwrote, that t2 wrote as well. The explainer
+// gives the key of a single dependency of each rel, so the keys are collected from the op values.
+func lostUpdateKeys(t1, t2 core.Op) []string {
+	written := map[string]core.Mop{}
+	for _, mop := range *t2.Value {
+		if mop.IsWrite() || mop.IsAppend() {
+			written[mop.GetKey()] = mop
+		}
+	}
+	read := map[string]bool{}
+	readValue := func(k string, v core.MopValueType) {
+		if w, ok := written[k]; ok && !observed(v, w.GetValue()) {
+			read[k] = true
+		}
+	}
+	var keys []string
+	for _, mop := range *t1.Value {
+		switch {
+		case mop.IsRead():
+			readValue(mop.GetKey(), mop.GetValue())
+		case mop.IsScan():
+			for k, v := range scanValues(mop) {
+				readValue(k, v)
+			}
+		case (mop.IsWrite() || mop.IsAppend()) && read[mop.GetKey()]:
+			keys = append(keys, mop.GetKey())
+			delete(read, mop.GetKey())
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// observed tells whether a read value includes the written one: an element of the list read, or the register read
+func observed(read, write core.MopValueType) bool {
+	if vs, ok := read.([]int); ok {
+		for _, v := range vs {
+			if reflect.DeepEqual(v, write) {
+				return true
+			}
+		}
+		return false
+	}
+	return reflect.DeepEqual(read, write)
+}
+
+// scanValues are the values of the keys a scan read, by key
+func scanValues(mop core.Mop) map[string]core.MopValueType {
+	values := map[string]core.MopValueType{}
+	v := reflect.ValueOf(mop.GetValue())
+	if v.Kind() != reflect.Map {
+		return values
+	}
+	for it := v.MapRange(); it.Next(); {
+		if k, ok := it.Key().Interface().(string); ok {
+			values[k] = it.Value().Interface()
+		}
+	}
+	return values
+}
+
+func hasRel(rels []core.Rel, rel core.Rel) bool {
+	for _, r := range rels {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
+
+func opIndex(v core.Vertex) int {
+	return v.Value.(core.Op).Index.GetOr(-1)
+}
@@ -1,8 +1,14 @@
 package txn
 
 import (
+	"context"
 	"encoding/json"
 	"log"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
 )
@@ -13,8 +19,40 @@ type Opts struct {
 	ConsistencyModels []core.ConsistencyModelName
 	Anomalies         []string
 	AdditionalGraphs  []core.Analyzer
+	// Directory receives the cycles of every anomaly type and their plots, nothing is written if empty
+	Directory string
+	// PlotFormat of the plots: svg (default), png or dot
+	PlotFormat string
+	// CycleSearchTimeout bounds the cycle search in each SCC and CycleSearchBudget the search in all of them,
+	// zero means no bound. The SCCs whose search runs out of time are reported as cycle-search-timeout.
+	CycleSearchTimeout time.Duration
+	CycleSearchBudget  time.Duration
+	// Graphs are the graphs of txn orders to combine, they are inferred from the anomalies if empty (see AdditionalGraphs).
+	// With TimeGraph, the time anomalies are prohibited where the models prohibit the same anomaly without timestamps.
+	Graphs GraphSet
+	// ClockSkew is how far apart the clocks of the database may be, two commits closer than that are not ordered by TimeGraph
+	ClockSkew time.Duration
+	// CommitTime gives the commit timestamps of TimeGraph, core.OpTime by default
+	CommitTime core.CommitTimeFn
+	// RealtimeEpsilon, if positive, orders the ops of RealtimeGraph by their :time instead of their position
+	// in the history: an op precedes another only if it completed more than RealtimeEpsilon before the invocation
+	RealtimeEpsilon time.Duration
+	// Parallelism is the number of workers searching the SCCs for the cycles of every anomaly spec,
+	// the search is serial below 2. The anomalies are reported in the same order either way.
+	Parallelism int
 }
 
+// CycleSearchTimeout is the anomaly of an SCC whose cycle search ran out of time (or was canceled)
+// while searching for AnomalySpecType, after ruling out the spec types in DoesNotContain.
+type CycleSearchTimeout struct {
+	AnomalySpecType string   `json:"anomaly_spec_type"`
+	DoesNotContain  []string `json:"does_not_contain"`
+	SccSize         int      `json:"scc_size"`
+}
+
+// IAnomaly ...
+func (CycleSearchTimeout) IAnomaly() {}
+
 // CheckResult records the check result
 type CheckResult struct {
 	// valid? true | :unknown | false
@@ -41,75 +79,305 @@ func (c CheckResult) Error() string {
 //	and a history. Analyzes the history and yields the analysis, plus an anomaly
 //	map like {:G1c [...]}.
 func Cycles(analyzer core.Analyzer, history core.History, opts ...interface{}) core.CheckResult {
-	checkedResult := core.Check(analyzer, history, opts...)
-	cases := CycleCases(checkedResult.Graph, checkedResult.Explainer, checkedResult.Sccs)
+	checkedResult, _ := cycles(context.Background(), Opts{}, analyzer, history, opts...)
+	return checkedResult
+}
+
+func cycles(ctx context.Context, opts Opts, analyzer core.Analyzer, history core.History, analyzerOpts ...interface{}) (core.CheckResult, map[string][]core.Anomaly) {
+	checkedResult := core.Check(analyzer, history, analyzerOpts...)
+	// the budget covers the cycle search and the pair cases
+	if opts.CycleSearchBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.CycleSearchBudget)
+		defer cancel()
+	}
+	cases := CycleCasesContext(ctx, opts, checkedResult.Graph, checkedResult.Explainer, checkedResult.Sccs)
+	// once ctx is done the SCCs are reported as cycle-search-timeout already
+	if ctx.Err() == nil {
+		for k, v := range PairCasesContext(ctx, opts, checkedResult.Graph, checkedResult.Explainer, checkedResult.Sccs) {
+			cases[k] = append(cases[k], v...)
+		}
+	}
 	for k, v := range cases {
 		checkedResult.Anomalies[k] = v
 	}
-	return checkedResult
+	return checkedResult, cases
 }
 
 // CycleCases finds anomaly cases and group them by there name
 func CycleCases(graph core.DirectedGraph, pairExplainer core.DataExplainer, sccs []core.SCC) map[string][]core.Anomaly {
-	g := FilteredGraphs(graph)
-	cases := map[string][]core.Anomaly{}
-	for _, scc := range sccs {
-		for _, v := range CycleCasesInScc(graph, g, pairExplainer, scc) {
-			if _, e := cases[string(v.Typ)]; !e {
-				cases[string(v.Typ)] = make([]core.Anomaly, 0)
+	return CycleCasesContext(context.Background(), Opts{}, graph, pairExplainer, sccs)
+}
+
+// CycleCasesContext is CycleCases within the time budgets of opts, the search stops when ctx is done.
+// Every SCC not fully searched adds a CycleSearchTimeout to the cycle-search-timeout cases.
+func CycleCasesContext(ctx context.Context, opts Opts, graph core.DirectedGraph, pairExplainer core.DataExplainer, sccs []core.SCC) map[string][]core.Anomaly {
+	if opts.CycleSearchBudget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.CycleSearchBudget)
+		defer cancel()
+	}
+	cg := core.CompactGraph(&graph)
+	g := CompactFilteredGraphs(cg)
+
+	var sccResults [][]specSearch
+	if opts.Parallelism > 1 {
+		sccResults = parallelCycleSearch(ctx, opts, cg, g, pairExplainer, sccs)
+	} else {
+		for _, scc := range sccs {
+			sccCtx, cancel := ctx, context.CancelFunc(func() {})
+			if opts.CycleSearchTimeout > 0 {
+				sccCtx, cancel = context.WithTimeout(ctx, opts.CycleSearchTimeout)
 			}
+			sccResults = append(sccResults, serialCycleSearch(sccCtx, cg, g, pairExplainer, scc))
+			cancel()
+		}
+	}
+
+	names := specNames()
+	cases := map[string][]core.Anomaly{}
+	for i, scc := range sccs {
+		found, timeout := sccCases(names, sccResults[i], scc)
+		for _, v := range found {
 			cases[string(v.Typ)] = append(cases[string(v.Typ)], v)
 		}
+		if timeout != nil {
+			cases["cycle-search-timeout"] = append(cases["cycle-search-timeout"], *timeout)
+		}
 	}
 	return cases
 }
 
+// specSearch is the outcome of the search of an SCC for the cycle of an anomaly spec
+type specSearch struct {
+	done  bool
+	found *core.CycleExplainerResult
+	err   error
+}
+
+// specNames returns the names of CycleAnomalySpecs in the order they are searched
+func specNames() []string {
+	names := make([]string, 0, len(CycleAnomalySpecs))
+	for cn := range CycleAnomalySpecs {
+		names = append(names, cn)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// serialCycleSearch searches scc for the cycle of every spec in order, it stops at the first that runs out of time
+func serialCycleSearch(ctx context.Context, graph *core.Graph[core.Vertex], filterGraph CompactFilterGraphFn, explainer core.DataExplainer, scc core.SCC) []specSearch {
+	names := specNames()
+	results := make([]specSearch, len(names))
+	for j, cn := range names {
+		results[j] = searchSpec(ctx, graph, filterGraph, explainer, scc, CycleAnomalySpecs[cn])
+		if results[j].err != nil {
+			break
+		}
+	}
+	return results
+}
+
+// parallelCycleSearch searches every scc for the cycle of every spec with opts.Parallelism workers.
+// The time of an SCC starts with the first search of its specs.
+func parallelCycleSearch(ctx context.Context, opts Opts, graph *core.Graph[core.Vertex], filterGraph CompactFilterGraphFn, explainer core.DataExplainer, sccs []core.SCC) [][]specSearch {
+	names := specNames()
+	type sccSearch struct {
+		once    sync.Once
+		ctx     context.Context
+		cancel  context.CancelFunc
+		pending int32
+	}
+	searches := make([]sccSearch, len(sccs))
+	results := make([][]specSearch, len(sccs))
+	for i := range sccs {
+		searches[i].pending = int32(len(names))
+		results[i] = make([]specSearch, len(names))
+	}
+
+	type job struct{ scc, spec int }
+	jobs := make(chan job)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				search := &searches[j.scc]
+				search.once.Do(func() {
+					search.ctx, search.cancel = ctx, func() {}
+					if opts.CycleSearchTimeout > 0 {
+						search.ctx, search.cancel = context.WithTimeout(ctx, opts.CycleSearchTimeout)
+					}
+				})
+				results[j.scc][j.spec] = searchSpec(search.ctx, graph, filterGraph, explainer, sccs[j.scc], CycleAnomalySpecs[names[j.spec]])
+				if atomic.AddInt32(&search.pending, -1) == 0 {
+					search.cancel()
+				}
+			}
+		}()
+	}
+	for i := range sccs {
+		for j := range names {
+			jobs <- job{scc: i, spec: j}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// searchSpec searches scc for the cycle of an anomaly spec
+func searchSpec(ctx context.Context, graph *core.Graph[core.Vertex], filterGraph CompactFilterGraphFn, explainer core.DataExplainer, scc core.SCC, v CycleAnomalySpecType) specSearch {
+	runtimeGraph := graph
+	if v.Rels != nil {
+		runtimeGraph = filterGraph(setKeys(v.Rels))
+	}
+	var c []core.Vertex
+	var err error
+	if v.With != nil {
+		c, err = core.FindCompactCycleWith(ctx, runtimeGraph, scc, v.With)
+	} else if v.Rels != nil {
+		c, err = core.FindCompactCycleWith(ctx, runtimeGraph, scc, nil)
+	} else {
+		// TODO(mahjonp): need review
+		// Note: this requires find-cycle-starting-with
+		//s1 := filterGraph([]core.Rel{v.FirstRel})
+		//s2 := filterGraph(setKeys(v.RestRels))
+		filteredGraph := filterGraph(core.RelSet([]core.Rel{v.FirstRel}).Append(v.RestRels))
+		c, err = core.FindCompactCycleWith(ctx, filteredGraph, scc, core.StartingWith(v.FirstRel, core.RelSet{}.Append(v.RestRels)))
+	}
+	if err != nil {
+		return specSearch{done: true, err: err}
+	}
+	cycle := core.NewCircle(c)
+	if cycle == nil {
+		return specSearch{done: true}
+	}
+
+	// the steps are explained by the relations of the spec
+	rels := core.RelMaskOf(setKeys(v.Rels)...) | core.RelMaskOf(setKeys(v.RestRels)...)
+	if v.FirstRel != "" {
+		rels |= core.RelBit(v.FirstRel)
+	}
+	explainerWrapper := CycleExplainerWrapper{}
+	ex := explainerWrapper.ExplainCycle(core.RestrictExplainer(explainer, rels), *cycle)
+	if v.FilterEx != nil && !v.FilterEx(&ex) {
+		return specSearch{done: true}
+	}
+	if v.Typ != "" {
+		ex.Typ = v.Typ
+	}
+	return specSearch{done: true, found: &ex}
+}
+
+// sccCases gathers the cases found in scc in the order of the specs, and a CycleSearchTimeout naming
+// the first spec that ran out of time and the specs ruled out, if any did
+func sccCases(names []string, results []specSearch, scc core.SCC) ([]core.CycleExplainerResult, *CycleSearchTimeout) {
+	var cases []core.CycleExplainerResult
+	var timeout *CycleSearchTimeout
+	var searched []string
+	for j, r := range results {
+		switch {
+		case !r.done:
+		case r.err != nil:
+			if timeout == nil {
+				timeout = &CycleSearchTimeout{AnomalySpecType: names[j], SccSize: len(scc.Vertices)}
+			}
+		case r.found != nil:
+			cases = append(cases, *r.found)
+		default:
+			searched = append(searched, names[j])
+		}
+	}
+	if timeout != nil {
+		timeout.DoesNotContain = searched
+	}
+	return cases, timeout
+}
+
 // FilterGraphFn ...
 type FilterGraphFn = func(rels []core.Rel) *core.DirectedGraph
 
 // CycleCasesInScc searches a single SCC for cycle anomalies.
-// TODO: add timeout logic.
 func CycleCasesInScc(graph core.DirectedGraph, filterGraph FilterGraphFn, explainer core.DataExplainer, scc core.SCC) []core.CycleExplainerResult {
-	var cases []core.CycleExplainerResult
-	for cn, v := range CycleAnomalySpecs {
-		_ = cn
-		var runtimeGraph *core.DirectedGraph
-		if v.Rels != nil {
-			runtimeGraph = filterGraph(setKeys(v.Rels))
-		} else {
-			runtimeGraph = &graph
-		}
-		var cycle *core.Circle
-		if v.With != nil {
-			c := core.FindCycleWith(runtimeGraph, scc, v.With)
-			cycle = core.NewCircle(c)
-		} else if v.Rels != nil {
-			c := core.FindCycle(runtimeGraph, scc)
-			cycle = core.NewCircle(c)
-		} else {
-			// TODO(mahjonp): need review
-			// Note: this requires find-cycle-starting-with
-			//s1 := filterGraph([]core.Rel{v.FirstRel})
-			//s2 := filterGraph(setKeys(v.RestRels))
-			filteredGraph := filterGraph(core.RelSet([]core.Rel{v.FirstRel}).Append(v.RestRels))
-			c := core.FindCycleStartingWith(filteredGraph, scc, v.FirstRel, core.RelSet{}.Append(v.RestRels))
-			cycle = core.NewCircle(c)
+	compactFilter := func(rels []core.Rel) *core.Graph[core.Vertex] {
+		return core.CompactGraph(filterGraph(rels))
+	}
+	cases, _ := CycleCasesInSccContext(context.Background(), core.CompactGraph(&graph), compactFilter, explainer, scc)
+	return cases
+}
+
+// CompactFilterGraphFn is FilterGraphFn on the compact form of a graph
+type CompactFilterGraphFn = func(rels []core.Rel) *core.Graph[core.Vertex]
+
+// CycleCasesInSccContext is CycleCasesInScc on the compact form of the graph, it gives up once ctx is done
+// and returns the cases found so far with a CycleSearchTimeout.
+func CycleCasesInSccContext(ctx context.Context, graph *core.Graph[core.Vertex], filterGraph CompactFilterGraphFn, explainer core.DataExplainer, scc core.SCC) ([]core.CycleExplainerResult, *CycleSearchTimeout) {
+	return sccCases(specNames(), serialCycleSearch(ctx, graph, filterGraph, explainer, scc), scc)
+}
+
+// CyclesWithDraw means "cycles!" in clojure: it is Cycles, and with a directory in opts it also writes
+// the layout of Elle, i.e. the explanation of every SCC to cycles.txt, the explanations of the cycles
+// of every anomaly type to <type>.txt and their plots to <type>/<i>.<format>.
+func CyclesWithDraw(opts Opts, analyzer core.Analyzer, history core.History, analyzerOpts ...interface{}) (core.CheckResult, error) {
+	return CyclesWithDrawContext(context.Background(), opts, analyzer, history, analyzerOpts...)
+}
+
+// CyclesWithDrawContext is CyclesWithDraw with the cycle search bounded by ctx and the budgets of opts.
+// An invalid PlotFormat is an error before the search, a failure to write returns the result along with the error.
+func CyclesWithDrawContext(ctx context.Context, opts Opts, analyzer core.Analyzer, history core.History, analyzerOpts ...interface{}) (core.CheckResult, error) {
+	if opts.Directory != "" {
+		if err := ValidatePlotFormat(opts.PlotFormat); err != nil {
+			return core.CheckResult{}, err
 		}
+	}
+	checkedResult, cases := cycles(ctx, opts, analyzer, history, analyzerOpts...)
+	if opts.Directory == "" {
+		return checkedResult, nil
+	}
+	format := opts.PlotFormat
+	if format == "" {
+		format = "svg"
+	}
 
-		if cycle != nil {
-			explainerWrapper := CycleExplainerWrapper{}
-			ex := explainerWrapper.ExplainCycle(explainer, *cycle)
-			if v.FilterEx != nil && !v.FilterEx(&ex) {
+	if err := core.WriteCycles(core.CycleExplainer{}, checkedResult.Explainer, opts.Directory, "cycles.txt", checkedResult.Cycles); err != nil {
+		return checkedResult, err
+	}
+	types := make([]string, 0, len(cases))
+	for typ := range cases {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		var explanations []string
+		var sccs []core.SCC
+		for _, anomaly := range cases[typ] {
+			cr, ok := anomaly.(core.CycleExplainerResult)
+			if !ok {
 				continue
 			}
-			cases = append(cases, ex)
+			explanations = append(explanations, CycleExplainerWrapper{}.RenderCycleExplanation(checkedResult.Explainer, cr))
+			sccs = append(sccs, cycleScc(cr.Circle))
+		}
+		if len(explanations) == 0 {
+			continue
+		}
+		if err := core.WriteCycles(core.CycleExplainer{}, checkedResult.Explainer, opts.Directory, typ+".txt", explanations); err != nil {
+			return checkedResult, err
+		}
+		if err := plotSccs(checkedResult, sccs, filepath.Join(opts.Directory, typ), format); err != nil {
+			return checkedResult, err
 		}
 	}
-	return cases
+	return checkedResult, nil
 }
 
-// CyclesWithDraw means "cycles!" in clojure.
-// TODO: This function contains some logic like draw, so I leave it unimplemented.
-func CyclesWithDraw() {
-	panic("implement me")
+// the ops of a cycle, plotted with the edges between them
+func cycleScc(circle core.Circle) core.SCC {
+	var scc core.SCC
+	for _, op := range circle.Path[:len(circle.Path)-1] {
+		scc.Vertices = append(scc.Vertices, core.Vertex{Value: op})
+	}
+	return scc
 }
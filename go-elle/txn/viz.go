@@ -0,0 +1,192 @@
+package txn
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-graphviz"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+type record struct {
+	name      string
+	label     string
+	height    float32
+	color     string
+	fontColor string
+}
+
+func (r record) String() string {
+	return fmt.Sprintf(`%s [height=%.2f,shape=record,label="%s",color="%s",fontcolor="%s"]`, r.name, r.height, r.label, r.color, r.fontColor)
+}
+
+type edge struct {
+	from      string
+	to        string
+	label     string
+	color     string
+	fontColor string
+}
+
+func (e edge) String() string {
+	return fmt.Sprintf(`%s -> %s [label="%s",fontcolor="%s",color="%s"]`, e.from, e.to, e.label, e.color, e.fontColor)
+}
+
+var typeColor = map[core.OpType]string{
+	core.OpTypeOk:   "#0058AD",
+	core.OpTypeInfo: "#AC6E00",
+	core.OpTypeFail: "#A50053",
+}
+
+func relColor(rel core.DependType) string {
+	switch rel {
+	case core.WWDepend:
+		return "#C02700"
+	case core.WRDepend:
+		return "#C000A5"
+	case core.RWDepend:
+		return "#5B00C0"
+	case core.RealtimeDepend:
+		return "#0050C0"
+	case core.ProcessDepend:
+		return "#00C0C0"
+	default:
+		return "#585858"
+	}
+
+}
+
+func renderOp(nodeIdx map[core.Op]string, op core.Op) record {
+	var labels []string
+	for idx, mop := range *op.Value {
+		labels = append(labels, fmt.Sprintf("<f%d> %s", idx, mop.String()))
+	}
+	return record{
+		name:      nodeIdx[op],
+		label:     strings.Join(labels, "|"),
+		height:    0.4,
+		color:     typeColor[op.Type],
+		fontColor: typeColor[op.Type],
+	}
+}
+
+func renderEdge(analysis core.CheckResult, nodeIdx map[core.Op]string, a, b core.Op) edge {
+	explainer := analysis.Explainer
+	ex := explainer.ExplainPairData(a, b)
+	an := nodeIdx[a]
+	bn := nodeIdx[b]
+	// point at the mops of the dependency, if known
+	if keyed, ok := ex.(core.KeyedExplainResult); ok {
+		ami, bmi := keyed.MopIndexes()
+		if ami >= 0 {
+			an = fmt.Sprintf("%s:f%d", an, ami)
+		}
+		if bmi >= 0 {
+			bn = fmt.Sprintf("%s:f%d", bn, bmi)
+		}
+	}
+	return edge{
+		from:      an,
+		to:        bn,
+		label:     string(ex.Type()),
+		color:     relColor(ex.Type()),
+		fontColor: relColor(ex.Type()),
+	}
+}
+
+func renderEdges(analysis core.CheckResult, scc core.SCC, nodeIdx map[core.Op]string, op core.Op) []edge {
+	edges := make([]edge, 0)
+	g := analysis.Graph
+
+	sccSet := map[core.Op]struct{}{}
+	for _, v := range scc.Vertices {
+		sccSet[v.Value.(core.Op)] = struct{}{}
+	}
+	for _, nextOp := range g.Out(core.Vertex{Value: op}) {
+		if _, e := sccSet[nextOp.Value.(core.Op)]; e {
+			edges = append(edges, renderEdge(analysis, nodeIdx, op, nextOp.Value.(core.Op)))
+		}
+	}
+	return edges
+}
+
+func renderScc(analysis core.CheckResult, scc core.SCC) string {
+	var tpl = []string{"digraph g {"}
+	nodeIdx := make(map[core.Op]string)
+	for i, node := range scc.Vertices {
+		op := node.Value.(core.Op)
+		if op.Index.Present() {
+			nodeIdx[op] = fmt.Sprintf("T%d", op.Index.MustGet())
+		} else {
+			nodeIdx[op] = fmt.Sprintf("n%d", i)
+		}
+	}
+
+	var nodes []record
+	var edges []edge
+
+	for _, node := range scc.Vertices {
+		op := node.Value.(core.Op)
+		nodes = append(nodes, renderOp(nodeIdx, op))
+		edges = append(edges, renderEdges(analysis, scc, nodeIdx, op)...)
+	}
+
+	for _, node := range nodes {
+		tpl = append(tpl, fmt.Sprintf("    %s", node.String()))
+	}
+
+	tpl = append(tpl, "\n")
+
+	for _, edge := range edges {
+		tpl = append(tpl, fmt.Sprintf("    %s", edge.String()))
+	}
+
+	tpl = append(tpl, "}")
+	return strings.Join(tpl, "\n")
+}
+
+// PlotAnalysis renders every SCC of an analysis to <directory>/<i>.<format>,
+// format is svg, png or dot
+func PlotAnalysis(analysis core.CheckResult, directory string, format string) error {
+	return plotSccs(analysis, analysis.Sccs, directory, format)
+}
+
+// ValidatePlotFormat returns an error unless format is svg, png, dot or empty for svg
+func ValidatePlotFormat(format string) error {
+	switch format {
+	case "", "svg", "png", "dot":
+		return nil
+	}
+	return fmt.Errorf("invalid plot format %s, expected svg, png or dot", format)
+}
+
+func plotSccs(analysis core.CheckResult, sccs []core.SCC, directory string, format string) error {
+	if err := os.MkdirAll(directory, 0755); err != nil {
+		return err
+	}
+	g := graphviz.New()
+	defer g.Close()
+	for i, scc := range sccs {
+		tpl := renderScc(analysis, scc)
+		fileName := filepath.Join(directory, fmt.Sprintf("%d.%s", i, format))
+		if format == "dot" {
+			if err := os.WriteFile(fileName, []byte(tpl), 0644); err != nil {
+				return err
+			}
+			continue
+		}
+		graph, err := graphviz.ParseBytes([]byte(tpl))
+		if err != nil {
+			return err
+		}
+		if err := g.RenderFilename(graph, graphviz.Format(format), fileName); err != nil {
+			graph.Close()
+			return err
+		}
+		graph.Close()
+	}
+	return nil
+}
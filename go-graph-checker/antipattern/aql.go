@@ -0,0 +1,56 @@
+package antipattern
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func quoteTypes(types []string) string {
+	quoted := make([]string, len(types))
+	for i, t := range types {
+		quoted[i] = strconv.Quote(t)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+/*
+AQLFilter compiles the pattern to an AQL condition on an array of edges, e.g. path.edges,
+it returns an empty string for a pattern matching any cycle
+
+	edges ww wr        ->  path.edges[*].type ALL IN ["ww", "wr"]
+	count rw < 2       ->  LENGTH(path.edges[* FILTER CURRENT.type == "rw"]) < 2
+	nonadjacent rw rw  ->  LENGTH(FOR i IN 0..LENGTH(path.edges) - 1
+	                           FILTER path.edges[i].type == "rw" AND path.edges[(i + 1) % LENGTH(path.edges)].type == "rw"
+	                           RETURN i) == 0
+*/
+func (p Pattern) AQLFilter(edges string) string {
+	var conds []string
+	if len(p.Edges) > 0 {
+		conds = append(conds, fmt.Sprintf("%s[*].type ALL IN %s", edges, quoteTypes(p.Edges)))
+	}
+	for _, c := range p.Counts {
+		conds = append(conds, fmt.Sprintf("LENGTH(%s[* FILTER CURRENT.type == %s]) %s %d", edges, strconv.Quote(c.Type), c.Op, c.N))
+	}
+	for _, a := range p.Adjacency {
+		op := "== 0"
+		if a.Required {
+			op = "> 0"
+		}
+		conds = append(conds, fmt.Sprintf(
+			"LENGTH(FOR i IN 0..LENGTH(%[1]s) - 1 FILTER %[1]s[i].type == %[2]s AND %[1]s[(i + 1) %% LENGTH(%[1]s)].type == %[3]s RETURN i) %[4]s",
+			edges, strconv.Quote(a.From), strconv.Quote(a.To), op))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+/*
+AQLEdgeFilter compiles the allowed edge types to an AQL condition on a single edge,
+used to prune the start edges of the shortest path searches
+*/
+func (p Pattern) AQLEdgeFilter(edge string) string {
+	if len(p.Edges) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s.type IN %s", edge, quoteTypes(p.Edges))
+}
@@ -0,0 +1,122 @@
+package antipattern
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+/*
+Class names the Adya anti-pattern class of a cycle from its edge types:
+G0 (only ww edges), G1c (ww and wr edges), G-single (exactly one rw edge),
+G2-item (more rw edges) or G2-predicate (an rw-predicate edge of a scan);
+other edge types (e.g. realtime) are ignored
+*/
+func Class(types []string) string {
+	var ww, wr, rw, predicate int
+	for _, t := range types {
+		switch t {
+		case "ww":
+			ww++
+		case "wr":
+			wr++
+		case "rw":
+			rw++
+		case string(core.PredicateRW):
+			predicate++
+		}
+	}
+	switch {
+	case predicate > 0:
+		return "G2-predicate"
+	case rw > 1:
+		return "G2-item"
+	case rw == 1:
+		return "G-single"
+	case wr > 0:
+		return "G1c"
+	case ww > 0:
+		return "G0"
+	default:
+		return ""
+	}
+}
+
+/*
+AdyaName names the most specific Adya anomaly of a cycle from its edge types, as go-elle does:
+G0, G1c, G-single (one rw edge), G-nonadjacent (more rw edges, none directly followed by another,
+including the step from the last edge back to the first), G2-item or G2-predicate, suffixed with -realtime,
+-time or -process if the cycle relies on such edges
+*/
+func AdyaName(types []string) string {
+	var rw, realtime, commitTime, process int
+	rwAdj := false
+	for i, t := range types {
+		switch t {
+		case "rw":
+			rw++
+			if len(types) > 1 && types[(i+len(types)-1)%len(types)] == "rw" {
+				rwAdj = true
+			}
+		case "realtime":
+			realtime++
+		case "time":
+			commitTime++
+		case "process":
+			process++
+		}
+	}
+	name := Class(types)
+	if name == "G2-item" && !rwAdj {
+		name = "G-nonadjacent"
+	}
+	if name == "" {
+		return ""
+	}
+	switch {
+	case realtime > 0:
+		name += "-realtime"
+	case commitTime > 0:
+		name += "-time"
+	case process > 0:
+		name += "-process"
+	}
+	return name
+}
+
+/*
+Anomaly is a witness cycle named after its Adya anomaly, with the consistency models it rules out
+as go-elle reports them: Not the weakest ones, AlsoNot the stronger ones
+*/
+type Anomaly struct {
+	Name    string   `json:"name"`
+	Not     []string `json:"not"`
+	AlsoNot []string `json:"also_not"`
+}
+
+/*
+Classify names the Adya anomaly of a cycle from its edge types and the models it rules out
+by go-elle's FriendlyBoundary, so that GRAIL and go-elle report in the same vocabulary
+*/
+func Classify(types []string) Anomaly {
+	a := Anomaly{Name: AdyaName(types)}
+	if a.Name != "" {
+		a.Not, a.AlsoNot = core.FriendlyBoundary([]string{a.Name})
+		sort.Strings(a.Not)
+		sort.Strings(a.AlsoNot)
+	}
+	return a
+}
+
+func (a Anomaly) String() string {
+	if len(a.Not) == 0 {
+		return a.Name
+	}
+	s := fmt.Sprintf("%s, not %s", a.Name, strings.Join(a.Not, ", "))
+	if len(a.AlsoNot) > 0 {
+		s += fmt.Sprintf(" (also not %s)", strings.Join(a.AlsoNot, ", "))
+	}
+	return s
+}
@@ -0,0 +1,83 @@
+package antipattern
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+)
+
+var elleRels = map[string]core.Rel{
+	string(core.WW):       core.WW,
+	string(core.WR):       core.WR,
+	string(core.RW):       core.RW,
+	string(core.Process):  core.Process,
+	string(core.Realtime): core.Realtime,
+}
+
+/*
+CyclePredicate compiles the pattern to a go-elle cycle predicate, a step with several
+relationships matches if any of them does
+*/
+func (p Pattern) CyclePredicate() core.CyclePredicate {
+	return func(trace []core.CycleTrace) bool {
+		steps := make([][]string, len(trace))
+		for i, t := range trace {
+			for _, rel := range t.Rels {
+				steps[i] = append(steps[i], string(rel))
+			}
+		}
+		return p.MatchSteps(steps)
+	}
+}
+
+/*
+ElleSpec compiles the pattern to a go-elle cycle anomaly spec: the allowed edge types restrict
+the searched graph, the other constraints become the With predicate, and found cycles are
+reported under the pattern name
+*/
+func (p Pattern) ElleSpec() (txn.CycleAnomalySpecType, error) {
+	if err := p.Validate(); err != nil {
+		return txn.CycleAnomalySpecType{}, err
+	}
+
+	types := p.Edges
+	if len(types) == 0 {
+		types = []string{string(core.WW), string(core.WR), string(core.RW)}
+	}
+	rels := map[core.Rel]struct{}{}
+	for _, t := range types {
+		rel, ok := elleRels[t]
+		if !ok {
+			return txn.CycleAnomalySpecType{}, fmt.Errorf("pattern %s: edge type %q is not a go-elle relationship", p.Name, t)
+		}
+		rels[rel] = struct{}{}
+	}
+
+	spec := txn.CycleAnomalySpecType{Rels: rels, Typ: p.Name}
+	if len(p.Counts) > 0 || len(p.Adjacency) > 0 {
+		spec.With = p.CyclePredicate()
+	}
+	return spec, nil
+}
+
+/*
+RegisterElle adds the pattern to the go-elle cycle anomaly specs, so that cycle searches
+report its cycles as anomalies named after the pattern
+*/
+func RegisterElle(p Pattern) error {
+	spec, err := p.ElleSpec()
+	if err != nil {
+		return err
+	}
+	txn.CycleAnomalySpecs[p.Name] = spec
+	txn.CycleTypeNames[p.Name] = struct{}{}
+	if strings.Contains(p.Name, "process") {
+		txn.ProcessAnalysisTypes[p.Name] = struct{}{}
+	}
+	if strings.Contains(p.Name, "realtime") {
+		txn.RealtimeAnalysisTypes[p.Name] = struct{}{}
+	}
+	return nil
+}
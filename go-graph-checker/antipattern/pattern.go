@@ -0,0 +1,239 @@
+package antipattern
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+Pattern declares the cycles that are anti-patterns of an isolation level. A cycle matches when
+  - every edge type is one of Edges (any type if Edges is empty)
+  - every Count holds for the number of edges of its type
+  - every Adjacency holds when walking the cycle, including the step from the last edge back to the first
+
+A pattern without any constraint matches every cycle, as for SER / PL-3.
+*/
+type Pattern struct {
+	Name      string
+	Edges     []string
+	Counts    []Count
+	Adjacency []Adjacency
+}
+
+/*
+Count compares the number of edges of Type with N, Op is one of <, <=, ==, !=, >=, >
+*/
+type Count struct {
+	Type string
+	Op   string
+	N    int
+}
+
+/*
+Adjacency constrains an edge of type From directly followed by an edge of type To:
+at least one such pair if Required, otherwise none
+*/
+type Adjacency struct {
+	From     string
+	To       string
+	Required bool
+}
+
+var typeRegex = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+var countOps = map[string]func(int, int) bool{
+	"<":  func(a, b int) bool { return a < b },
+	"<=": func(a, b int) bool { return a <= b },
+	"==": func(a, b int) bool { return a == b },
+	"!=": func(a, b int) bool { return a != b },
+	">=": func(a, b int) bool { return a >= b },
+	">":  func(a, b int) bool { return a > b },
+}
+
+/*
+the standard levels, each pattern is the anti-pattern the level proscribes
+*/
+var (
+	SER = MustParse("SER", "")
+	SI  = MustParse("SI", "nonadjacent rw rw")
+	PSI = MustParse("PSI", "count rw < 2")
+	PL2 = MustParse("PL-2", "edges ww wr")
+	PL1 = MustParse("PL-1", "edges ww")
+)
+
+// Any reports whether the pattern matches every cycle
+func (p Pattern) Any() bool {
+	return len(p.Edges) == 0 && len(p.Counts) == 0 && len(p.Adjacency) == 0
+}
+
+// Validate checks edge types and operators, so that they can be safely compiled to AQL
+func (p Pattern) Validate() error {
+	types := append([]string{}, p.Edges...)
+	for _, c := range p.Counts {
+		if _, ok := countOps[c.Op]; !ok {
+			return fmt.Errorf("pattern %s: invalid count operator %q", p.Name, c.Op)
+		}
+		if c.N < 0 {
+			return fmt.Errorf("pattern %s: negative count %d", p.Name, c.N)
+		}
+		types = append(types, c.Type)
+	}
+	for _, a := range p.Adjacency {
+		types = append(types, a.From, a.To)
+	}
+	for _, t := range types {
+		if !typeRegex.MatchString(t) {
+			return fmt.Errorf("pattern %s: invalid edge type %q", p.Name, t)
+		}
+	}
+	return nil
+}
+
+func (p Pattern) allowed(t string) bool {
+	if len(p.Edges) == 0 {
+		return true
+	}
+	for _, e := range p.Edges {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Match reports whether a cycle, given as the types of its edges in order, is an anti-pattern
+*/
+func (p Pattern) Match(types []string) bool {
+	if len(types) == 0 {
+		return false
+	}
+	for _, t := range types {
+		if !p.allowed(t) {
+			return false
+		}
+	}
+	for _, c := range p.Counts {
+		n := 0
+		for _, t := range types {
+			if t == c.Type {
+				n++
+			}
+		}
+		if !countOps[c.Op](n, c.N) {
+			return false
+		}
+	}
+	for _, a := range p.Adjacency {
+		found := false
+		for i, t := range types {
+			if t == a.From && types[(i+1)%len(types)] == a.To {
+				found = true
+				break
+			}
+		}
+		if found != a.Required {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+MatchSteps is Match for cycles whose steps may carry several types at once (as go-elle steps do),
+it reports whether some choice of one type per step matches
+*/
+func (p Pattern) MatchSteps(steps [][]string) bool {
+	types := make([]string, len(steps))
+	var choose func(i int) bool
+	choose = func(i int) bool {
+		if i == len(steps) {
+			return p.Match(types)
+		}
+		for _, t := range steps[i] {
+			if !p.allowed(t) {
+				continue
+			}
+			types[i] = t
+			if choose(i + 1) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(steps) > 0 && choose(0)
+}
+
+// String renders the pattern in the syntax accepted by Parse
+func (p Pattern) String() string {
+	var clauses []string
+	if len(p.Edges) > 0 {
+		clauses = append(clauses, "edges "+strings.Join(p.Edges, " "))
+	}
+	for _, c := range p.Counts {
+		clauses = append(clauses, fmt.Sprintf("count %s %s %d", c.Type, c.Op, c.N))
+	}
+	for _, a := range p.Adjacency {
+		keyword := "nonadjacent"
+		if a.Required {
+			keyword = "adjacent"
+		}
+		clauses = append(clauses, fmt.Sprintf("%s %s %s", keyword, a.From, a.To))
+	}
+	return strings.Join(clauses, "; ")
+}
+
+/*
+Parse reads a pattern from clauses separated by semicolons or new lines:
+
+	edges ww wr         every edge is ww or wr
+	count rw < 2        fewer than two rw edges (operators <, <=, ==, !=, >=, >)
+	nonadjacent rw rw   no rw edge directly followed by a rw edge, cyclically
+	adjacent rw rw      at least one rw edge directly followed by a rw edge
+
+Lines starting with # are comments, an empty pattern matches any cycle.
+*/
+func Parse(name string, src string) (Pattern, error) {
+	p := Pattern{Name: name}
+	for _, line := range strings.FieldsFunc(src, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		switch fields[0] {
+		case "edges":
+			if len(fields) < 2 {
+				return p, fmt.Errorf("pattern %s: edges needs at least one type: %q", name, line)
+			}
+			p.Edges = append(p.Edges, fields[1:]...)
+		case "count":
+			if len(fields) != 4 {
+				return p, fmt.Errorf("pattern %s: expected count <type> <op> <n>: %q", name, line)
+			}
+			n, err := strconv.Atoi(fields[3])
+			if err != nil {
+				return p, fmt.Errorf("pattern %s: invalid count %q: %v", name, fields[3], err)
+			}
+			p.Counts = append(p.Counts, Count{Type: fields[1], Op: fields[2], N: n})
+		case "adjacent", "nonadjacent":
+			if len(fields) != 3 {
+				return p, fmt.Errorf("pattern %s: expected %s <type> <type>: %q", name, fields[0], line)
+			}
+			p.Adjacency = append(p.Adjacency, Adjacency{From: fields[1], To: fields[2], Required: fields[0] == "adjacent"})
+		default:
+			return p, fmt.Errorf("pattern %s: unknown clause %q", name, fields[0])
+		}
+	}
+	return p, p.Validate()
+}
+
+// MustParse is Parse for patterns known to be valid
+func MustParse(name string, src string) Pattern {
+	p, err := Parse(name, src)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
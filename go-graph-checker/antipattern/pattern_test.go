@@ -0,0 +1,199 @@
+package antipattern
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/stretchr/testify/require"
+)
+
+// the predicates the checkers used before the patterns, kept as the reference semantics
+func legacySI(types []string) bool {
+	for i, t := range types {
+		if t == "rw" && types[(i+1)%len(types)] == "rw" {
+			return false
+		}
+	}
+	return true
+}
+
+func legacyPSI(types []string) bool {
+	n := 0
+	for _, t := range types {
+		if t == "rw" {
+			n++
+		}
+	}
+	return n < 2
+}
+
+func legacyPL2(types []string) bool {
+	for _, t := range types {
+		if t == "rw" {
+			return false
+		}
+	}
+	return true
+}
+
+func legacyPL1(types []string) bool {
+	for _, t := range types {
+		if t != "ww" {
+			return false
+		}
+	}
+	return true
+}
+
+// every sequence of ww, wr and rw edges of the given lengths
+func allCycles(minLen, maxLen int) [][]string {
+	var cycles [][]string
+	var grow func(prefix []string)
+	grow = func(prefix []string) {
+		if len(prefix) >= minLen {
+			cycles = append(cycles, append([]string{}, prefix...))
+		}
+		if len(prefix) == maxLen {
+			return
+		}
+		for _, t := range []string{"ww", "wr", "rw"} {
+			grow(append(prefix, t))
+		}
+	}
+	grow(nil)
+	return cycles
+}
+
+func TestStandardLevels(t *testing.T) {
+	for _, tc := range []struct {
+		pattern Pattern
+		legacy  func([]string) bool
+	}{
+		{SER, func([]string) bool { return true }},
+		{SI, legacySI},
+		{PSI, legacyPSI},
+		{PL2, legacyPL2},
+		{PL1, legacyPL1},
+	} {
+		for _, cycle := range allCycles(1, 6) {
+			require.Equal(t, tc.legacy(cycle), tc.pattern.Match(cycle), "%s on %v", tc.pattern.Name, cycle)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse("G2-item", `
+		# at least two rw edges, two of them adjacent
+		count rw >= 2; adjacent rw rw
+	`)
+	require.NoError(t, err)
+	require.Equal(t, "count rw >= 2; adjacent rw rw", p.String())
+	require.True(t, p.Match([]string{"ww", "rw", "rw"}))
+	require.True(t, p.Match([]string{"rw", "wr", "rw"}))
+	require.False(t, p.Match([]string{"rw", "wr", "rw", "ww"}))
+
+	again, err := Parse(p.Name, p.String())
+	require.NoError(t, err)
+	require.Equal(t, p, again)
+
+	for _, src := range []string{
+		"edges",
+		"count rw ~ 2",
+		"count rw < two",
+		"adjacent rw",
+		"edges ww \"wr",
+		"cyclic rw",
+	} {
+		_, err := Parse("bad", src)
+		require.Error(t, err, src)
+	}
+}
+
+func TestMatchSteps(t *testing.T) {
+	// the second step is both ww and rw, ww makes it a G0 cycle
+	require.True(t, PL1.MatchSteps([][]string{{"ww"}, {"rw", "ww"}}))
+	require.False(t, PL1.MatchSteps([][]string{{"wr"}, {"rw", "ww"}}))
+	// choosing wr for the step avoids the adjacent rw edges
+	require.True(t, SI.MatchSteps([][]string{{"rw"}, {"rw", "wr"}, {"ww"}}))
+	require.False(t, SI.MatchSteps(nil))
+}
+
+func TestAQLFilter(t *testing.T) {
+	require.Equal(t, "", SER.AQLFilter("path.edges"))
+	require.Equal(t, `path.edges[*].type ALL IN ["ww", "wr"]`, PL2.AQLFilter("path.edges"))
+	require.Equal(t, `LENGTH(cycle.edges[* FILTER CURRENT.type == "rw"]) < 2`, PSI.AQLFilter("cycle.edges"))
+	require.Equal(t,
+		`LENGTH(FOR i IN 0..LENGTH(p) - 1 FILTER p[i].type == "rw" AND p[(i + 1) % LENGTH(p)].type == "rw" RETURN i) == 0`,
+		SI.AQLFilter("p"))
+	require.Equal(t, `edge.type IN ["ww"]`, PL1.AQLEdgeFilter("edge"))
+	require.Equal(t, "", SI.AQLEdgeFilter("edge"))
+}
+
+func TestElleSpec(t *testing.T) {
+	spec, err := PL2.ElleSpec()
+	require.NoError(t, err)
+	require.Equal(t, map[core.Rel]struct{}{core.WW: {}, core.WR: {}}, spec.Rels)
+	require.Nil(t, spec.With)
+	require.Equal(t, "PL-2", spec.Typ)
+
+	spec, err = SI.ElleSpec()
+	require.NoError(t, err)
+	require.Len(t, spec.Rels, 3)
+	trace := func(rels ...core.Rel) []core.CycleTrace {
+		var traces []core.CycleTrace
+		for _, r := range rels {
+			traces = append(traces, core.CycleTrace{Rels: []core.Rel{r}})
+		}
+		return traces
+	}
+	require.True(t, spec.With(trace(core.RW, core.WW, core.RW, core.WR)))
+	require.False(t, spec.With(trace(core.RW, core.RW, core.WW)))
+
+	_, err = MustParse("custom", "edges ww lock").ElleSpec()
+	require.Error(t, err)
+}
+
+func TestClass(t *testing.T) {
+	for types, class := range map[string]string{
+		"ww ww":       "G0",
+		"ww wr":       "G1c",
+		"wr rw":       "G-single",
+		"rw ww rw":    "G2-item",
+		"realtime ww": "G0",
+		"":            "",
+	} {
+		require.Equal(t, class, Class(strings.Fields(types)), types)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for types, name := range map[string]string{
+		"ww ww":               "G0",
+		"ww wr":               "G1c",
+		"wr rw":               "G-single",
+		"rw ww rw":            "G2-item",
+		"rw ww rw ww":         "G-nonadjacent",
+		"rw rw ww":            "G2-item",
+		"realtime rw":         "G-single-realtime",
+		"process ww":          "G0-process",
+		"process realtime wr": "G1c-realtime",
+		"time rw ww rw wr":    "G-nonadjacent-time",
+		"rw-predicate wr":     "G2-predicate",
+		"":                    "",
+	} {
+		require.Equal(t, name, AdyaName(strings.Fields(types)), types)
+	}
+
+	a := Classify([]string{"rw", "wr"})
+	require.Equal(t, "G-single", a.Name)
+	require.Contains(t, a.Not, "consistent-view")
+	require.Contains(t, a.AlsoNot, "snapshot-isolation")
+	require.True(t, strings.HasPrefix(a.String(), "G-single, not "))
+	require.Equal(t, Anomaly{}, Classify(nil))
+
+	// phantoms are allowed by repeatable read
+	a = Classify([]string{"rw-predicate", "rw-predicate"})
+	require.Equal(t, "G2-predicate", a.Name)
+	require.NotContains(t, append(a.Not, a.AlsoNot...), "repeatable-read")
+}
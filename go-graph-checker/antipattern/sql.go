@@ -0,0 +1,65 @@
+package antipattern
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SQLToken encodes an edge type for the SQL filters, a cycle is the concatenation of its tokens
+func SQLToken(t string) string {
+	return "|" + t + "|"
+}
+
+func sqlLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+/*
+SQLFilter compiles the pattern to a SQL condition on a cycle encoded as text: types is the
+column holding the concatenated SQLToken of its edges, first the column holding the token
+of its first edge (used to close the cycle for the adjacency constraints). It returns an
+empty string for a pattern matching any cycle.
+
+	edges ww wr        ->  replace(replace(types, '|ww|', ''), '|wr|', '') = ''
+	count rw < 2       ->  (length(types) - length(replace(types, '|rw|', ''))) / 4 < 2
+	nonadjacent rw rw  ->  instr(types || first, '|rw||rw|') = 0
+*/
+func (p Pattern) SQLFilter(types string, first string) string {
+	var conds []string
+	if len(p.Edges) > 0 {
+		rest := types
+		for _, t := range p.Edges {
+			rest = fmt.Sprintf("replace(%s, %s, '')", rest, sqlLiteral(SQLToken(t)))
+		}
+		conds = append(conds, rest+" = ''")
+	}
+	for _, c := range p.Counts {
+		token := SQLToken(c.Type)
+		conds = append(conds, fmt.Sprintf("(length(%[1]s) - length(replace(%[1]s, %[2]s, ''))) / %[3]d %[4]s %[5]d",
+			types, sqlLiteral(token), len(token), c.Op, c.N))
+	}
+	for _, a := range p.Adjacency {
+		op := "= 0"
+		if a.Required {
+			op = "> 0"
+		}
+		conds = append(conds, fmt.Sprintf("instr(%s || %s, %s) %s",
+			types, first, sqlLiteral(SQLToken(a.From)+SQLToken(a.To)), op))
+	}
+	return strings.Join(conds, " AND ")
+}
+
+/*
+SQLEdgeFilter compiles the allowed edge types to a SQL condition on the type column of a single edge,
+used to prune the recursive cycle search
+*/
+func (p Pattern) SQLEdgeFilter(typ string) string {
+	if len(p.Edges) == 0 {
+		return ""
+	}
+	literals := make([]string, len(p.Edges))
+	for i, t := range p.Edges {
+		literals[i] = sqlLiteral(t)
+	}
+	return fmt.Sprintf("%s IN (%s)", typ, strings.Join(literals, ", "))
+}
@@ -0,0 +1,32 @@
+package antipattern
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLFilterAgreesWithMatch(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+
+	custom := MustParse("G2-item", "count rw >= 2; adjacent rw rw")
+	for _, p := range []Pattern{SI, PSI, PL2, PL1, custom} {
+		query := "SELECT " + p.SQLFilter("?1", "?2")
+		for _, cycle := range allCycles(2, 5) {
+			var types strings.Builder
+			for _, typ := range cycle {
+				types.WriteString(SQLToken(typ))
+			}
+			var got bool
+			require.NoError(t, db.QueryRow(query, types.String(), SQLToken(cycle[0])).Scan(&got))
+			require.Equal(t, p.Match(cycle), got, "%s on %v", p.Name, cycle)
+		}
+	}
+	require.Equal(t, "", SER.SQLFilter("types", "first"))
+	require.Equal(t, "d.type IN ('ww', 'wr')", PL2.SQLEdgeFilter("d.type"))
+}
@@ -0,0 +1,191 @@
+/*
+Package bench times the phases of a check (parse, node insert, edge derivation, edge insert, query)
+with warmup runs and repeated measurements, and records per phase the runtime statistics
+(mean, stddev, min, max, percentiles), the memory allocated by the checker and statistics of the
+database. The records are written as CSV or JSON, one row per history, phase and query, the form
+the scalability figures are plotted from.
+
+	opts := bench.Options{Warmup: 2, Repetitions: 20}
+	records := listappend.BenchSQLite("100.edn", content, dbConsts, []string{"ser", "si"}, []string{"sv"}, opts)
+	err := bench.WriteCSV(os.Stdout, records)
+*/
+package bench
+
+import (
+	"math"
+	"runtime"
+	"sort"
+	"time"
+)
+
+/*
+the phases of a check, in order
+*/
+const (
+	Parse          = "parse"
+	NodeInsert     = "node-insert"
+	EdgeDerivation = "edge-derivation"
+	EdgeInsert     = "edge-insert"
+	Query          = "query"
+)
+
+var Phases = []string{Parse, NodeInsert, EdgeDerivation, EdgeInsert, Query}
+
+/*
+Options configures the measurements: Warmup runs are discarded, Repetitions runs are measured
+*/
+type Options struct {
+	Warmup      int
+	Repetitions int
+}
+
+/*
+DefaultOptions returns one warmup run and 10 repetitions
+*/
+func DefaultOptions() Options {
+	return Options{Warmup: 1, Repetitions: 10}
+}
+
+/*
+Stats summarizes the runtimes of the measured runs, in milliseconds
+*/
+type Stats struct {
+	Runs   int     `json:"runs"`
+	Mean   float64 `json:"mean_ms"`
+	Stddev float64 `json:"stddev_ms"`
+	Min    float64 `json:"min_ms"`
+	P50    float64 `json:"p50_ms"`
+	P90    float64 `json:"p90_ms"`
+	P99    float64 `json:"p99_ms"`
+	Max    float64 `json:"max_ms"`
+}
+
+/*
+NewStats computes the statistics of samples, percentiles are nearest-rank
+*/
+func NewStats(samples []time.Duration) Stats {
+	if len(samples) == 0 {
+		return Stats{}
+	}
+	ms := make([]float64, len(samples))
+	var sum float64
+	for i, d := range samples {
+		ms[i] = float64(d.Nanoseconds()) / 1e6
+		sum += ms[i]
+	}
+	sort.Float64s(ms)
+	mean := sum / float64(len(ms))
+	var sq float64
+	for _, v := range ms {
+		sq += (v - mean) * (v - mean)
+	}
+	stddev := 0.0
+	if len(ms) > 1 {
+		stddev = math.Sqrt(sq / float64(len(ms)-1))
+	}
+	return Stats{
+		Runs:   len(ms),
+		Mean:   mean,
+		Stddev: stddev,
+		Min:    ms[0],
+		P50:    percentile(ms, 50),
+		P90:    percentile(ms, 90),
+		P99:    percentile(ms, 99),
+		Max:    ms[len(ms)-1],
+	}
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+/*
+Memory is what the measured runs allocated on average, and the heap in use after the last one
+*/
+type Memory struct {
+	AllocBytes     uint64 `json:"alloc_bytes"`
+	Mallocs        uint64 `json:"mallocs"`
+	HeapInuseBytes uint64 `json:"heap_inuse_bytes"`
+}
+
+/*
+Measure runs setup then run Warmup+Repetitions times and measures the repeated runs of run,
+setup prepares each run (e.g. a fresh database) and is neither timed nor counted
+*/
+func Measure(opts Options, setup func(), run func()) (Stats, Memory) {
+	for i := 0; i < opts.Warmup; i++ {
+		if setup != nil {
+			setup()
+		}
+		run()
+	}
+
+	samples := make([]time.Duration, 0, opts.Repetitions)
+	var mem Memory
+	var before, after runtime.MemStats
+	for i := 0; i < opts.Repetitions; i++ {
+		if setup != nil {
+			setup()
+		}
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		run()
+		samples = append(samples, time.Since(start))
+		runtime.ReadMemStats(&after)
+		mem.AllocBytes += after.TotalAlloc - before.TotalAlloc
+		mem.Mallocs += after.Mallocs - before.Mallocs
+		mem.HeapInuseBytes = after.HeapInuse
+	}
+	if opts.Repetitions > 0 {
+		mem.AllocBytes /= uint64(opts.Repetitions)
+		mem.Mallocs /= uint64(opts.Repetitions)
+	}
+	return NewStats(samples), mem
+}
+
+/*
+Record is the measurement of a phase on a history, Query names the level and mode of a query
+phase (e.g. "ser/sv") and DB holds statistics of the database after the phase (e.g. row counts)
+*/
+type Record struct {
+	Backend string `json:"backend"`
+	History string `json:"history"`
+	Txns    int    `json:"txns"`
+	Phase   string `json:"phase"`
+	Query   string `json:"query,omitempty"`
+	Stats
+	Memory
+	DB map[string]int64 `json:"db,omitempty"`
+}
+
+/*
+Recorder collects the records of the phases of a history
+*/
+type Recorder struct {
+	Backend string
+	History string
+	Txns    int
+	Opts    Options
+	Records []Record
+}
+
+/*
+Phase measures a phase and records it, see Measure; the record returned is valid until the next call
+*/
+func (r *Recorder) Phase(phase string, query string, setup func(), run func()) *Record {
+	stats, mem := Measure(r.Opts, setup, run)
+	r.Records = append(r.Records, Record{
+		Backend: r.Backend,
+		History: r.History,
+		Txns:    r.Txns,
+		Phase:   phase,
+		Query:   query,
+		Stats:   stats,
+		Memory:  mem,
+	})
+	return &r.Records[len(r.Records)-1]
+}
@@ -0,0 +1,67 @@
+package bench_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/bench"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStats(t *testing.T) {
+	var samples []time.Duration
+	for i := 10; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	s := bench.NewStats(samples)
+	require.Equal(t, 10, s.Runs)
+	require.InDelta(t, 5.5, s.Mean, 1e-9)
+	require.InDelta(t, 3.0277, s.Stddev, 1e-4)
+	require.Equal(t, 1.0, s.Min)
+	require.Equal(t, 5.0, s.P50)
+	require.Equal(t, 9.0, s.P90)
+	require.Equal(t, 10.0, s.P99)
+	require.Equal(t, 10.0, s.Max)
+
+	require.Equal(t, bench.Stats{}, bench.NewStats(nil))
+}
+
+func TestMeasure(t *testing.T) {
+	setups, runs := 0, 0
+	var buf []byte
+	stats, mem := bench.Measure(bench.Options{Warmup: 2, Repetitions: 5}, func() { setups++ }, func() {
+		runs++
+		buf = make([]byte, 1<<20)
+	})
+	require.Equal(t, 7, setups)
+	require.Equal(t, 7, runs)
+	require.Equal(t, 5, stats.Runs)
+	require.GreaterOrEqual(t, mem.AllocBytes, uint64(len(buf)))
+	require.Greater(t, mem.Mallocs, uint64(0))
+}
+
+func TestWrite(t *testing.T) {
+	r := &bench.Recorder{Backend: "sqlite", History: "10.edn", Txns: 10, Opts: bench.Options{Repetitions: 3}}
+	r.Phase(bench.Parse, "", nil, func() {})
+	r.Phase(bench.NodeInsert, "", nil, func() {}).DB = map[string]int64{"txn_count": 10, "bytes": 4096}
+	r.Phase(bench.Query, "ser/sv", nil, func() {})
+
+	var buf bytes.Buffer
+	require.NoError(t, bench.WriteCSV(&buf, r.Records))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	require.True(t, strings.HasSuffix(lines[0], ",db_bytes,db_txn_count"), lines[0])
+	require.True(t, strings.HasPrefix(lines[2], "sqlite,10.edn,10,node-insert,,3,"), lines[2])
+	require.True(t, strings.HasSuffix(lines[2], ",4096,10"), lines[2])
+	require.True(t, strings.HasSuffix(lines[3], ",,"), lines[3])
+
+	buf.Reset()
+	require.NoError(t, bench.WriteJSON(&buf, r.Records))
+	var records []bench.Record
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &records))
+	require.Equal(t, r.Records, records)
+	require.Contains(t, buf.String(), `"mean_ms"`)
+}
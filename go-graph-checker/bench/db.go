@@ -0,0 +1,69 @@
+package bench
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+/*
+ArangoStats returns the document count, the size of the live documents and the size of the
+indexes of each collection, as "<collection>_count", "<collection>_bytes", "<collection>_index_bytes"
+*/
+func ArangoStats(db driver.Database, collections ...string) map[string]int64 {
+	stats := make(map[string]int64)
+	for _, name := range collections {
+		col, err := db.Collection(context.Background(), name)
+		if err != nil {
+			log.Fatalf("Failed to open collection %s: %v\n", name, err)
+		}
+		s, err := col.Statistics(context.Background())
+		if err != nil {
+			log.Fatalf("Failed to read statistics of %s: %v\n", name, err)
+		}
+		stats[name+"_count"] = s.Count
+		stats[name+"_bytes"] = s.Figures.Alive.Size
+		stats[name+"_index_bytes"] = s.Figures.Indexes.Size
+	}
+	return stats
+}
+
+/*
+SQLiteStats returns the row count of every table, as "<table>_count", and the size
+of the database, as "bytes"
+*/
+func SQLiteStats(db *sql.DB) map[string]int64 {
+	rows, err := db.Query(`SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		log.Fatalf("Failed to list tables: %v\n", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			log.Fatalf("Failed to list tables: %v\n", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	stats := make(map[string]int64)
+	for _, table := range tables {
+		var n int64
+		if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+			log.Fatalf("Failed to count %s: %v\n", table, err)
+		}
+		stats[table+"_count"] = n
+	}
+	var pages, pageSize int64
+	if err := db.QueryRow(`PRAGMA page_count`).Scan(&pages); err != nil {
+		log.Fatalf("Failed to read the page count: %v\n", err)
+	}
+	if err := db.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		log.Fatalf("Failed to read the page size: %v\n", err)
+	}
+	stats["bytes"] = pages * pageSize
+	return stats
+}
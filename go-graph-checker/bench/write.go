@@ -0,0 +1,70 @@
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+)
+
+var csvHeader = []string{"backend", "history", "txns", "phase", "query", "runs",
+	"mean_ms", "stddev_ms", "min_ms", "p50_ms", "p90_ms", "p99_ms", "max_ms",
+	"alloc_bytes", "mallocs", "heap_inuse_bytes"}
+
+/*
+WriteCSV writes the records with a header row, the DB statistics of all records
+become "db_<name>" columns, empty where a record has none
+*/
+func WriteCSV(w io.Writer, records []Record) error {
+	var dbKeys []string
+	seen := make(map[string]bool)
+	for _, r := range records {
+		for k := range r.DB {
+			if !seen[k] {
+				seen[k] = true
+				dbKeys = append(dbKeys, k)
+			}
+		}
+	}
+	sort.Strings(dbKeys)
+
+	cw := csv.NewWriter(w)
+	header := append([]string{}, csvHeader...)
+	for _, k := range dbKeys {
+		header = append(header, "db_"+k)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	ms := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, r := range records {
+		row := []string{r.Backend, r.History, strconv.Itoa(r.Txns), r.Phase, r.Query, strconv.Itoa(r.Runs),
+			ms(r.Mean), ms(r.Stddev), ms(r.Min), ms(r.P50), ms(r.P90), ms(r.P99), ms(r.Max),
+			strconv.FormatUint(r.AllocBytes, 10), strconv.FormatUint(r.Mallocs, 10), strconv.FormatUint(r.HeapInuseBytes, 10)}
+		for _, k := range dbKeys {
+			if v, ok := r.DB[k]; ok {
+				row = append(row, strconv.FormatInt(v, 10))
+			} else {
+				row = append(row, "")
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+/*
+WriteJSON writes the records as an indented JSON array
+*/
+func WriteJSON(w io.Writer, records []Record) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if records == nil {
+		records = []Record{}
+	}
+	return enc.Encode(records)
+}
@@ -0,0 +1,154 @@
+/*
+grail-bench measures the phases of checking histories (parse, node insert, edge derivation,
+edge insert, query per level and mode) with warmup runs and repetitions, and writes runtime
+percentiles, memory and database statistics as CSV (and JSON) for the scalability figures.
+
+	go run ./go-graph-checker/cmd/grail-bench -dir go-graph-checker/histories/collection-time -csv list-append.csv
+	go run ./go-graph-checker/cmd/grail-bench -type rw-register -backend sqlite -dir go-graph-checker/histories/rw-register -reps 20
+
+rw-register histories are read with the WAL next to them (<name>.log), see package bench. With ArangoDB
+the histories are inserted into a fresh database of the run, unless the config sets Overwrite or a RunID
+*/
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/bench"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+	rwregister "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/rw_register"
+)
+
+func main() {
+	typ := flag.String("type", "list-append", "history type, list-append or rw-register")
+	backend := flag.String("backend", "arango", "checker backend, arango or sqlite")
+	dir := flag.String("dir", "", "directory of EDN histories")
+	historyFile := flag.String("history", "", "EDN history file, if no directory is given")
+	levels := flag.String("levels", "ser,si,psi,pl-2,pl-1", "comma-separated levels to query")
+	modes := flag.String("modes", "sv", "comma-separated search modes, pregel for CheckSERPregel")
+	config := flag.String("config", "", "config file, $GRAIL_CONFIG if empty")
+	warmup := flag.Int("warmup", 1, "warmup runs per phase")
+	reps := flag.Int("reps", 10, "measured runs per phase")
+	csvFile := flag.String("csv", "", "write the records as CSV to this file instead of stdout")
+	jsonFile := flag.String("json", "", "also write the records as JSON to this file")
+	flag.Parse()
+
+	var files []string
+	switch {
+	case *dir != "":
+		matches, err := filepath.Glob(filepath.Join(*dir, "*.edn"))
+		if err != nil {
+			log.Fatalf("Cannot list histories in %s: %v\n", *dir, err)
+		}
+		files = matches
+	case *historyFile != "":
+		files = []string{*historyFile}
+	default:
+		log.Fatalf("either -dir or -history is required\n")
+	}
+	// 10.edn before 100.edn
+	sort.SliceStable(files, func(i, j int) bool { return sizeOf(files[i]) < sizeOf(files[j]) })
+
+	opts := bench.Options{Warmup: *warmup, Repetitions: *reps}
+	levelList, modeList := strings.Split(*levels, ","), strings.Split(*modes, ",")
+	// all histories share the database of the run
+	var listAppendConsts listappend.DBConsts
+	var rwRegisterConsts rwregister.DBConsts
+	switch *typ {
+	case "list-append":
+		listAppendConsts = loadListAppend(*config)
+	case "rw-register":
+		rwRegisterConsts = loadRWRegister(*config)
+	default:
+		log.Fatalf("invalid type: %s, not from any of the following:\nlist-append, rw-register\n", *typ)
+	}
+
+	var records []bench.Record
+	for _, fileName := range files {
+		log.Printf("Benchmarking %s...\n", fileName)
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			log.Fatalf("Cannot read edn file %s\n", fileName)
+		}
+		name := filepath.Base(fileName)
+		switch *typ {
+		case "list-append":
+			if *backend == "arango" {
+				records = append(records, listappend.Bench(name, string(content), listAppendConsts, levelList, modeList, opts)...)
+			} else {
+				records = append(records, listappend.BenchSQLite(name, string(content), listAppendConsts, levelList, modeList, opts)...)
+			}
+		case "rw-register":
+			walFile := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".log"
+			walContent, err := os.ReadFile(walFile)
+			if err != nil {
+				log.Fatalf("Cannot read the WAL: %v\n", err)
+			}
+			wal, err := rwregister.ParseWAL(string(walContent))
+			if err != nil {
+				log.Fatalf("Cannot parse the WAL: %v\n", err)
+			}
+			if *backend == "arango" {
+				records = append(records, rwregister.Bench(name, string(content), wal, rwRegisterConsts, levelList, modeList, opts)...)
+			} else {
+				records = append(records, rwregister.BenchSQLite(name, string(content), wal, rwRegisterConsts, levelList, modeList, opts)...)
+			}
+		}
+	}
+
+	write(*csvFile, records, bench.WriteCSV)
+	if *jsonFile != "" {
+		write(*jsonFile, records, bench.WriteJSON)
+	}
+}
+
+// the config, with the database of the run unless it sets Overwrite or a RunID
+func loadListAppend(config string) listappend.DBConsts {
+	dbConsts, err := listappend.LoadDBConsts(config)
+	if err != nil {
+		log.Fatalf("Cannot load the config: %v\n", err)
+	}
+	if dbConsts.RunID == "" && !dbConsts.Overwrite {
+		dbConsts = dbConsts.PerRun()
+	}
+	return dbConsts
+}
+
+func loadRWRegister(config string) rwregister.DBConsts {
+	dbConsts, err := rwregister.LoadDBConsts(config)
+	if err != nil {
+		log.Fatalf("Cannot load the config: %v\n", err)
+	}
+	if dbConsts.RunID == "" && !dbConsts.Overwrite {
+		dbConsts = dbConsts.PerRun()
+	}
+	return dbConsts
+}
+
+// the numeric name of a history, e.g. 100 for 100.edn, or 0
+func sizeOf(fileName string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName)))
+	return n
+}
+
+func write(fileName string, records []bench.Record, f func(io.Writer, []bench.Record) error) {
+	var w io.Writer = os.Stdout
+	if fileName != "" {
+		file, err := os.Create(fileName)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v\n", fileName, err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := f(w, records); err != nil {
+		log.Fatalf("Failed to write the records: %v\n", err)
+	}
+}
@@ -0,0 +1,38 @@
+/*
+grail-cleanup drops stale checker databases, i.e. databases carrying the
+checker marker that are older than the retention period.
+
+	go run ./go-graph-checker/cmd/grail-cleanup -config grail.yaml -retention 24h -dry-run
+
+the connection is configured as for the checker, see listappend.LoadDBConsts
+*/
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+)
+
+func main() {
+	config := flag.String("config", "", "YAML or JSON config file, defaults to $GRAIL_CONFIG")
+	retention := flag.Duration("retention", 24*time.Hour, "keep checker databases younger than this")
+	dryRun := flag.Bool("dry-run", false, "only list the databases that would be dropped")
+	flag.Parse()
+
+	dbConsts, err := listappend.LoadDBConsts(*config)
+	if err != nil {
+		log.Fatalf("Cannot load the checker config: %v\n", err)
+	}
+	dropped := listappend.CleanupCheckerDBs(dbConsts, *retention, *dryRun)
+	for _, name := range dropped {
+		if *dryRun {
+			log.Printf("would drop %s\n", name)
+		} else {
+			log.Printf("dropped %s\n", name)
+		}
+	}
+	log.Printf("%d stale checker databases\n", len(dropped))
+}
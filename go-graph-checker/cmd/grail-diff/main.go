@@ -0,0 +1,150 @@
+/*
+grail-diff runs GRAIL (on ArangoDB, or offline with SQLite) and go-elle on the same histories and prints
+every level on which they disagree with both witnesses; it exits with 1 if there is any disagreement.
+the connection is configured as for the checker, see listappend.LoadDBConsts
+
+	go run ./go-graph-checker/cmd/grail-diff -dir go-graph-checker/histories/histories-30s
+	go run ./go-graph-checker/cmd/grail-diff -type rw-register -history 10.edn -wal 10.log -json diff.json
+	go run ./go-graph-checker/cmd/grail-diff -offline -mode all -dir go-graph-checker/histories/histories-30s
+
+see package differential
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+	rwregister "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/rw_register"
+)
+
+func main() {
+	typ := flag.String("type", "list-append", "history type, list-append or rw-register")
+	dir := flag.String("dir", "", "directory of EDN histories (and .log WALs for rw-register)")
+	historyFile := flag.String("history", "", "EDN history file, if no directory is given")
+	walFile := flag.String("wal", "", "WAL of an rw-register history")
+	mode := flag.String("mode", "sv", "search mode, see IsolationLevelChecker (sv or all with -offline)")
+	offline := flag.Bool("offline", false, "check with the SQLite backend instead of ArangoDB")
+	config := flag.String("config", "", "config file, $GRAIL_CONFIG if empty")
+	jsonFile := flag.String("json", "", "also write the diffs as JSON to this file")
+	flag.Parse()
+
+	var diffs []differential.Diff
+	switch {
+	case *dir != "" && *typ == "list-append":
+		dbConsts := loadListAppend(*config)
+		if *offline {
+			diffs = listappend.DiffDirectorySQLite(*dir, *mode, dbConsts)
+		} else {
+			diffs = listappend.DiffDirectory(*dir, *mode, dbConsts)
+		}
+	case *dir != "" && *typ == "rw-register":
+		dbConsts := loadRWRegister(*config)
+		if *offline {
+			diffs = rwregister.DiffDirectorySQLite(*dir, *mode, dbConsts)
+		} else {
+			diffs = rwregister.DiffDirectory(*dir, *mode, dbConsts)
+		}
+	case *historyFile != "":
+		diffs = []differential.Diff{diffFile(*typ, *historyFile, *walFile, *mode, *offline, *config)}
+	default:
+		log.Fatalf("either -dir or -history is required\n")
+	}
+
+	for _, d := range diffs {
+		for _, dis := range d.Disagreements {
+			fmt.Println(dis)
+		}
+		if len(d.ElleOnly) > 0 {
+			fmt.Printf("%s: go-elle only: %v\n", d.History, d.ElleOnly)
+		}
+	}
+	fmt.Println(differential.Summary(diffs))
+
+	if *jsonFile != "" {
+		data, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal the diffs: %v\n", err)
+		}
+		if err := os.WriteFile(*jsonFile, data, 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v\n", *jsonFile, err)
+		}
+	}
+	for _, d := range diffs {
+		if len(d.Disagreements) > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+func loadListAppend(config string) listappend.DBConsts {
+	dbConsts, err := listappend.LoadDBConsts(config)
+	if err != nil {
+		log.Fatalf("Cannot load the config: %v\n", err)
+	}
+	if dbConsts.RunID == "" {
+		dbConsts = dbConsts.PerRun()
+	}
+	return dbConsts
+}
+
+func loadRWRegister(config string) rwregister.DBConsts {
+	dbConsts, err := rwregister.LoadDBConsts(config)
+	if err != nil {
+		log.Fatalf("Cannot load the config: %v\n", err)
+	}
+	if dbConsts.RunID == "" {
+		dbConsts = dbConsts.PerRun()
+	}
+	return dbConsts
+}
+
+func diffFile(typ string, historyFile string, walFile string, mode string, offline bool, config string) differential.Diff {
+	content, err := os.ReadFile(historyFile)
+	if err != nil {
+		log.Fatalf("Cannot read the history: %v\n", err)
+	}
+	name := filepath.Base(historyFile)
+	switch typ {
+	case "list-append":
+		history, err := core.ParseHistory(string(content))
+		if err != nil {
+			log.Fatalf("Cannot parse the history: %v\n", err)
+		}
+		dbConsts := loadListAppend(config)
+		grail := listappend.DiffChecker(mode, dbConsts)
+		if offline {
+			grail = listappend.DiffCheckerSQLite(mode, dbConsts)
+		}
+		return differential.Compare(name, history, grail, differential.ElleListAppend)
+	case "rw-register":
+		history, err := core.ParseHistoryRW(string(content))
+		if err != nil {
+			log.Fatalf("Cannot parse the history: %v\n", err)
+		}
+		walContent, err := os.ReadFile(walFile)
+		if err != nil {
+			log.Fatalf("Cannot read the WAL: %v\n", err)
+		}
+		wal, err := rwregister.ParseWAL(string(walContent))
+		if err != nil {
+			log.Fatalf("Cannot parse the WAL: %v\n", err)
+		}
+		dbConsts := loadRWRegister(config)
+		grail := rwregister.DiffChecker(mode, wal, dbConsts)
+		if offline {
+			grail = rwregister.DiffCheckerSQLite(mode, wal, dbConsts)
+		}
+		return differential.Compare(name, history, grail, differential.ElleRWRegister)
+	default:
+		log.Fatalf("invalid type: %s, not from any of the following:\nlist-append, rw-register\n", typ)
+	}
+	return differential.Diff{}
+}
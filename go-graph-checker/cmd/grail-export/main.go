@@ -0,0 +1,69 @@
+/*
+grail-export derives the txn dependency graph of a history offline and writes it
+for Gephi, Graphviz, Neo4j or the neo4j-graph-checker.
+
+	go run ./go-graph-checker/cmd/grail-export -history 10.edn -out graphs -formats graphml,dot,csv,json,neo4j
+	go run ./go-graph-checker/cmd/grail-export -type rw-register -history 10.edn -wal 10.log -out graphs
+
+files are named after the history, see export.WriteFiles
+*/
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+	rwregister "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/rw_register"
+)
+
+func main() {
+	typ := flag.String("type", "list-append", "history type, list-append or rw-register")
+	historyFile := flag.String("history", "", "EDN history file")
+	walFile := flag.String("wal", "", "WAL of an rw-register history")
+	out := flag.String("out", ".", "output directory")
+	formats := flag.String("formats", strings.Join(export.Formats, ","), "comma separated formats")
+	flag.Parse()
+
+	content, err := os.ReadFile(*historyFile)
+	if err != nil {
+		log.Fatalf("Cannot read the history: %v\n", err)
+	}
+
+	var g export.Graph
+	switch *typ {
+	case "list-append":
+		history, err := core.ParseHistory(string(content))
+		if err != nil {
+			log.Fatalf("Cannot parse the history: %v\n", err)
+		}
+		g = listappend.BuildDepGraph(history, listappend.DefaultDBConsts()).Export()
+	case "rw-register":
+		history, err := core.ParseHistoryRW(string(content))
+		if err != nil {
+			log.Fatalf("Cannot parse the history: %v\n", err)
+		}
+		walContent, err := os.ReadFile(*walFile)
+		if err != nil {
+			log.Fatalf("Cannot read the WAL: %v\n", err)
+		}
+		wal, err := rwregister.ParseWAL(string(walContent))
+		if err != nil {
+			log.Fatalf("Cannot parse the WAL: %v\n", err)
+		}
+		g = rwregister.BuildDepGraph(history, wal, rwregister.DefaultDBConsts()).Export()
+	default:
+		log.Fatalf("invalid type: %s, not from any of the following:\nlist-append, rw-register\n", *typ)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(*historyFile), filepath.Ext(*historyFile))
+	if err := export.WriteFiles(*out, name, g, strings.Split(*formats, ",")...); err != nil {
+		log.Fatalf("Failed to export: %v\n", err)
+	}
+	log.Printf("exported %d txns and %d edges of %s\n", len(g.Nodes), len(g.Edges), *historyFile)
+}
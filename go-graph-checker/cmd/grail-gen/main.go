@@ -0,0 +1,111 @@
+/*
+grail-gen generates a synthetic history with injected anomalies and writes it as <out>/<name>.edn,
+its labels as <out>/<name>.labels.json and, for rw-register, a WAL of its versions as <out>/<name>.log.
+With -isolation, the history is recorded from the in-memory reference database instead (see package mvcc).
+
+	go run ./go-graph-checker/cmd/grail-gen -txns 1000 -inject G-single,write-skew -out gen -name 1
+	go run ./go-graph-checker/cmd/grail-gen -type rw-register -inject long-fork -seed 7 -out gen -name 2
+	go run ./go-graph-checker/cmd/grail-gen -isolation si -txns 1000 -out gen -name si
+
+see package generator
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/mvcc"
+	rwregister "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/rw_register"
+)
+
+func main() {
+	defaults := generator.DefaultOpts()
+	typ := flag.String("type", string(defaults.Workload), "history type, list-append or rw-register")
+	clients := flag.Int("clients", defaults.Clients, "concurrent clients")
+	txns := flag.Int("txns", defaults.Txns, "workload txns")
+	keys := flag.Int("keys", defaults.KeyCount, "workload keys")
+	minLength := flag.Int("min-length", defaults.MinTxnLength, "min micro-ops per txn")
+	maxLength := flag.Int("max-length", defaults.MaxTxnLength, "max micro-ops per txn")
+	readRatio := flag.Float64("read-ratio", defaults.ReadRatio, "probability of a micro-op to be a read")
+	failRate := flag.Float64("fail-rate", defaults.FailRate, "probability of a txn to abort")
+	infoRate := flag.Float64("info-rate", defaults.InfoRate, "probability of a txn to time out")
+	inject := flag.String("inject", "", "comma separated anomalies to inject: G0, G1a, G1b, G1c, G-single, write-skew, long-fork, lost-update")
+	isolation := flag.String("isolation", "", "record the history from the reference database under this isolation: serializable-2pl, ssi, si, psi, read-committed, read-uncommitted")
+	sites := flag.Int("sites", 2, "replicas of the reference database under psi")
+	seed := flag.Int64("seed", defaults.Seed, "random seed")
+	out := flag.String("out", ".", "output directory")
+	name := flag.String("name", "generated", "file name without extension")
+	flag.Parse()
+
+	opts := generator.Opts{
+		Workload:     generator.Workload(*typ),
+		Clients:      *clients,
+		Txns:         *txns,
+		KeyCount:     *keys,
+		MinTxnLength: *minLength,
+		MaxTxnLength: *maxLength,
+		ReadRatio:    *readRatio,
+		FailRate:     *failRate,
+		InfoRate:     *infoRate,
+		Seed:         *seed,
+	}
+	if *inject != "" {
+		for _, a := range strings.Split(*inject, ",") {
+			opts.Inject = append(opts.Inject, generator.Anomaly(strings.TrimSpace(a)))
+		}
+	}
+	var r generator.Result
+	var err error
+	if *isolation != "" {
+		if len(opts.Inject) > 0 {
+			log.Fatalf("-inject does not apply to the reference database\n")
+		}
+		runOpts := mvcc.DefaultOpts(mvcc.Isolation(*isolation))
+		runOpts.Workload, runOpts.Clients, runOpts.Txns, runOpts.KeyCount = opts.Workload, opts.Clients, opts.Txns, opts.KeyCount
+		runOpts.MinTxnLength, runOpts.MaxTxnLength, runOpts.ReadRatio = opts.MinTxnLength, opts.MaxTxnLength, opts.ReadRatio
+		runOpts.Sites, runOpts.Seed = *sites, opts.Seed
+		r, err = mvcc.Run(runOpts)
+	} else {
+		r, err = generator.Generate(opts)
+	}
+	if err != nil {
+		log.Fatalf("Cannot generate the history: %v\n", err)
+	}
+
+	var edn strings.Builder
+	for _, op := range r.History {
+		edn.WriteString(op.String())
+		edn.WriteString("\n")
+	}
+	write(filepath.Join(*out, *name+".edn"), []byte(edn.String()))
+
+	labels, err := json.MarshalIndent(r.Labels, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to marshal the labels: %v\n", err)
+	}
+	write(filepath.Join(*out, *name+".labels.json"), append(labels, '\n'))
+
+	if opts.Workload == generator.RWRegister {
+		f, err := os.Create(filepath.Join(*out, *name+".log"))
+		if err != nil {
+			log.Fatalf("Failed to create the WAL: %v\n", err)
+		}
+		if err := rwregister.WriteWAL(f, rwregister.WALFromVersions(r.Versions)); err != nil {
+			log.Fatalf("Failed to write the WAL: %v\n", err)
+		}
+		f.Close()
+	}
+	log.Printf("%d ops with %d injected anomalies written to %s\n", len(r.History), len(r.Labels), *out)
+}
+
+func write(path string, data []byte) {
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v\n", path, err)
+	}
+}
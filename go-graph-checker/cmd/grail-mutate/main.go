@@ -0,0 +1,152 @@
+/*
+grail-mutate measures how sensitive GRAIL (on ArangoDB, or offline with SQLite) and go-elle are: it mutates
+valid histories and prints, per mutation operator, how many mutants each checker flagged. The GRAIL checker
+is reported as "grail", or as "grail-sqlite" with -offline.
+
+	go run ./go-graph-checker/cmd/grail-mutate -history go-graph-checker/histories/collection-time/10.edn
+	go run ./go-graph-checker/cmd/grail-mutate -offline -dir go-graph-checker/histories/collection-time -mutants 5 -csv kills.csv
+	go run ./go-graph-checker/cmd/grail-mutate -type rw-register -history 10.edn -wal 10.log
+
+see package mutation
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/mutation"
+	rwregister "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/rw_register"
+)
+
+func main() {
+	typ := flag.String("type", "list-append", "history type, list-append or rw-register")
+	dir := flag.String("dir", "", "directory of EDN histories (and .log WALs for rw-register)")
+	historyFile := flag.String("history", "", "EDN history file, if no directory is given")
+	walFile := flag.String("wal", "", "WAL of an rw-register history")
+	mutants := flag.Int("mutants", 20, "mutants per operator and history")
+	seed := flag.Int64("seed", 1, "random seed")
+	mode := flag.String("mode", "sv", "search mode, see IsolationLevelChecker (sv or all with -offline)")
+	offline := flag.Bool("offline", false, "check with the SQLite backend instead of ArangoDB")
+	config := flag.String("config", "", "config file, $GRAIL_CONFIG if empty")
+	csvFile := flag.String("csv", "", "also write the scores as CSV to this file")
+	survivors := flag.Bool("survivors", false, "print the surviving mutants")
+	flag.Parse()
+
+	var files []string
+	name := *historyFile
+	switch {
+	case *dir != "":
+		name = *dir
+		matches, err := filepath.Glob(filepath.Join(*dir, "*.edn"))
+		if err != nil {
+			log.Fatalf("Cannot list histories in %s: %v\n", *dir, err)
+		}
+		files = matches
+	case *historyFile != "":
+		files = []string{*historyFile}
+	default:
+		log.Fatalf("either -dir or -history is required\n")
+	}
+
+	grail := "grail"
+	if *offline {
+		grail = "grail-sqlite"
+	}
+	// the mutants of all histories are checked one after the other in the database of this run
+	var laConsts listappend.DBConsts
+	var rwConsts rwregister.DBConsts
+	var err error
+	switch *typ {
+	case "list-append":
+		laConsts, err = listappend.LoadDBConsts(*config)
+		if err == nil && laConsts.RunID == "" {
+			laConsts = laConsts.PerRun()
+		}
+	case "rw-register":
+		rwConsts, err = rwregister.LoadDBConsts(*config)
+		if err == nil && rwConsts.RunID == "" {
+			rwConsts = rwConsts.PerRun()
+		}
+	default:
+		log.Fatalf("invalid type: %s, not from any of the following:\nlist-append, rw-register\n", *typ)
+	}
+	if err != nil {
+		log.Fatalf("Cannot load the config: %v\n", err)
+	}
+
+	reports := make([]mutation.Report, 0, len(files))
+	for _, fileName := range files {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			log.Fatalf("Cannot read edn file %s\n", fileName)
+		}
+		var history core.History
+		checkers := make(map[string]mutation.Checker)
+		if *typ == "list-append" {
+			history, err = core.ParseHistory(string(content))
+			checkers[grail] = listappend.MutationChecker(*mode, laConsts)
+			if *offline {
+				checkers[grail] = listappend.MutationCheckerSQLite(*mode, laConsts)
+			}
+			checkers["go-elle"] = mutation.ElleListAppend
+		} else {
+			history, err = core.ParseHistoryRW(string(content))
+			wal := readWAL(fileName, *walFile)
+			checkers[grail] = rwregister.MutationChecker(*mode, wal, rwConsts)
+			if *offline {
+				checkers[grail] = rwregister.MutationCheckerSQLite(*mode, wal, rwConsts)
+			}
+			checkers["go-elle"] = mutation.ElleRWRegister
+		}
+		if err != nil {
+			log.Fatalf("Cannot parse edn file %s\n", fileName)
+		}
+		report, err := mutation.Run(filepath.Base(fileName), history, checkers, mutation.Options{Mutants: *mutants, Seed: *seed})
+		if err != nil {
+			log.Fatalf("Cannot mutate %s: %v\n", fileName, err)
+		}
+		reports = append(reports, report)
+	}
+
+	report := mutation.Merge(name, reports)
+	fmt.Print(report)
+	if *survivors {
+		for _, s := range report.Survivors {
+			fmt.Printf("%s survived %s: %s\n", s.Checker, s.Operator, s.Mutation)
+		}
+	}
+
+	if *csvFile != "" {
+		f, err := os.Create(*csvFile)
+		if err != nil {
+			log.Fatalf("Failed to create %s: %v\n", *csvFile, err)
+		}
+		defer f.Close()
+		if err := mutation.WriteCSV(f, reports...); err != nil {
+			log.Fatalf("Failed to write %s: %v\n", *csvFile, err)
+		}
+	}
+}
+
+// the WAL of an rw-register history, walFile or the .log file of the same name
+func readWAL(fileName string, walFile string) rwregister.WAL {
+	if walFile == "" {
+		walFile = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".log"
+	}
+	content, err := os.ReadFile(walFile)
+	if err != nil {
+		log.Fatalf("Cannot read wal log %s\n", walFile)
+	}
+	wal, err := rwregister.ParseWAL(string(content))
+	if err != nil {
+		log.Fatalf("Cannot parse wal log %s\n", walFile)
+	}
+	return wal
+}
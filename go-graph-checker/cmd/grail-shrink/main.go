@@ -0,0 +1,80 @@
+/*
+grail-shrink minimizes a history violating an isolation level, offline, and writes the
+minimal history with its cycle as <out>/<name>.min.edn and <out>/<name>.min.cycle.edn.
+
+	go run ./go-graph-checker/cmd/grail-shrink -history 10.edn -level si -out shrunk
+	go run ./go-graph-checker/cmd/grail-shrink -type rw-register -history 10.edn -wal 10.log -level ser
+
+see shrink.Shrink
+*/
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+	rwregister "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/rw_register"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/shrink"
+)
+
+func main() {
+	typ := flag.String("type", "list-append", "history type, list-append or rw-register")
+	historyFile := flag.String("history", "", "EDN history file")
+	walFile := flag.String("wal", "", "WAL of an rw-register history")
+	level := flag.String("level", "ser", "violated level")
+	mode := flag.String("mode", "sv", "SQLite search mode, sv or all")
+	maxChecks := flag.Int("max-checks", 0, "bound on the checks, 0 for none")
+	out := flag.String("out", ".", "output directory")
+	verbose := flag.Bool("v", false, "log every reduction")
+	flag.Parse()
+
+	content, err := os.ReadFile(*historyFile)
+	if err != nil {
+		log.Fatalf("Cannot read the history: %v\n", err)
+	}
+
+	var history core.History
+	var oracle shrink.Oracle
+	opts := shrink.Options{MaxChecks: *maxChecks, Verbose: *verbose}
+	switch *typ {
+	case "list-append":
+		history, err = core.ParseHistory(string(content))
+		dbConsts := listappend.DefaultDBConsts()
+		oracle = listappend.ShrinkOracle(*level, *mode, dbConsts)
+		opts.TxnNode = dbConsts.TxnNode
+	case "rw-register":
+		history, err = core.ParseHistoryRW(string(content))
+		walContent, walErr := os.ReadFile(*walFile)
+		if walErr != nil {
+			log.Fatalf("Cannot read the WAL: %v\n", walErr)
+		}
+		wal, walErr := rwregister.ParseWAL(string(walContent))
+		if walErr != nil {
+			log.Fatalf("Cannot parse the WAL: %v\n", walErr)
+		}
+		dbConsts := rwregister.DefaultDBConsts()
+		oracle = rwregister.ShrinkOracle(*level, *mode, wal, dbConsts)
+		opts.TxnNode = dbConsts.TxnNode
+	default:
+		log.Fatalf("invalid type: %s, not from any of the following:\nlist-append, rw-register\n", *typ)
+	}
+	if err != nil {
+		log.Fatalf("Cannot parse the history: %v\n", err)
+	}
+
+	result, err := shrink.Shrink(history, oracle, opts)
+	if err != nil {
+		log.Fatalf("Cannot shrink %s: %v\n", *historyFile, err)
+	}
+	name := strings.TrimSuffix(filepath.Base(*historyFile), filepath.Ext(*historyFile)) + ".min"
+	if err := shrink.WriteFiles(*out, name, result); err != nil {
+		log.Fatalf("Failed to write the minimal history: %v\n", err)
+	}
+	log.Printf("%d ops shrunk to %d reproducing %s of %s in %d checks\n",
+		len(history), len(result.History), result.Violation.Class, *level, result.Checks)
+}
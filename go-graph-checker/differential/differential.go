@@ -0,0 +1,238 @@
+/*
+Package differential runs GRAIL and go-elle on the same histories and reports every level on
+which they disagree, with the witness of each side, so that a soundness bug in either checker
+shows up as a disagreement. go-elle anomaly names are mapped onto the GRAIL levels they violate:
+
+	G0 -> pl-1, G1c -> pl-2, G-single -> psi, G-nonadjacent -> si, G2-item -> ser
+
+as are the pair anomalies it reports besides, lost-update -> psi and write-skew -> ser,
+and a level is violated by go-elle if an anomaly of the level or of a weaker level was found.
+G1a and G1b are compared with the G1 anomalies GRAIL finds while building the graph.
+
+	diff := differential.Compare("10.edn", history, listappend.DiffChecker("all", dbConsts), differential.ElleListAppend)
+*/
+package differential
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	ellelistappend "github.com/grail/anti-pattern-graph-checker-single/go-elle/list_append"
+	ellerwregister "github.com/grail/anti-pattern-graph-checker-single/go-elle/rw_register"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+)
+
+/*
+Levels are the GRAIL levels compared, from the weakest to the strongest
+*/
+var Levels = []string{"pl-1", "pl-2", "psi", "si", "ser"}
+
+/*
+G1 are the non-cycle anomalies compared
+*/
+var G1 = []string{"G1a", "G1b"}
+
+/*
+ElleLevels maps the go-elle cycle anomalies onto the weakest GRAIL level they violate
+*/
+var ElleLevels = map[string]string{
+	"G0":            "pl-1",
+	"G1c":           "pl-2",
+	"G-single":      "psi",
+	"G-nonadjacent": "si",
+	"G2-item":       "ser",
+	"G2-predicate":  "ser",
+	"lost-update":   "psi",
+	"write-skew":    "ser",
+}
+
+// go-elle only reports the anomalies its consistency models prohibit, G-nonadjacent is asked for
+var elleOpts = txn.Opts{
+	ConsistencyModels: []core.ConsistencyModelName{"serializable"},
+	Anomalies:         []string{"G-nonadjacent"},
+}
+
+/*
+Verdict is what a checker says about a level (or a G1 anomaly): whether it holds and,
+if it does not, a witness, e.g. "T1 (wr) T2 (rw) T1"
+*/
+type Verdict struct {
+	Valid   bool   `json:"valid"`
+	Witness string `json:"witness,omitempty"`
+}
+
+/*
+Verdicts are keyed by level or G1 anomaly name
+*/
+type Verdicts map[string]Verdict
+
+/*
+Checker checks a history against all Levels and G1
+*/
+type Checker func(history core.History) Verdicts
+
+/*
+Disagreement is a level on which the checkers differ, with both witnesses
+*/
+type Disagreement struct {
+	History string  `json:"history"`
+	Level   string  `json:"level"`
+	Grail   Verdict `json:"grail"`
+	Elle    Verdict `json:"elle"`
+}
+
+func (d Disagreement) String() string {
+	verdict := func(v Verdict) string {
+		if v.Valid {
+			return "valid"
+		}
+		return "violated by " + v.Witness
+	}
+	return fmt.Sprintf("%s %s: GRAIL %s, go-elle %s", d.History, d.Level, verdict(d.Grail), verdict(d.Elle))
+}
+
+/*
+Diff is the comparison of one history, ElleOnly lists the go-elle anomalies
+GRAIL has no counterpart for (e.g. internal, incompatible-order)
+*/
+type Diff struct {
+	History       string         `json:"history"`
+	Disagreements []Disagreement `json:"disagreements"`
+	ElleOnly      []string       `json:"elle_only,omitempty"`
+}
+
+/*
+Compare runs both checkers on a history
+*/
+func Compare(name string, history core.History, grail Checker, elle Checker) Diff {
+	g, e := grail(history), elle(history)
+	diff := Diff{History: name}
+	for _, level := range append(append([]string{}, Levels...), G1...) {
+		gv, ev := g[level], e[level]
+		if gv.Valid != ev.Valid {
+			diff.Disagreements = append(diff.Disagreements, Disagreement{History: name, Level: level, Grail: gv, Elle: ev})
+		}
+	}
+	for name, v := range e {
+		if !v.Valid && !isCompared(name) {
+			diff.ElleOnly = append(diff.ElleOnly, name)
+		}
+	}
+	sort.Strings(diff.ElleOnly)
+	return diff
+}
+
+func isCompared(name string) bool {
+	for _, n := range append(append([]string{}, Levels...), G1...) {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func levelRank(level string) int {
+	for i, l := range Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
+
+/*
+ElleVerdicts maps a go-elle result onto Verdicts, the anomalies that are neither
+cycles of ElleLevels nor G1 are kept under their own names
+*/
+func ElleVerdicts(result txn.CheckResult) Verdicts {
+	verdicts := make(Verdicts)
+	for _, level := range append(append([]string{}, Levels...), G1...) {
+		verdicts[level] = Verdict{Valid: true}
+	}
+
+	names := make([]string, 0, len(result.Anomalies))
+	for name := range result.Anomalies {
+		names = append(names, name)
+	}
+	// weakest first, so that every level is witnessed by the weakest anomaly violating it
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := levelRank(ElleLevels[names[i]]), levelRank(ElleLevels[names[j]])
+		if ri != rj {
+			return ri < rj
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		anomalies := result.Anomalies[name]
+		if len(anomalies) == 0 {
+			continue
+		}
+		witness := name + " " + elleWitness(anomalies[0])
+		level, ok := ElleLevels[name]
+		if !ok {
+			verdicts[name] = Verdict{Valid: false, Witness: witness}
+			continue
+		}
+		for _, l := range Levels[levelRank(level):] {
+			if verdicts[l].Valid {
+				verdicts[l] = Verdict{Valid: false, Witness: witness}
+			}
+		}
+	}
+	return verdicts
+}
+
+// "T1 (wr) T2 (rw) T1" for cycles, as GRAIL prints them
+func elleWitness(anomaly core.Anomaly) string {
+	cr, ok := anomaly.(core.CycleExplainerResult)
+	if !ok || len(cr.Circle.Path) == 0 {
+		return fmt.Sprintf("%v", anomaly)
+	}
+	var b strings.Builder
+	b.WriteString(opName(cr.Circle.Path[0]))
+	for i := 1; i < len(cr.Circle.Path); i++ {
+		typ := "?"
+		if i-1 < len(cr.Steps) && cr.Steps[i-1].Result != nil {
+			typ = string(cr.Steps[i-1].Result.Type())
+		}
+		b.WriteString(fmt.Sprintf(" (%s) %s", typ, opName(cr.Circle.Path[i])))
+	}
+	return b.String()
+}
+
+func opName(op core.Op) string {
+	if op.Index.Present() {
+		return fmt.Sprintf("T%d", op.Index.MustGet())
+	}
+	return "T?"
+}
+
+/*
+ElleListAppend checks a list-append history with go-elle
+*/
+func ElleListAppend(history core.History) Verdicts {
+	return ElleVerdicts(ellelistappend.Check(elleOpts, history))
+}
+
+/*
+ElleRWRegister checks an rw-register history, as core.ParseHistoryRW reads it, with go-elle
+*/
+func ElleRWRegister(history core.History) Verdicts {
+	return ElleVerdicts(ellerwregister.Check(elleOpts, ellerwregister.ConvertHistory(history), ellerwregister.GraphOption{}))
+}
+
+/*
+Summary counts the histories compared and those with disagreements
+*/
+func Summary(diffs []Diff) string {
+	disagreeing := 0
+	for _, d := range diffs {
+		if len(d.Disagreements) > 0 {
+			disagreeing++
+		}
+	}
+	return fmt.Sprintf("%d histories compared, %d with disagreements", len(diffs), disagreeing)
+}
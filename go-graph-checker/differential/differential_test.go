@@ -0,0 +1,63 @@
+package differential_test
+
+import (
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/stretchr/testify/require"
+)
+
+func parseHistory(t *testing.T, ops ...string) core.History {
+	var history core.History
+	for i, s := range ops {
+		op, err := core.ParseOp(s)
+		require.NoError(t, err)
+		op.Index = core.NewOptInt(i)
+		history = append(history, op)
+	}
+	return history
+}
+
+func TestElleListAppend(t *testing.T) {
+	// G1c: T0 and T1 read each other's appends
+	history := parseHistory(t,
+		`{:type :ok, :value [[:append x 1] [:r y [1]]]}`,
+		`{:type :ok, :value [[:append y 1] [:r x [1]]]}`,
+	)
+	verdicts := differential.ElleListAppend(history)
+	require.True(t, verdicts["pl-1"].Valid)
+	for _, level := range []string{"pl-2", "psi", "si", "ser"} {
+		require.False(t, verdicts[level].Valid, level)
+		require.Contains(t, verdicts[level].Witness, "G1c")
+	}
+	require.True(t, verdicts["G1a"].Valid)
+	require.True(t, verdicts["G1b"].Valid)
+}
+
+func TestCompare(t *testing.T) {
+	valid := func(core.History) differential.Verdicts {
+		return differential.Verdicts{"pl-1": {Valid: true}, "pl-2": {Valid: true}, "psi": {Valid: true},
+			"si": {Valid: true}, "ser": {Valid: true}, "G1a": {Valid: true}, "G1b": {Valid: true}}
+	}
+	writeSkew := func(h core.History) differential.Verdicts {
+		v := valid(h)
+		v["ser"] = differential.Verdict{Witness: "T0 (rw) T1 (rw) T0"}
+		v["internal"] = differential.Verdict{Witness: "internal {...}"}
+		return v
+	}
+
+	diff := differential.Compare("h.edn", nil, valid, valid)
+	require.Empty(t, diff.Disagreements)
+
+	diff = differential.Compare("h.edn", nil, valid, writeSkew)
+	require.Equal(t, []differential.Disagreement{{
+		History: "h.edn",
+		Level:   "ser",
+		Grail:   differential.Verdict{Valid: true},
+		Elle:    differential.Verdict{Witness: "T0 (rw) T1 (rw) T0"},
+	}}, diff.Disagreements)
+	require.Equal(t, []string{"internal"}, diff.ElleOnly)
+	require.Equal(t, "h.edn ser: GRAIL valid, go-elle violated by T0 (rw) T1 (rw) T0", diff.Disagreements[0].String())
+	require.Equal(t, "1 histories compared, 1 with disagreements", differential.Summary([]differential.Diff{diff}))
+}
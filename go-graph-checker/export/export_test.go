@@ -0,0 +1,155 @@
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	ellelistappend "github.com/grail/anti-pattern-graph-checker-single/go-elle/list_append"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+	"github.com/stretchr/testify/require"
+)
+
+func gSingleHistory(t *testing.T) core.History {
+	var history core.History
+	for i, s := range []string{
+		`{:type :ok, :value [[:append 1 1] [:append 2 1]]}`,
+		`{:type :ok, :value [[:append 1 2] [:append 2 2]]}`,
+		`{:type :ok, :value [[:r 1 [1 2]] [:r 2 [1]]]}`,
+	} {
+		op, err := core.ParseOp(s)
+		require.NoError(t, err)
+		op.Index = core.NewOptInt(i)
+		history = append(history, op)
+	}
+	return history
+}
+
+var testGraph = export.Graph{
+	Nodes: []export.Node{{ID: "txn/0", Key: "0", Label: "txn"}, {ID: "txn/1", Key: "1", Label: "txn"}},
+	Edges: []export.Edge{{From: "txn/0", To: "txn/1", FromEvt: "a_evt/0,0", ToEvt: "r_evt/1,1", Obj: `x "1"`, Type: "wr"}},
+}
+
+func TestWriteGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteGraphML(&buf, testGraph))
+
+	var doc struct {
+		Graph struct {
+			Nodes []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"node"`
+			Edges []struct {
+				Source string `xml:"source,attr"`
+				Target string `xml:"target,attr"`
+				Data   []struct {
+					Key   string `xml:"key,attr"`
+					Value string `xml:",chardata"`
+				} `xml:"data"`
+			} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	require.Len(t, doc.Graph.Nodes, 2)
+	require.Len(t, doc.Graph.Edges, 1)
+	data := make(map[string]string)
+	for _, d := range doc.Graph.Edges[0].Data {
+		data[d.Key] = d.Value
+	}
+	require.Equal(t, map[string]string{"type": "wr", "obj": `x "1"`, "from_evt": "a_evt/0,0", "to_evt": "r_evt/1,1"}, data)
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteDOT(&buf, testGraph))
+	require.Equal(t, `digraph txn_g {
+  "txn/0" [label="0"];
+  "txn/1" [label="1"];
+  "txn/0" -> "txn/1" [label="wr x \"1\"", type="wr", obj="x \"1\"", from_evt="a_evt/0,0", to_evt="r_evt/1,1"];
+}
+`, buf.String())
+}
+
+func TestWriteCSV(t *testing.T) {
+	var nodes, edges bytes.Buffer
+	require.NoError(t, export.WriteCSV(&nodes, &edges, testGraph))
+
+	rows, err := csv.NewReader(&nodes).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"_id:ID", "_key", ":LABEL"}, {"txn/0", "0", "txn"}, {"txn/1", "1", "txn"}}, rows)
+
+	rows, err = csv.NewReader(&edges).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{":START_ID", ":END_ID", ":TYPE", "obj", "from_evt", "to_evt"},
+		{"txn/0", "txn/1", "wr", `x "1"`, "a_evt/0,0", "r_evt/1,1"},
+	}, rows)
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteJSON(&buf, testGraph))
+	var g export.Graph
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &g))
+	require.Equal(t, testGraph.Edges, g.Edges)
+
+	// the form of the neo4j-graph-checker resources
+	var vertices, edges bytes.Buffer
+	require.NoError(t, export.WriteNeo4jJSON(&vertices, &edges, testGraph))
+	var vs []map[string]string
+	require.NoError(t, json.Unmarshal(vertices.Bytes(), &vs))
+	require.Equal(t, []map[string]string{{"_id": "txn/0", "_key": "0"}, {"_id": "txn/1", "_key": "1"}}, vs)
+	var es []map[string]string
+	require.NoError(t, json.Unmarshal(edges.Bytes(), &es))
+	require.Equal(t, "r_evt/1,1", es[0]["to_evt"])
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, export.WriteFiles(dir, "10", testGraph, export.Formats...))
+	for _, name := range []string{"10.graphml", "10.dot", "10_nodes.csv", "10_edges.csv", "10.json", "txn10.json", "dep10.json"} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err, name)
+	}
+	require.Error(t, export.WriteFiles(dir, "10", testGraph, "gexf"))
+}
+
+func TestFromElle(t *testing.T) {
+	g, explainer := ellelistappend.Graph(gSingleHistory(t))
+	eg := export.FromElle(*g, explainer, export.ListAppendCollections)
+	require.Len(t, eg.Nodes, 3)
+	require.Contains(t, eg.Edges, export.Edge{From: "txn/0", To: "txn/1", FromEvt: "a_evt/0,0", ToEvt: "a_evt/1,0", Obj: "1", Type: "ww"})
+	require.Contains(t, eg.Edges, export.Edge{From: "txn/1", To: "txn/2", FromEvt: "a_evt/1,0", ToEvt: "r_evt/2,0", Obj: "1", Type: "wr"})
+	for _, e := range eg.Edges {
+		require.NotEmpty(t, e.Obj, e)
+		require.True(t, strings.Contains(e.FromEvt, ","), e)
+	}
+}
+
+func TestDepGraphExport(t *testing.T) {
+	eg := listappend.BuildDepGraph(gSingleHistory(t), listappend.DefaultDBConsts()).Export()
+	require.Len(t, eg.Nodes, 3)
+	require.Contains(t, eg.Edges, export.Edge{From: "txn/2", To: "txn/1", FromEvt: "r_evt/2,1", ToEvt: "a_evt/1,1", Obj: "2", Type: "rw"})
+
+	// go-elle only orders the versions it read, GRAIL also follows the appends of a txn
+	type edge struct{ from, to, obj, typ string }
+	edges := func(g export.Graph) map[edge]bool {
+		m := make(map[edge]bool)
+		for _, e := range g.Edges {
+			m[edge{e.From, e.To, e.Obj, e.Type}] = true
+		}
+		return m
+	}
+	g, explainer := ellelistappend.Graph(gSingleHistory(t))
+	grail := edges(eg)
+	for e := range edges(export.FromElle(*g, explainer, export.ListAppendCollections)) {
+		require.True(t, grail[e], e)
+	}
+}
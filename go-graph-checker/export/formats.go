@@ -0,0 +1,174 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+/*
+Formats are the names accepted by WriteFiles
+*/
+var Formats = []string{"graphml", "dot", "csv", "json", "neo4j"}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+/*
+WriteGraphML writes the graph as GraphML, edge attributes are type, obj, from_evt and to_evt
+*/
+func WriteGraphML(w io.Writer, g Graph) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="key" for="node" attr.name="key" attr.type="string"/>
+  <key id="type" for="edge" attr.name="type" attr.type="string"/>
+  <key id="obj" for="edge" attr.name="obj" attr.type="string"/>
+  <key id="from_evt" for="edge" attr.name="from_evt" attr.type="string"/>
+  <key id="to_evt" for="edge" attr.name="to_evt" attr.type="string"/>
+  <graph id="txn_g" edgedefault="directed">
+`)
+	for _, n := range g.Nodes {
+		fmt.Fprintf(bw, "    <node id=\"%s\">\n      <data key=\"key\">%s</data>\n    </node>\n",
+			xmlEscape(n.ID), xmlEscape(n.Key))
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(bw, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(e.From), xmlEscape(e.To))
+		for _, d := range [][2]string{{"type", e.Type}, {"obj", e.Obj}, {"from_evt", e.FromEvt}, {"to_evt", e.ToEvt}} {
+			fmt.Fprintf(bw, "      <data key=\"%s\">%s</data>\n", d[0], xmlEscape(d[1]))
+		}
+		bw.WriteString("    </edge>\n")
+	}
+	bw.WriteString("  </graph>\n</graphml>\n")
+	return bw.Flush()
+}
+
+/*
+WriteDOT writes the whole graph in the Graphviz DOT language, edges are labelled "<type> <obj>"
+*/
+func WriteDOT(w io.Writer, g Graph) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph txn_g {\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(bw, "  %s [label=%s];\n", strconv.Quote(n.ID), strconv.Quote(n.Key))
+	}
+	for _, e := range g.Edges {
+		label := strings.TrimSpace(e.Type + " " + e.Obj)
+		fmt.Fprintf(bw, "  %s -> %s [label=%s, type=%s, obj=%s, from_evt=%s, to_evt=%s];\n",
+			strconv.Quote(e.From), strconv.Quote(e.To), strconv.Quote(label), strconv.Quote(e.Type),
+			strconv.Quote(e.Obj), strconv.Quote(e.FromEvt), strconv.Quote(e.ToEvt))
+	}
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+/*
+WriteCSV writes the nodes and the edges as two CSV files with neo4j-admin import headers,
+which LOAD CSV WITH HEADERS reads as well:
+
+	_id:ID,_key,:LABEL
+	:START_ID,:END_ID,:TYPE,obj,from_evt,to_evt
+*/
+func WriteCSV(nodes io.Writer, edges io.Writer, g Graph) error {
+	nw := csv.NewWriter(nodes)
+	nw.Write([]string{"_id:ID", "_key", ":LABEL"})
+	for _, n := range g.Nodes {
+		nw.Write([]string{n.ID, n.Key, n.Label})
+	}
+	nw.Flush()
+	if err := nw.Error(); err != nil {
+		return err
+	}
+
+	ew := csv.NewWriter(edges)
+	ew.Write([]string{":START_ID", ":END_ID", ":TYPE", "obj", "from_evt", "to_evt"})
+	for _, e := range g.Edges {
+		ew.Write([]string{e.From, e.To, e.Type, e.Obj, e.FromEvt, e.ToEvt})
+	}
+	ew.Flush()
+	return ew.Error()
+}
+
+/*
+WriteJSON writes the graph as a single JSON object {"vertices": [...], "edges": [...]}
+*/
+func WriteJSON(w io.Writer, g Graph) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g)
+}
+
+/*
+WriteNeo4jJSON writes the vertices and the edges as two JSON arrays, in the form of the
+ArangoDB collection dumps the neo4j-graph-checker imports (resources <type>/txn<N>.json and dep<N>.json)
+*/
+func WriteNeo4jJSON(vertices io.Writer, edges io.Writer, g Graph) error {
+	enc := json.NewEncoder(vertices)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(g.Nodes); err != nil {
+		return err
+	}
+	enc = json.NewEncoder(edges)
+	enc.SetIndent("", "  ")
+	return enc.Encode(g.Edges)
+}
+
+/*
+WriteFiles writes the graph to dir in each of the formats, named after name:
+
+	graphml  <name>.graphml
+	dot      <name>.dot
+	csv      <name>_nodes.csv, <name>_edges.csv
+	json     <name>.json
+	neo4j    txn<name>.json, dep<name>.json
+*/
+func WriteFiles(dir string, name string, g Graph, formats ...string) error {
+	write := func(fileName string, f func(w io.Writer) error) error {
+		file, err := os.Create(filepath.Join(dir, fileName))
+		if err != nil {
+			return err
+		}
+		if err := f(file); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+	write2 := func(fileName1 string, fileName2 string, f func(w1 io.Writer, w2 io.Writer) error) error {
+		return write(fileName1, func(w1 io.Writer) error {
+			return write(fileName2, func(w2 io.Writer) error { return f(w1, w2) })
+		})
+	}
+
+	for _, format := range formats {
+		var err error
+		switch format {
+		case "graphml":
+			err = write(name+".graphml", func(w io.Writer) error { return WriteGraphML(w, g) })
+		case "dot":
+			err = write(name+".dot", func(w io.Writer) error { return WriteDOT(w, g) })
+		case "csv":
+			err = write2(name+"_nodes.csv", name+"_edges.csv", func(n io.Writer, e io.Writer) error { return WriteCSV(n, e, g) })
+		case "json":
+			err = write(name+".json", func(w io.Writer) error { return WriteJSON(w, g) })
+		case "neo4j":
+			err = write2("txn"+name+".json", "dep"+name+".json", func(v io.Writer, e io.Writer) error { return WriteNeo4jJSON(v, e, g) })
+		default:
+			err = fmt.Errorf("invalid format: %s, not from any of the following:\n%s", format, strings.Join(Formats, ", "))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
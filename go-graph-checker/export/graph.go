@@ -0,0 +1,146 @@
+/*
+Package export writes txn dependency graphs to files for external tools:
+GraphML (Gephi), DOT (Graphviz), node/edge CSV (Neo4j LOAD CSV and neo4j-admin import)
+and JSON, including the txn<N>.json/dep<N>.json pair read by the neo4j-graph-checker.
+
+Graphs come either from the checker (DepGraph.Export of listappend and rwregister)
+or from go-elle (FromElle); both use the ArangoDB document ids of GRAIL.
+*/
+package export
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+/*
+Node is a txn, ID is the document id "<collection>/<key>"
+*/
+type Node struct {
+	ID    string `json:"_id"`
+	Key   string `json:"_key"`
+	Label string `json:"-"`
+}
+
+/*
+Edge is a dependency between two txns, Obj is the key the dependency is on
+and FromEvt/ToEvt the ids of the events inducing it; all three are empty
+for edges without an explanation (e.g. process or realtime order)
+*/
+type Edge struct {
+	From    string `json:"_from"`
+	To      string `json:"_to"`
+	FromEvt string `json:"from_evt"`
+	ToEvt   string `json:"to_evt"`
+	Obj     string `json:"obj"`
+	Type    string `json:"type"`
+}
+
+/*
+Graph is the exported txn graph
+*/
+type Graph struct {
+	Nodes []Node `json:"vertices"`
+	Edges []Edge `json:"edges"`
+}
+
+/*
+ElleCollections names the collections of the ids FromElle generates
+*/
+type ElleCollections struct {
+	Txn      string
+	ReadEvt  string
+	WriteEvt string
+}
+
+/*
+ListAppendCollections and RWRegisterCollections are the default collections of the checkers
+*/
+var (
+	ListAppendCollections = ElleCollections{Txn: "txn", ReadEvt: "r_evt", WriteEvt: "a_evt"}
+	RWRegisterCollections = ElleCollections{Txn: "txn", ReadEvt: "r_evt", WriteEvt: "w_evt"}
+)
+
+/*
+FromElle converts a go-elle graph of ops to an exported graph. Txn keys are the op indexes,
+as in GRAIL; an edge is explained by the explainer when its result implements
+core.KeyedExplainResult for the type of the edge.
+*/
+func FromElle(graph core.DirectedGraph, explainer core.DataExplainer, collections ElleCollections) Graph {
+	var g Graph
+	vertices := graph.Vertices()
+	ops := make(map[core.Vertex]core.Op, len(vertices))
+	for _, v := range vertices {
+		op := v.Value.(core.Op)
+		ops[v] = op
+		key := fmt.Sprint(op.Index.MustGet())
+		g.Nodes = append(g.Nodes, Node{ID: collections.Txn + "/" + key, Key: key, Label: collections.Txn})
+	}
+
+	for a, outs := range graph.Outs {
+		for b, rels := range outs {
+			opA, opB := ops[a], ops[b]
+			var ex core.ExplainResult
+			if explainer != nil {
+				ex = explainer.ExplainPairData(opA, opB)
+			}
+			for _, rel := range rels {
+				e := Edge{
+					From: fmt.Sprintf("%s/%d", collections.Txn, opA.Index.MustGet()),
+					To:   fmt.Sprintf("%s/%d", collections.Txn, opB.Index.MustGet()),
+					Type: string(rel),
+				}
+				if keyed, ok := ex.(core.KeyedExplainResult); ok && string(keyed.Type()) == string(rel) {
+					i, j := keyed.MopIndexes()
+					e.Obj = keyed.GetKey()
+					e.FromEvt = elleEvtId(collections, opA, i)
+					e.ToEvt = elleEvtId(collections, opB, j)
+				}
+				g.Edges = append(g.Edges, e)
+			}
+		}
+	}
+	g.Sort()
+	return g
+}
+
+// the id of the event of mop i in op, empty if unknown
+func elleEvtId(collections ElleCollections, op core.Op, i int) string {
+	if i < 0 || op.Value == nil || i >= len(*op.Value) {
+		return ""
+	}
+	collection := collections.WriteEvt
+	if (*op.Value)[i].IsRead() {
+		collection = collections.ReadEvt
+	}
+	return fmt.Sprintf("%s/%d,%d", collection, op.Index.MustGet(), i)
+}
+
+/*
+Sort orders nodes and edges by id, so that exports of the same graph are identical
+*/
+func (g Graph) Sort() {
+	sort.Slice(g.Nodes, func(i, j int) bool { return lessId(g.Nodes[i].ID, g.Nodes[j].ID) })
+	sort.Slice(g.Edges, func(i, j int) bool {
+		a, b := g.Edges[i], g.Edges[j]
+		if a.From != b.From {
+			return lessId(a.From, b.From)
+		}
+		if a.To != b.To {
+			return lessId(a.To, b.To)
+		}
+		return a.Type < b.Type
+	})
+}
+
+// numeric keys in numeric order, "txn/9" before "txn/10"
+func lessId(a, b string) bool {
+	ka, kb := a[strings.IndexByte(a, '/')+1:], b[strings.IndexByte(b, '/')+1:]
+	if len(ka) != len(kb) && a[:len(a)-len(ka)] == b[:len(b)-len(kb)] {
+		return len(ka) < len(kb)
+	}
+	return a < b
+}
@@ -0,0 +1,91 @@
+package listappend
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/antipattern"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files under testdata")
+
+// renders the query followed by its bind parameters, keys sorted by encoding/json
+func renderAQL(q AQL) string {
+	bindVars, err := json.MarshalIndent(q.BindVars, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return fmt.Sprintf("%s// bind vars\n%s\n", q.Query, bindVars)
+}
+
+func checkGolden(t *testing.T, name string, got string) {
+	path := filepath.Join("testdata", "aql", name+".aql")
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0644))
+	}
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "run go test -update to create the golden file")
+	require.Equal(t, string(want), got)
+}
+
+func TestAQLGolden(t *testing.T) {
+	dbConsts := DefaultDBConsts()
+	for _, spec := range []LevelSpec{LevelSER, LevelSI, LevelPSI, LevelPL2, LevelPL1} {
+		level := strings.ToLower(strings.ReplaceAll(spec.name(), "-", ""))
+		for _, mode := range []string{"sv", "sv-filter", "sv-random"} {
+			checkGolden(t, level+"_"+mode, renderAQL(svQuery(spec, mode, dbConsts)))
+		}
+		checkGolden(t, level+"_sp", renderAQL(spQuery(spec, dbConsts)))
+		if !spec.Pattern.Any() {
+			checkGolden(t, level+"_sp-allcycles", renderAQL(spAllCyclesQuery(spec, dbConsts)))
+		}
+	}
+	checkGolden(t, "ser_pregel", renderAQL(pregelCycleQuery(dbConsts)))
+	checkGolden(t, "pair_edges", renderAQL(pairEdgesQuery(dbConsts)))
+	checkGolden(t, "long_fork_edges", renderAQL(longForkEdgesQuery(dbConsts)))
+}
+
+func TestAQLBindsNames(t *testing.T) {
+	dbConsts := DefaultDBConsts().Namespaced(`x" RETURN 1 //`)
+	for _, q := range []AQL{
+		svQuery(LevelSI, "sv", dbConsts),
+		svQuery(LevelSI, "sv-random", dbConsts),
+		spQuery(LevelPL1, dbConsts),
+		spAllCyclesQuery(LevelPSI, dbConsts),
+		pregelCycleQuery(dbConsts),
+		pairEdgesQuery(dbConsts),
+		longForkEdgesQuery(dbConsts),
+	} {
+		require.NotContains(t, q.Query, "RETURN 1")
+		for _, v := range q.BindVars {
+			if s, ok := v.(string); ok {
+				require.NotContains(t, q.Query, s)
+			}
+		}
+	}
+}
+
+func TestLookupLevel(t *testing.T) {
+	spec, ok := LookupLevel("snapshot isolation")
+	require.True(t, ok)
+	require.Equal(t, "SI", spec.name())
+	_, ok = LookupLevel("read committed")
+	require.False(t, ok)
+}
+
+func TestRegisterCustomLevel(t *testing.T) {
+	gSingle := LevelSpec{Pattern: antipattern.MustParse("no-G-single", "count rw == 1")}
+	RegisterLevel(gSingle, "no-g-single")
+	spec, ok := LookupLevel("no-g-single")
+	require.True(t, ok)
+	require.True(t, spec.isAntiPattern([]TxnDepEdge{{Type: "ww"}, {Type: "rw"}}))
+	require.False(t, spec.isAntiPattern([]TxnDepEdge{{Type: "rw"}, {Type: "rw"}}))
+	checkGolden(t, "no-g-single_sp", renderAQL(spQuery(spec, DefaultDBConsts())))
+}
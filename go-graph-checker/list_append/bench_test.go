@@ -0,0 +1,34 @@
+package listappend
+
+import (
+	"os"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/bench"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBenchSQLite(t *testing.T) {
+	content, err := os.ReadFile("../histories/collection-time/10.edn")
+	require.NoError(t, err)
+	records := BenchSQLite("10.edn", string(content), DefaultDBConsts(), []string{"ser", "si"}, []string{"sv"},
+		bench.Options{Warmup: 1, Repetitions: 3})
+
+	var phases []string
+	for _, r := range records {
+		phases = append(phases, r.Phase+" "+r.Query)
+		require.Equal(t, 3, r.Runs)
+		require.Equal(t, "sqlite", r.Backend)
+		require.Greater(t, r.Txns, 0)
+		require.LessOrEqual(t, r.Min, r.P50)
+		require.LessOrEqual(t, r.P50, r.Max)
+	}
+	require.Equal(t, []string{"parse ", "edge-derivation ", "node-insert ", "edge-insert ", "query ser/sv", "query si/sv"}, phases)
+
+	nodes, edges := records[2].DB, records[3].DB
+	require.Equal(t, int64(records[0].Txns), nodes["txn_count"])
+	require.Equal(t, int64(0), nodes["txn_dep_count"])
+	require.Equal(t, nodes["txn_count"], edges["txn_count"])
+	require.Greater(t, edges["txn_dep_count"], int64(0))
+	require.Greater(t, edges["bytes"], int64(0))
+}
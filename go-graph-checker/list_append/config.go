@@ -0,0 +1,250 @@
+package listappend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+/*
+the constants used by the tests and the experiments
+*/
+func DefaultDBConsts() DBConsts {
+	return DBConsts{
+		Host:          "starter",
+		Port:          8529,
+		DB:            "checker_db",
+		TxnGraph:      "txn_g",
+		EvtGraph:      "evt_g",
+		TxnNode:       "txn",
+		AppendEvtNode: "a_evt",
+		ReadEvtNode:   "r_evt",
+		TxnDepEdge:    "dep",
+		EvtDepEdge:    "evt_dep",
+	}
+}
+
+type CollectionsConfig struct {
+	TxnGraph      string `json:"txn_graph" yaml:"txn_graph"`
+	EvtGraph      string `json:"evt_graph" yaml:"evt_graph"`
+	TxnNode       string `json:"txn" yaml:"txn"`
+	AppendEvtNode string `json:"append_evt" yaml:"append_evt"`
+	ReadEvtNode   string `json:"read_evt" yaml:"read_evt"`
+	TxnDepEdge    string `json:"txn_dep" yaml:"txn_dep"`
+	EvtDepEdge    string `json:"evt_dep" yaml:"evt_dep"`
+}
+
+/*
+layout of a config file, e.g.
+
+	endpoints: [https://coordinator1:8529, https://coordinator2:8529]
+	database: checker_db
+	prefix: nightly
+	auth: {type: basic, username: root, password: secret}
+	tls: {ca_file: ca.pem}
+
+the booleans are pointers, so that a later config (e.g. the environment) can turn off
+what an earlier one turned on, an unset boolean leaves the setting alone
+*/
+type FileConfig struct {
+	Host        string            `json:"host" yaml:"host"`
+	Port        int               `json:"port" yaml:"port"`
+	DB          string            `json:"database" yaml:"database"`
+	Overwrite   *bool             `json:"overwrite" yaml:"overwrite"`
+	PerRun      *bool             `json:"per_run" yaml:"per_run"`
+	Prefix      string            `json:"prefix" yaml:"prefix"` // see DBConsts.Namespaced
+	Collections CollectionsConfig `json:"collections" yaml:"collections"`
+	Endpoints   []string          `json:"endpoints" yaml:"endpoints"`
+	Auth        AuthConfig        `json:"auth" yaml:"auth"`
+	TLS         TLSFileConfig     `json:"tls" yaml:"tls"`
+}
+
+/*
+TLSConfig of a config file
+*/
+type TLSFileConfig struct {
+	CAFile             string `json:"ca_file" yaml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file"`
+	KeyFile            string `json:"key_file" yaml:"key_file"`
+	ServerName         string `json:"server_name" yaml:"server_name"`
+	InsecureSkipVerify *bool  `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+/*
+environment variables overriding the config file
+*/
+const (
+	EnvConfig      = "GRAIL_CONFIG" // path of the config file
+	EnvEndpoints   = "GRAIL_ENDPOINTS"
+	EnvHost        = "GRAIL_HOST"
+	EnvPort        = "GRAIL_PORT"
+	EnvDB          = "GRAIL_DB"
+	EnvOverwrite   = "GRAIL_OVERWRITE"
+	EnvPerRun      = "GRAIL_PER_RUN"
+	EnvPrefix      = "GRAIL_PREFIX"
+	EnvAuthType    = "GRAIL_AUTH"
+	EnvUsername    = "GRAIL_USERNAME"
+	EnvPassword    = "GRAIL_PASSWORD"
+	EnvToken       = "GRAIL_TOKEN"
+	EnvTLSCA       = "GRAIL_TLS_CA"
+	EnvTLSCert     = "GRAIL_TLS_CERT"
+	EnvTLSKey      = "GRAIL_TLS_KEY"
+	EnvTLSInsecure = "GRAIL_TLS_INSECURE"
+)
+
+/*
+reads a YAML (.yaml, .yml) or JSON config file
+*/
+func ParseConfigFile(path string) (FileConfig, error) {
+	var conf FileConfig
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return conf, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &conf)
+	case ".json":
+		err = json.Unmarshal(content, &conf)
+	default:
+		err = fmt.Errorf("invalid config file %s, expecting .yaml, .yml or .json", path)
+	}
+	return conf, err
+}
+
+func overrideStr(dest *string, value string) {
+	if value != "" {
+		*dest = value
+	}
+}
+
+func overrideBool(dest *bool, value *bool) {
+	if value != nil {
+		*dest = *value
+	}
+}
+
+/*
+applies the non-empty settings of conf to dbConsts
+*/
+func (conf FileConfig) apply(dbConsts DBConsts) DBConsts {
+	overrideStr(&dbConsts.Host, conf.Host)
+	if conf.Port != 0 {
+		dbConsts.Port = conf.Port
+	}
+	overrideStr(&dbConsts.DB, conf.DB)
+	overrideBool(&dbConsts.Overwrite, conf.Overwrite)
+
+	cols := conf.Collections
+	overrideStr(&dbConsts.TxnGraph, cols.TxnGraph)
+	overrideStr(&dbConsts.EvtGraph, cols.EvtGraph)
+	overrideStr(&dbConsts.TxnNode, cols.TxnNode)
+	overrideStr(&dbConsts.AppendEvtNode, cols.AppendEvtNode)
+	overrideStr(&dbConsts.ReadEvtNode, cols.ReadEvtNode)
+	overrideStr(&dbConsts.TxnDepEdge, cols.TxnDepEdge)
+	overrideStr(&dbConsts.EvtDepEdge, cols.EvtDepEdge)
+
+	if len(conf.Endpoints) > 0 {
+		dbConsts.Conn.Endpoints = conf.Endpoints
+	}
+	auth := &dbConsts.Conn.Auth
+	overrideStr(&auth.Type, conf.Auth.Type)
+	overrideStr(&auth.Username, conf.Auth.Username)
+	overrideStr(&auth.Password, conf.Auth.Password)
+	overrideStr(&auth.Token, conf.Auth.Token)
+	tlsConf := &dbConsts.Conn.TLS
+	overrideStr(&tlsConf.CAFile, conf.TLS.CAFile)
+	overrideStr(&tlsConf.CertFile, conf.TLS.CertFile)
+	overrideStr(&tlsConf.KeyFile, conf.TLS.KeyFile)
+	overrideStr(&tlsConf.ServerName, conf.TLS.ServerName)
+	overrideBool(&tlsConf.InsecureSkipVerify, conf.TLS.InsecureSkipVerify)
+	return dbConsts
+}
+
+func envConfig() (FileConfig, error) {
+	var conf FileConfig
+	if endpoints := os.Getenv(EnvEndpoints); endpoints != "" {
+		for _, e := range strings.Split(endpoints, ",") {
+			conf.Endpoints = append(conf.Endpoints, strings.TrimSpace(e))
+		}
+	}
+	conf.Host = os.Getenv(EnvHost)
+	if port := os.Getenv(EnvPort); port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return conf, fmt.Errorf("invalid %s: %v", EnvPort, err)
+		}
+		conf.Port = p
+	}
+	conf.DB = os.Getenv(EnvDB)
+	conf.Prefix = os.Getenv(EnvPrefix)
+	conf.Auth = AuthConfig{
+		Type:     os.Getenv(EnvAuthType),
+		Username: os.Getenv(EnvUsername),
+		Password: os.Getenv(EnvPassword),
+		Token:    os.Getenv(EnvToken),
+	}
+	conf.TLS = TLSFileConfig{
+		CAFile:   os.Getenv(EnvTLSCA),
+		CertFile: os.Getenv(EnvTLSCert),
+		KeyFile:  os.Getenv(EnvTLSKey),
+	}
+	for env, dest := range map[string]**bool{
+		EnvOverwrite:   &conf.Overwrite,
+		EnvPerRun:      &conf.PerRun,
+		EnvTLSInsecure: &conf.TLS.InsecureSkipVerify,
+	} {
+		if v := os.Getenv(env); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return conf, fmt.Errorf("invalid %s: %v", env, err)
+			}
+			*dest = &b
+		}
+	}
+	return conf, nil
+}
+
+/*
+returns DefaultDBConsts, overridden by the config file at path (or $GRAIL_CONFIG if path is empty)
+and then by the GRAIL_* environment variables
+*/
+func LoadDBConsts(path string) (DBConsts, error) {
+	dbConsts := DefaultDBConsts()
+	if path == "" {
+		path = os.Getenv(EnvConfig)
+	}
+
+	var confs []FileConfig
+	if path != "" {
+		conf, err := ParseConfigFile(path)
+		if err != nil {
+			return dbConsts, err
+		}
+		confs = append(confs, conf)
+	}
+	env, err := envConfig()
+	if err != nil {
+		return dbConsts, err
+	}
+	confs = append(confs, env)
+
+	prefix, perRun := "", false
+	for _, conf := range confs {
+		dbConsts = conf.apply(dbConsts)
+		overrideStr(&prefix, conf.Prefix)
+		overrideBool(&perRun, conf.PerRun)
+	}
+	if prefix != "" {
+		dbConsts = dbConsts.Namespaced(prefix)
+	}
+	if perRun {
+		dbConsts = dbConsts.PerRun()
+	}
+	return dbConsts, nil
+}
@@ -0,0 +1,134 @@
+package listappend
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+/*
+a mock coordinator answering the version endpoint,
+it records the Authorization header of the last request
+*/
+func newMockCoordinator(auth *atomic.Value) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth.Store(r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/_api/version":
+			w.Write([]byte(`{"server":"arango","version":"3.9.10","license":"community"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":true,"code":404,"errorNum":1203,"errorMessage":"not found"}`))
+		}
+	}))
+}
+
+func TestLoadDBConsts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "grail.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+endpoints: [http://coordinator1:8529, http://coordinator2:8529]
+database: nightly_db
+prefix: nightly
+auth:
+  type: basic
+  username: root
+  password: secret
+collections:
+  txn: t
+`), 0644))
+
+	t.Setenv(EnvPassword, "from-env")
+	t.Setenv(EnvOverwrite, "true")
+
+	dbConsts, err := LoadDBConsts(path)
+	require.NoError(t, err)
+	require.Equal(t, "nightly_db", dbConsts.DB)
+	require.Equal(t, []string{"http://coordinator1:8529", "http://coordinator2:8529"}, dbConsts.Conn.Endpoints)
+	require.Equal(t, "root", dbConsts.Conn.Auth.Username)
+	require.Equal(t, "from-env", dbConsts.Conn.Auth.Password)
+	require.True(t, dbConsts.Overwrite)
+	require.Equal(t, "nightly_t", dbConsts.TxnNode)
+	require.Equal(t, "nightly_dep", dbConsts.TxnDepEdge)
+
+	jsonPath := filepath.Join(dir, "grail.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"host": "localhost", "port": 8530, "tls": {"insecure_skip_verify": true}}`), 0644))
+	dbConsts, err = LoadDBConsts(jsonPath)
+	require.NoError(t, err)
+	require.Equal(t, []string{"https://localhost:8530"}, dbConsts.endpoints())
+
+	_, err = LoadDBConsts(filepath.Join(dir, "grail.toml"))
+	require.Error(t, err)
+}
+
+func TestLoadDBConstsEnvFalse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grail.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+overwrite: true
+per_run: true
+tls: {insecure_skip_verify: true}
+`), 0644))
+
+	dbConsts, err := LoadDBConsts(path)
+	require.NoError(t, err)
+	require.NotEmpty(t, dbConsts.RunID)
+
+	// false in the environment wins over true in the file
+	t.Setenv(EnvPerRun, "false")
+	dbConsts, err = LoadDBConsts(path)
+	require.NoError(t, err)
+	require.Empty(t, dbConsts.RunID)
+	require.True(t, dbConsts.Overwrite)
+	require.True(t, dbConsts.Conn.TLS.InsecureSkipVerify)
+
+	t.Setenv(EnvOverwrite, "false")
+	t.Setenv(EnvTLSInsecure, "false")
+	dbConsts, err = LoadDBConsts(path)
+	require.NoError(t, err)
+	require.False(t, dbConsts.Overwrite)
+	require.False(t, dbConsts.Conn.TLS.InsecureSkipVerify)
+	require.Empty(t, dbConsts.RunID)
+	require.Equal(t, "checker_db", dbConsts.DB)
+}
+
+func TestClientFailoverAndAuth(t *testing.T) {
+	var auth atomic.Value
+	mock := newMockCoordinator(&auth)
+	defer mock.Close()
+
+	// the first coordinator is down
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL := down.URL
+	down.Close()
+
+	dbConsts := DefaultDBConsts()
+	dbConsts.Conn = ConnConfig{
+		Endpoints: []string{downURL, mock.URL},
+		Auth:      AuthConfig{Type: "basic", Username: "root", Password: "secret"},
+	}
+	client, err := newClient(dbConsts)
+	require.NoError(t, err)
+	version, err := client.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "3.9.10", string(version.Version))
+	require.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("root:secret")), auth.Load())
+
+	dbConsts.Conn.Auth = AuthConfig{Type: "jwt", Token: "abc"}
+	client, err = newClient(dbConsts)
+	require.NoError(t, err)
+	_, err = client.Version(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "bearer abc", auth.Load())
+
+	dbConsts.Conn.Auth = AuthConfig{Type: "kerberos"}
+	_, err = newClient(dbConsts)
+	require.Error(t, err)
+}
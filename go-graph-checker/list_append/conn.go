@@ -0,0 +1,121 @@
+package listappend
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	driver "github.com/arangodb/go-driver"
+	"github.com/arangodb/go-driver/http"
+)
+
+type AuthConfig struct {
+	Type     string `json:"type" yaml:"type"` // "" (none), "basic" or "jwt"
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password" yaml:"password"`
+	Token    string `json:"token" yaml:"token"` // a ready JWT, used instead of username/password
+}
+
+type TLSConfig struct {
+	CAFile             string `json:"ca_file" yaml:"ca_file"`
+	CertFile           string `json:"cert_file" yaml:"cert_file"`
+	KeyFile            string `json:"key_file" yaml:"key_file"`
+	ServerName         string `json:"server_name" yaml:"server_name"`
+	InsecureSkipVerify bool   `json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+func (c TLSConfig) enabled() bool {
+	return c.CAFile != "" || c.CertFile != "" || c.ServerName != "" || c.InsecureSkipVerify
+}
+
+/*
+Endpoints lists the coordinators, the client fails over to the next one
+when a coordinator is unreachable. Without endpoints, Host and Port of DBConsts are used.
+*/
+type ConnConfig struct {
+	Endpoints []string   `json:"endpoints" yaml:"endpoints"`
+	Auth      AuthConfig `json:"auth" yaml:"auth"`
+	TLS       TLSConfig  `json:"tls" yaml:"tls"`
+}
+
+func (dbConsts DBConsts) endpoints() []string {
+	if len(dbConsts.Conn.Endpoints) > 0 {
+		return dbConsts.Conn.Endpoints
+	}
+	scheme := "http"
+	if dbConsts.Conn.TLS.enabled() {
+		scheme = "https"
+	}
+	return []string{fmt.Sprintf("%s://%s:%d", scheme, dbConsts.Host, dbConsts.Port)}
+}
+
+func (c TLSConfig) build() (*tls.Config, error) {
+	if !c.enabled() {
+		return nil, nil
+	}
+	conf := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if c.CAFile != "" {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.CAFile)
+		}
+		conf.RootCAs = pool
+	}
+	if c.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.Certificates = []tls.Certificate{cert}
+	}
+	return conf, nil
+}
+
+func (c AuthConfig) build() (driver.Authentication, error) {
+	switch c.Type {
+	case "":
+		return nil, nil
+	case "basic":
+		return driver.BasicAuthentication(c.Username, c.Password), nil
+	case "jwt":
+		if c.Token != "" {
+			return driver.RawAuthentication("bearer " + c.Token), nil
+		}
+		return driver.JWTAuthentication(c.Username, c.Password), nil
+	default:
+		return nil, fmt.Errorf("invalid auth type: %s, not from any of the following: basic, jwt", c.Type)
+	}
+}
+
+/*
+returns a client for the endpoints, authentication and TLS settings of dbConsts
+*/
+func newClient(dbConsts DBConsts) (driver.Client, error) {
+	tlsConfig, err := dbConsts.Conn.TLS.build()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := http.NewConnection(http.ConnectionConfig{
+		Endpoints: dbConsts.endpoints(),
+		TLSConfig: tlsConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+	auth, err := dbConsts.Conn.Auth.build()
+	if err != nil {
+		return nil, err
+	}
+	return driver.NewClient(driver.ClientConfig{
+		Connection:     conn,
+		Authentication: auth,
+	})
+}
@@ -0,0 +1,241 @@
+package listappend
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+)
+
+/*
+DepGraph holds the nodes and dependency edges GRAIL stores in ArangoDB, derived in memory.
+Ids follow the ArangoDB document ids ("<collection>/<key>") of dbConsts, so that edges
+of both sources can be compared and fed to the same checkers.
+*/
+type DepGraph struct {
+	TxnIds      []int
+	Txns        []TxnNode
+	AppendEvts  []AppendEvt
+	ReadEvts    []ReadEvt
+	EvtDepEdges []EvtDepEdge
+	TxnDepEdges []TxnDepEdge
+	G1          G1Anomalies
+	DBConsts    DBConsts
+}
+
+/*
+BuildDepGraph derives the dependency graph of a history without a database,
+the counterpart of ConstructGraph for the embedded and in-memory backends
+*/
+func BuildDepGraph(history core.History, dbConsts DBConsts) DepGraph {
+	g, err := TryBuildDepGraph(history, dbConsts)
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	return g
+}
+
+/*
+TryBuildDepGraph is BuildDepGraph returning an error instead of exiting
+when the history cannot be traced (duplicate appends, non-prefix reads)
+*/
+func TryBuildDepGraph(history core.History, dbConsts DBConsts) (DepGraph, error) {
+	history = preProcessHistory(history)
+	okHistory := core.FilterOkHistory(history)
+
+	txns, txnIds, appendEvts, readEvts := collectNodes(okHistory)
+	appendMap, itmdMap, err := groupAppendEvts(appendEvts, dbConsts)
+	if err != nil {
+		return DepGraph{}, err
+	}
+	evtDepEdges, g1, err := deriveEvtDepEdges(groupReadEvts(readEvts, dbConsts), appendMap, itmdMap)
+	if err != nil {
+		return DepGraph{}, err
+	}
+
+	return DepGraph{
+		TxnIds:      txnIds,
+		Txns:        txns,
+		AppendEvts:  appendEvts,
+		ReadEvts:    readEvts,
+		EvtDepEdges: evtDepEdges,
+		TxnDepEdges: projectTxnDepEdges(evtDepEdges, dbConsts),
+		G1:          g1,
+		DBConsts:    dbConsts,
+	}, nil
+}
+
+func docId(collection string, key string) string {
+	return fmt.Sprintf("%s/%s", collection, key)
+}
+
+/*
+in-memory counterpart of queryReadEvts: read events grouped by object,
+then by the value read, longer values first
+*/
+func groupReadEvts(readEvts []ReadEvt, dbConsts DBConsts) []ReadEvtsInfo {
+	byObj := make(map[string]map[string]*ReadEvtsTrace)
+	for _, evt := range readEvts {
+		if _, ok := byObj[evt.Obj]; !ok {
+			byObj[evt.Obj] = make(map[string]*ReadEvtsTrace)
+		}
+		val := fmt.Sprint(evt.V)
+		if _, ok := byObj[evt.Obj][val]; !ok {
+			byObj[evt.Obj][val] = &ReadEvtsTrace{Val: evt.V}
+		}
+		trace := byObj[evt.Obj][val]
+		trace.Ids = append(trace.Ids, docId(dbConsts.ReadEvtNode, evt.Key))
+	}
+
+	infos := make([]ReadEvtsInfo, 0, len(byObj))
+	for obj, traces := range byObj {
+		info := ReadEvtsInfo{Obj: obj}
+		for _, trace := range traces {
+			info.Traces = append(info.Traces, *trace)
+		}
+		sort.Slice(info.Traces, func(i, j int) bool {
+			vi, vj := info.Traces[i].Val, info.Traces[j].Val
+			if len(vi) != len(vj) {
+				return len(vi) > len(vj)
+			}
+			return fmt.Sprint(vi) < fmt.Sprint(vj)
+		})
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Obj < infos[j].Obj })
+	return infos
+}
+
+/*
+in-memory counterpart of queryAppendEvts
+*/
+func groupAppendEvts(appendEvts []AppendEvt, dbConsts DBConsts) (map[string]map[int]string, map[string]map[int]bool, error) {
+	appendMap := make(map[string]map[int]string)
+	itmdMap := make(map[string]map[int]bool)
+	for _, evt := range appendEvts {
+		if _, ok := appendMap[evt.Obj]; !ok {
+			appendMap[evt.Obj] = make(map[int]string)
+			itmdMap[evt.Obj] = make(map[int]bool)
+		}
+		id := docId(dbConsts.AppendEvtNode, evt.Key)
+		if prev, ok := appendMap[evt.Obj][evt.Arg]; ok {
+			return nil, nil, fmt.Errorf("Anomaly: Multiple events %v append the same value %v to the same object %v. Non-recoverable.",
+				[]string{prev, id}, evt.Arg, evt.Obj)
+		}
+		appendMap[evt.Obj][evt.Arg] = id
+		if evt.Index != -1 {
+			itmdMap[evt.Obj][evt.Arg] = true
+		}
+	}
+	return appendMap, itmdMap, nil
+}
+
+// the txn key of an evt id "<collection>/<txn>,<evt>"
+func evtTxnKey(id string) string {
+	return strings.Split(strings.SplitN(id, "/", 2)[1], ",")[0]
+}
+
+/*
+in-memory counterpart of getTxnDepEdges: evt edges between different txns projected
+to txn edges, one per (from, to, type) carrying the first pair of evts
+*/
+func projectTxnDepEdges(evtDepEdges []EvtDepEdge, dbConsts DBConsts) []TxnDepEdge {
+	type edgeKey struct{ from, to, typ string }
+	seen := make(map[edgeKey]bool)
+	txnDepEdges := make([]TxnDepEdge, 0, len(evtDepEdges))
+	for _, e := range evtDepEdges {
+		fromTxn, toTxn := evtTxnKey(e.From), evtTxnKey(e.To)
+		if fromTxn == toTxn {
+			continue
+		}
+		k := edgeKey{docId(dbConsts.TxnNode, fromTxn), docId(dbConsts.TxnNode, toTxn), e.Type}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		txnDepEdges = append(txnDepEdges, TxnDepEdge{From: k.from, To: k.to, FromEvt: e.From, ToEvt: e.To, Obj: e.Obj, Type: e.Type})
+	}
+	sort.SliceStable(txnDepEdges, func(i, j int) bool {
+		a, b := txnDepEdges[i], txnDepEdges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+	return txnDepEdges
+}
+
+/*
+RealtimeTxnDepEdges derives the "realtime" txn edges of the go-elle realtime graph: T1 -> T2 if T1
+completed before T2 was invoked, more than opts.RealtimeEpsilon before by the :time of the ops if it
+is set. The edges implied by transitivity are left out, they carry no evts.
+*/
+func RealtimeTxnDepEdges(opts txn.Opts, history core.History, dbConsts DBConsts) []TxnDepEdge {
+	history = preProcessHistory(history)
+	_, g, _ := txn.RealtimeAnalyzer(opts)(history)
+
+	txnId := func(v core.Vertex) (string, bool) {
+		op := v.Value.(core.Op)
+		return docId(dbConsts.TxnNode, strconv.Itoa(op.Index.MustGet())), op.Type == core.OpTypeOk
+	}
+	var txnDepEdges []TxnDepEdge
+	for from, outs := range g.Outs {
+		fromId, ok := txnId(from)
+		if !ok {
+			continue
+		}
+		for to := range outs {
+			// completions that failed or are unknown are no txns
+			if toId, ok := txnId(to); ok {
+				txnDepEdges = append(txnDepEdges, TxnDepEdge{From: fromId, To: toId, Type: string(core.Realtime)})
+			}
+		}
+	}
+	sort.Slice(txnDepEdges, func(i, j int) bool {
+		a, b := txnDepEdges[i], txnDepEdges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return txnDepEdges
+}
+
+/*
+WithRealtime returns the graph with the realtime edges of RealtimeTxnDepEdges added,
+its anti-patterns then include the violations of the realtime order (e.g. strict SER for SER)
+*/
+func (g DepGraph) WithRealtime(opts txn.Opts, history core.History) DepGraph {
+	edges := RealtimeTxnDepEdges(opts, history, g.DBConsts)
+	g.TxnDepEdges = append(append(make([]TxnDepEdge, 0, len(g.TxnDepEdges)+len(edges)), g.TxnDepEdges...), edges...)
+	return g
+}
+
+/*
+Export converts the txn graph for the exporters, edges keep their obj and evts
+*/
+func (g DepGraph) Export() export.Graph {
+	var eg export.Graph
+	for _, txn := range g.Txns {
+		eg.Nodes = append(eg.Nodes, export.Node{ID: docId(g.DBConsts.TxnNode, txn.Key), Key: txn.Key, Label: g.DBConsts.TxnNode})
+	}
+	eg.Edges = exportEdges(g.TxnDepEdges)
+	eg.Sort()
+	return eg
+}
+
+func exportEdges(edges []TxnDepEdge) []export.Edge {
+	exported := make([]export.Edge, 0, len(edges))
+	for _, e := range edges {
+		exported = append(exported, export.Edge{From: e.From, To: e.To, FromEvt: e.FromEvt, ToEvt: e.ToEvt, Obj: e.Obj, Type: e.Type})
+	}
+	return exported
+}
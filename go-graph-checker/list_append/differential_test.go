@@ -0,0 +1,54 @@
+package listappend
+
+import (
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffCheckerSQLite(t *testing.T) {
+	// G1c, both checkers find it
+	g1c := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:append x 1] [:r y [1]]], :index 0}`),
+		mustParseOp(`{:type :ok, :value [[:append y 1] [:r x [1]]], :index 1}`),
+	}
+	diff := differential.Compare("g1c", g1c, DiffCheckerSQLite("all", DefaultDBConsts()), differential.ElleListAppend)
+	require.Empty(t, diff.Disagreements)
+
+	// G-single, go-elle only orders the observed versions of key 2, so it misses
+	// the rw edge from T2 to T1 that GRAIL infers from [:r 2 [1]]
+	gSingle := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:append 1 1] [:append 2 1]], :index 0}`),
+		mustParseOp(`{:type :ok, :value [[:append 1 2] [:append 2 2]], :index 1}`),
+		mustParseOp(`{:type :ok, :value [[:r 1 [1 2]] [:r 2 [1]]], :index 2}`),
+	}
+	diff = differential.Compare("g-single", gSingle, DiffCheckerSQLite("all", DefaultDBConsts()), differential.ElleListAppend)
+	levels := make([]string, 0, len(diff.Disagreements))
+	for _, d := range diff.Disagreements {
+		require.False(t, d.Grail.Valid)
+		require.NotEmpty(t, d.Grail.Witness)
+		require.True(t, d.Elle.Valid)
+		levels = append(levels, d.Level)
+	}
+	require.Equal(t, []string{"psi", "si", "ser"}, levels)
+}
+
+// go test -v -timeout 600s -run ^TestDiffCheckerArango$ github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append
+func TestDiffCheckerArango(t *testing.T) {
+	gSingle := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:append 1 1] [:append 2 1]], :index 0}`),
+		mustParseOp(`{:type :ok, :value [[:append 1 2] [:append 2 2]], :index 1}`),
+		mustParseOp(`{:type :ok, :value [[:r 1 [1 2]] [:r 2 [1]]], :index 2}`),
+	}
+	// the ArangoDB checker agrees with the offline fallback on every level
+	dbConsts := DefaultDBConsts().PerRun()
+	arango := DiffChecker("sv", dbConsts)(gSingle)
+	offline := DiffCheckerSQLite("sv", dbConsts)(gSingle)
+	for name, verdict := range offline {
+		require.Equal(t, verdict.Valid, arango[name].Valid, name)
+	}
+	require.False(t, arango["si"].Valid)
+	require.True(t, arango["pl-2"].Valid)
+}
@@ -0,0 +1,217 @@
+package listappend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/arangodb/go-driver"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+/*
+EvtDocs holds the evt documents the txn edges were derived from, keyed by their ids
+("<collection>/<key>"), to justify every edge of a witness cycle like the go-elle explainers
+*/
+type EvtDocs struct {
+	Appends map[string]AppendEvt
+	Reads   map[string]ReadEvt
+}
+
+/*
+reads the evt documents of edges from one of the backends, see reportCycle
+*/
+type evtSource func(edges []TxnDepEdge) EvtDocs
+
+/*
+EvtDocs returns the evt documents of the in-memory graph
+*/
+func (g DepGraph) EvtDocs() EvtDocs {
+	docs := EvtDocs{Appends: make(map[string]AppendEvt, len(g.AppendEvts)), Reads: make(map[string]ReadEvt, len(g.ReadEvts))}
+	for _, evt := range g.AppendEvts {
+		docs.Appends[docId(g.DBConsts.AppendEvtNode, evt.Key)] = evt
+	}
+	for _, evt := range g.ReadEvts {
+		docs.Reads[docId(g.DBConsts.ReadEvtNode, evt.Key)] = evt
+	}
+	return docs
+}
+
+/*
+the ids of the append and read evts of the edges, by the types of the edges:
+ww edges link appends, wr edges an append to a read and rw edges a read to an append
+*/
+func evtIds(edges []TxnDepEdge) (appendIds []string, readIds []string) {
+	for _, e := range edges {
+		switch e.Type {
+		case "ww":
+			appendIds = append(appendIds, e.FromEvt, e.ToEvt)
+		case "wr":
+			appendIds = append(appendIds, e.FromEvt)
+			readIds = append(readIds, e.ToEvt)
+		case "rw":
+			readIds = append(readIds, e.FromEvt)
+			appendIds = append(appendIds, e.ToEvt)
+		}
+	}
+	return appendIds, readIds
+}
+
+/*
+QueryEvtDocs reads the evt documents of the edges from ArangoDB
+*/
+func QueryEvtDocs(db driver.Database, edges []TxnDepEdge) EvtDocs {
+	appendIds, readIds := evtIds(edges)
+	return EvtDocs{Appends: queryDocs[AppendEvt](db, appendIds), Reads: queryDocs[ReadEvt](db, readIds)}
+}
+
+func arangoEvts(db driver.Database) evtSource {
+	return func(edges []TxnDepEdge) EvtDocs { return QueryEvtDocs(db, edges) }
+}
+
+// the documents of ids, missing documents are left out
+func queryDocs[T any](db driver.Database, ids []string) map[string]T {
+	docs := make(map[string]T, len(ids))
+	if len(ids) == 0 {
+		return docs
+	}
+	query := `
+		FOR id IN @ids
+			LET doc = DOCUMENT(id)
+			FILTER doc != null
+			RETURN {id: id, doc: doc}
+	`
+	cursor, err := db.Query(context.Background(), query, map[string]interface{}{"ids": ids})
+	if err != nil {
+		log.Fatalf("Query failed: %v\n", err)
+	}
+	defer cursor.Close()
+
+	for {
+		var found struct {
+			Id  string `json:"id"`
+			Doc T      `json:"doc"`
+		}
+		_, err := cursor.ReadDocument(context.Background(), &found)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			log.Fatalf("Cannot read return values: %v\n", err)
+		}
+		docs[found.Id] = found.Doc
+	}
+	return docs
+}
+
+/*
+QuerySQLiteEvtDocs reads the evt documents of the edges from the SQLite backend
+*/
+func QuerySQLiteEvtDocs(db *sql.DB, edges []TxnDepEdge) (EvtDocs, error) {
+	appendIds, readIds := evtIds(edges)
+	docs := EvtDocs{Appends: make(map[string]AppendEvt, len(appendIds)), Reads: make(map[string]ReadEvt, len(readIds))}
+	for _, id := range appendIds {
+		evt := AppendEvt{Key: evtDocKey(id)}
+		err := db.QueryRow(`SELECT obj, arg, idx FROM append_evt WHERE id = ?`, id).Scan(&evt.Obj, &evt.Arg, &evt.Index)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return EvtDocs{}, err
+		}
+		docs.Appends[id] = evt
+	}
+	for _, id := range readIds {
+		evt := ReadEvt{Key: evtDocKey(id)}
+		var v string
+		err := db.QueryRow(`SELECT obj, v FROM read_evt WHERE id = ?`, id).Scan(&evt.Obj, &v)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return EvtDocs{}, err
+		}
+		if err := json.Unmarshal([]byte(v), &evt.V); err != nil {
+			return EvtDocs{}, err
+		}
+		docs.Reads[id] = evt
+	}
+	return docs, nil
+}
+
+func sqliteEvts(db *sql.DB) evtSource {
+	return func(edges []TxnDepEdge) EvtDocs {
+		docs, err := QuerySQLiteEvtDocs(db, edges)
+		if err != nil {
+			log.Fatalf("Cannot read evt documents: %v\n", err)
+		}
+		return docs
+	}
+}
+
+// the document key of an id "<collection>/<key>"
+func evtDocKey(id string) string {
+	return strings.SplitN(id, "/", 2)[1]
+}
+
+// "T12" for the txn id "txn/12"
+func txnName(id string) string {
+	return "T" + evtDocKey(id)
+}
+
+// a list read, eliding all but the last element, e.g. [..,5]
+func renderRead(v []int) string {
+	switch len(v) {
+	case 0:
+		return "[]"
+	case 1:
+		return fmt.Sprintf("[%d]", v[0])
+	default:
+		return fmt.Sprintf("[..,%d]", v[len(v)-1])
+	}
+}
+
+/*
+ExplainEdge justifies a txn edge from its evt documents, e.g. "T12 appended 5 to key x, T40 read [..,5] of x"
+for wr or "T40 read x before 7 was appended by T55" for rw; without the documents, only the type and key are given
+*/
+func (docs EvtDocs) ExplainEdge(e TxnDepEdge) string {
+	a, b := txnName(e.From), txnName(e.To)
+	switch e.Type {
+	case "ww":
+		pre, preOk := docs.Appends[e.FromEvt]
+		post, postOk := docs.Appends[e.ToEvt]
+		if preOk && postOk {
+			return fmt.Sprintf("%s appended %d to key %s, %s appended %d right after it", a, pre.Arg, pre.Obj, b, post.Arg)
+		}
+	case "wr":
+		w, wOk := docs.Appends[e.FromEvt]
+		r, rOk := docs.Reads[e.ToEvt]
+		if wOk && rOk {
+			return fmt.Sprintf("%s appended %d to key %s, %s read %s of %s", a, w.Arg, w.Obj, b, renderRead(r.V), r.Obj)
+		}
+	case "rw":
+		r, rOk := docs.Reads[e.FromEvt]
+		w, wOk := docs.Appends[e.ToEvt]
+		if rOk && wOk {
+			if len(r.V) == 0 {
+				return fmt.Sprintf("%s read %s before %d was appended by %s", a, r.Obj, w.Arg, b)
+			}
+			return fmt.Sprintf("%s read %s of %s before %d was appended by %s", a, renderRead(r.V), r.Obj, w.Arg, b)
+		}
+	case string(core.Realtime):
+		return fmt.Sprintf("%s completed before %s was invoked", a, b)
+	}
+	return fmt.Sprintf("%s (%s) %s on key %s", a, e.Type, b, e.Obj)
+}
+
+/*
+ExplainCycle justifies every edge of a cycle, see ExplainEdge
+*/
+func (docs EvtDocs) ExplainCycle(cycle []TxnDepEdge) []string {
+	lines := make([]string, len(cycle))
+	for i, e := range cycle {
+		lines[i] = docs.ExplainEdge(e)
+	}
+	return lines
+}
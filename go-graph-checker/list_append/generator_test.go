@@ -0,0 +1,49 @@
+package listappend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedHistories(t *testing.T) {
+	// the weakest level an injected anomaly violates, G1a and G1b are flags
+	weakest := map[generator.Anomaly]string{
+		generator.G0:         "pl-1",
+		generator.G1c:        "pl-2",
+		generator.GSingle:    "psi",
+		generator.LostUpdate: "psi",
+		generator.LongFork:   "si",
+		generator.WriteSkew:  "ser",
+	}
+	for _, a := range append([]generator.Anomaly{""}, generator.Anomalies...) {
+		opts := generator.DefaultOpts()
+		opts.Txns = 20
+		if a != "" {
+			opts.Inject = []generator.Anomaly{a}
+		}
+		r, err := generator.Generate(opts)
+		require.NoError(t, err)
+
+		// through EDN, as GRAIL reads it
+		var edn strings.Builder
+		for _, op := range r.History {
+			edn.WriteString(op.String() + "\n")
+		}
+		history, err := core.ParseHistory(edn.String())
+		require.NoError(t, err)
+
+		verdicts := DiffCheckerSQLite("sv", DefaultDBConsts())(history)
+		require.Equal(t, a != generator.G1a, verdicts["G1a"].Valid, a)
+		require.Equal(t, a != generator.G1b, verdicts["G1b"].Valid, a)
+		violated := false
+		for _, level := range differential.Levels {
+			violated = violated || level == weakest[a]
+			require.Equal(t, !violated, verdicts[level].Valid, "%s %s %s", a, level, verdicts[level].Witness)
+		}
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"strings"
 
 	driver "github.com/arangodb/go-driver"
-	"github.com/arangodb/go-driver/http"
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
 )
 
@@ -51,6 +50,9 @@ type DBConsts struct {
 	ReadEvtNode   string
 	TxnDepEdge    string
 	EvtDepEdge    string
+	Overwrite     bool   // drop an existing checker-owned DB of the same name
+	RunID         string // identifies the run that owns DB, see PerRun
+	Conn          ConnConfig
 }
 
 /*
@@ -65,41 +67,54 @@ func preProcessHistory(history core.History) core.History {
 /*
 returns a client instance of ArangoDB
 */
-func startClient(host string, port int) driver.Client {
-	endpoint := fmt.Sprintf("http://%s:%d", host, port)
-	conn, err := http.NewConnection(http.ConnectionConfig{
-		Endpoints: []string{endpoint},
-	})
-	if err != nil {
-		log.Fatalf("Failed to connect to the host %s at port %d: %v\n", host, port, err)
-	}
-	client, err := driver.NewClient(driver.ClientConfig{
-		Connection: conn,
-	})
+func startClient(dbConsts DBConsts) driver.Client {
+	client, err := newClient(dbConsts)
 	if err != nil {
-		log.Fatalf("Failed to create a new client: %v\n", err)
+		log.Fatalf("Failed to connect to %v: %v\n", dbConsts.endpoints(), err)
 	}
 	return client
 }
 
 /*
 get db if db already exists, otherwise create db
+
+an existing db is only dropped if it carries the checker marker
+and dbConsts.Overwrite is set; any other db is left untouched.
+a db created by the same run (dbConsts.RunID) is reused as it is
 */
-func getOrCreateDB(client driver.Client, dbName string) driver.Database {
+func getOrCreateDB(client driver.Client, dbConsts DBConsts) driver.Database {
+	dbName := dbConsts.DB
 	if dbExists, _ := client.DatabaseExists(context.Background(), dbName); dbExists {
-		log.Println("db exists already and will be dropped first...")
 		db, err := client.Database(context.Background(), dbName)
 		if err != nil {
 			log.Fatalf("Failed to open existing database: %v\n", err)
 		}
-		// forcibly drop the database for a new checker
-		db.Remove(context.Background())
+		marker, owned, err := readMarker(db)
+		if err != nil {
+			log.Fatalf("Database %s: %v\n", dbName, err)
+		}
+		if !owned {
+			log.Fatalf("Database %s exists but is not owned by the checker (no %s marker). Refusing to drop it.\n",
+				dbName, MarkerCollection)
+		}
+		if dbConsts.RunID != "" && marker.RunID == dbConsts.RunID {
+			return db
+		}
+		if !dbConsts.Overwrite {
+			log.Fatalf("Database %s was created by run %s. Set Overwrite to drop it or use PerRun for a fresh database.\n",
+				dbName, marker.RunID)
+		}
+		log.Printf("db %s of run %s exists already and will be dropped first...\n", dbName, marker.RunID)
+		if err := db.Remove(context.Background()); err != nil {
+			log.Fatalf("Failed to drop database %s: %v\n", dbName, err)
+		}
 	}
 
 	db, err := client.CreateDatabase(context.Background(), dbName, nil)
 	if err != nil {
 		log.Fatalf("Failed to create database: %v\n", err)
 	}
+	writeMarker(db, dbConsts.RunID)
 	return db
 }
 
@@ -111,7 +126,7 @@ func evtKey(i int, j int) string {
 returns db and graph
 */
 func createGraph(client driver.Client, dbConsts DBConsts) (driver.Database, driver.Graph, driver.Graph) {
-	db := getOrCreateDB(client, dbConsts.DB)
+	db := getOrCreateDB(client, dbConsts)
 
 	txnDepEdgeDef := driver.EdgeDefinition{
 		Collection: dbConsts.TxnDepEdge,
@@ -195,9 +210,9 @@ func createGraph(client driver.Client, dbConsts DBConsts) (driver.Database, driv
 }
 
 /*
-create nodes: txns, appendEvts & readEvts
+collect nodes from the ok history: txns, appendEvts & readEvts
 */
-func createNodes(txnGraph driver.Graph, evtGraph driver.Graph, okHistory core.History, dbConsts DBConsts) []int {
+func collectNodes(okHistory core.History) ([]TxnNode, []int, []AppendEvt, []ReadEvt) {
 	txns := make([]TxnNode, 0, len(okHistory))
 	txnIds := make([]int, 0, len(okHistory))
 	// init by assuming each txn has one append, two reads on avg
@@ -243,6 +258,15 @@ func createNodes(txnGraph driver.Graph, evtGraph driver.Graph, okHistory core.Hi
 		}
 	}
 
+	return txns, txnIds, appendEvts, readEvts
+}
+
+/*
+create nodes: txns, appendEvts & readEvts
+*/
+func createNodes(txnGraph driver.Graph, evtGraph driver.Graph, okHistory core.History, dbConsts DBConsts) []int {
+	txns, txnIds, appendEvts, readEvts := collectNodes(okHistory)
+
 	txnNodes, err := txnGraph.VertexCollection(context.Background(), dbConsts.TxnNode)
 	if err != nil {
 		log.Fatalf("Failed to get node collection: %v\n", err)
@@ -303,8 +327,8 @@ FOR e1 IN r_evt
 	)}
 */
 func queryReadEvts(db driver.Database, dbConsts DBConsts) (arr []ReadEvtsInfo) {
-	query := fmt.Sprintf(`
-		FOR e1 IN %s
+	query := `
+		FOR e1 IN @@evts
 			COLLECT obj = e1.obj INTO objs
 			RETURN { obj, traces: (
 				FOR e2 in objs[*].e1
@@ -312,9 +336,10 @@ func queryReadEvts(db driver.Database, dbConsts DBConsts) (arr []ReadEvtsInfo) {
 					SORT LENGTH(val) DESC
 					RETURN { val, ids: vals[*].e2._id }
 			)}
-	`, dbConsts.ReadEvtNode)
+	`
 
-	cursor, err := db.Query(context.Background(), query, nil)
+	bindVars := map[string]interface{}{"@evts": dbConsts.ReadEvtNode}
+	cursor, err := db.Query(context.Background(), query, bindVars)
 
 	if err != nil {
 		log.Fatalf("Query failed: %v\n", err)
@@ -352,17 +377,18 @@ type AppendEvtsElement struct {
 returns an append map {obj1: {key1: id1, key2: id2, ...}, ...}
 */
 func queryAppendEvts(db driver.Database, dbConsts DBConsts) (map[string]map[int]string, map[string]map[int]bool) {
-	query := fmt.Sprintf(`
-		FOR e1 IN %s
+	query := `
+		FOR e1 IN @@evts
 			COLLECT obj = e1.obj into objs
 			RETURN { obj, evts: (
 				FOR e2 in objs[*].e1
 					COLLECT element = e2.arg INTO elements
 					RETURN { element, ids: elements[*].e2._id, append_idx: elements[*].e2.index }
 			)}
-	`, dbConsts.AppendEvtNode)
+	`
 
-	cursor, err := db.Query(context.Background(), query, nil)
+	bindVars := map[string]interface{}{"@evts": dbConsts.AppendEvtNode}
+	cursor, err := db.Query(context.Background(), query, bindVars)
 
 	if err != nil {
 		log.Fatalf("Query failed: %v\n", err)
@@ -449,7 +475,18 @@ func happensBefore(id1 string, id2 string) bool {
 func getEvtDepEdges(db driver.Database, dbConsts DBConsts) ([]EvtDepEdge, G1Anomalies) {
 	readEvtsInfoArr := queryReadEvts(db, dbConsts)
 	appendMap, itmdMap := queryAppendEvts(db, dbConsts)
+	evtDepEdges, g1, err := deriveEvtDepEdges(readEvtsInfoArr, appendMap, itmdMap)
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	return evtDepEdges, g1
+}
 
+/*
+derives the evt dependency edges from the grouped read events and the append map,
+it fails if the reads of an object are not prefixes of each other (non-traceable)
+*/
+func deriveEvtDepEdges(readEvtsInfoArr []ReadEvtsInfo, appendMap map[string]map[int]string, itmdMap map[string]map[int]bool) ([]EvtDepEdge, G1Anomalies, error) {
 	evtDepEdges := make([]EvtDepEdge, 0, len(readEvtsInfoArr)*3)
 	evtDepEdgeId := 0
 
@@ -687,7 +724,7 @@ func getEvtDepEdges(db driver.Database, dbConsts DBConsts) ([]EvtDepEdge, G1Anom
 			} else {
 				// once a value is appended, it cannot be removed
 				// this case violates the non-traceable property
-				log.Fatalf("Anomaly 2: %v read by events %v is not a prefix of %v read by events %v (inconsistent read events under object %v). Non-traceable.\n",
+				return nil, g1, fmt.Errorf("Anomaly 2: %v read by events %v is not a prefix of %v read by events %v (inconsistent read events under object %v). Non-traceable.",
 					val, ridArr, longerVal, longerRidArr, obj)
 			}
 		}
@@ -716,7 +753,7 @@ func getEvtDepEdges(db driver.Database, dbConsts DBConsts) ([]EvtDepEdge, G1Anom
 		}
 	}
 
-	return evtDepEdges, g1
+	return evtDepEdges, g1, nil
 }
 
 func isPrefix(v1 []int, v2 []int) bool {
@@ -738,35 +775,39 @@ type TxnDepEdge struct {
 	To      string `json:"_to"`
 	FromEvt string `json:"from_evt"`
 	ToEvt   string `json:"to_evt"`
+	Obj     string `json:"obj"`
 	Type    string `json:"type"`
 }
 
 func getTxnDepEdges(db driver.Database, evtDepEdges []EvtDepEdge, dbConst DBConsts) []TxnDepEdge {
-	query := fmt.Sprintf(`
+	query := `
 		LET projs = (
-			FOR d IN %s
+			FOR d IN @@deps
 				LET from_txn = SPLIT(d._from, ["/", ","])[1]
 				LET to_txn = SPLIT(d._to, ["/", ","])[1]
 				FILTER from_txn != to_txn
-				RETURN { _from: CONCAT("txn/", from_txn), _to: CONCAT("txn/", to_txn),
-					from_evt: d._from, to_evt: d._to, type: d.type }
+				RETURN { _from: CONCAT(@txn, "/", from_txn), _to: CONCAT(@txn, "/", to_txn),
+					from_evt: d._from, to_evt: d._to, obj: d.obj, type: d.type }
 			)
 	
 		FOR proj IN projs
 			COLLECT from = proj._from, to = proj._to, type = proj.type INTO groups = {
 				"from_evt": proj.from_evt,
-				"to_evt": proj.to_evt
+				"to_evt": proj.to_evt,
+				"obj": proj.obj
 			}
 			RETURN {
 				"_from": from,
 				"_to": to,
 				"type": type,
 				"from_evt": groups[0].from_evt,
-				"to_evt": groups[0].to_evt
+				"to_evt": groups[0].to_evt,
+				"obj": groups[0].obj
 			}
-	`, dbConst.EvtDepEdge)
+	`
 
-	cursor, err := db.Query(context.Background(), query, nil)
+	bindVars := map[string]interface{}{"txn": dbConst.TxnNode, "@deps": dbConst.EvtDepEdge}
+	cursor, err := db.Query(context.Background(), query, bindVars)
 
 	if err != nil {
 		log.Fatalf("Query failed: %v\n", err)
@@ -807,8 +848,10 @@ func addDepEdges(db driver.Database, txnGraph driver.Graph, evtGraph driver.Grap
 	}
 
 	// projections from evts to txns
-	txnDepEdges := getTxnDepEdges(db, evtDepEdges, dbConsts)
+	addTxnDepEdges(txnGraph, dbConsts, getTxnDepEdges(db, evtDepEdges, dbConsts))
+}
 
+func addTxnDepEdges(txnGraph driver.Graph, dbConsts DBConsts, txnDepEdges []TxnDepEdge) {
 	txnDepEdgeCol, _, err := txnGraph.EdgeCollection(context.Background(), dbConsts.TxnDepEdge)
 	if err != nil {
 		log.Fatalf("Failed to get edge collection: %v\n", err)
@@ -7,27 +7,29 @@ import (
 	"os"
 	"strconv"
 	"testing"
-	"time"
 
 	driver "github.com/arangodb/go-driver"
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/bench"
 	"github.com/stretchr/testify/require"
 )
 
-func TestCheckExample(t *testing.T) {
-	dbConsts := DBConsts{
-		"starter",    // Host
-		8529,         // Port
-		"checker_db", // DB
-		"txn_g",      // TxnGraph
-		"evt_g",      // EvtGraph
-		"txn",        // TxnNode
-		"a_evt",      // AppendEvtNode
-		"r_evt",      // ReadEvtNode
-		"dep",        // TxnDepEdge
-		"evt_dep",    // EvtDepEdge
+/*
+the default constants, overridden by $GRAIL_CONFIG and the GRAIL_* environment variables;
+tests drop and recreate the checker database
+*/
+func testDBConsts() DBConsts {
+	dbConsts, err := LoadDBConsts("")
+	if err != nil {
+		log.Fatalf("Cannot load the checker config: %v", err)
 	}
+	dbConsts.Overwrite = true
+	return dbConsts
+}
+
+func TestCheckExample(t *testing.T) {
+	dbConsts := testDBConsts()
 	ednFileName := "../histories/collection-time/10.edn"
 	prompt := fmt.Sprintf("Checking %s...", ednFileName)
 	log.Println(prompt)
@@ -41,13 +43,9 @@ func TestCheckExample(t *testing.T) {
 		log.Fatalf("Cannot parse edn file %s", ednFileName)
 		t.Fail()
 	}
-	t1 := time.Now()
 	db, txnIds, g1 := ConstructGraph(txn.Opts{}, history, dbConsts)
 	require.Equal(t, g1.G1a, false)
 	require.Equal(t, g1.G1b, false)
-	t2 := time.Now()
-	constructTime := t2.Sub(t1).Nanoseconds() / 1e6
-	fmt.Printf("constructing graph: %d ms\n", constructTime)
 
 	{
 		valid, cycle := IsolationLevelChecker(db, dbConsts, txnIds, true, "ser", "sp")
@@ -101,18 +99,7 @@ func TestCheckExample(t *testing.T) {
 }
 
 func constructArangoGraph(fileName string, t *testing.T) (driver.Database, []int, DBConsts, core.History) {
-	dbConsts := DBConsts{
-		"starter",    // Host
-		8529,         // Port
-		"checker_db", // DB
-		"txn_g",      // TxnGraph
-		"evt_g",      // EvtGraph
-		"txn",        // TxnNode
-		"a_evt",      // AppendEvtNode
-		"r_evt",      // ReadEvtNode
-		"dep",        // TxnDepEdge
-		"evt_dep",    // EvtDepEdge
-	}
+	dbConsts := testDBConsts()
 	ednFileName := fmt.Sprintf("../histories/collection-time/%s.edn", fileName)
 	prompt := fmt.Sprintf("Checking %s...", ednFileName)
 	log.Println(prompt)
@@ -126,13 +113,9 @@ func constructArangoGraph(fileName string, t *testing.T) (driver.Database, []int
 		log.Fatalf("Cannot parse edn file %s", ednFileName)
 		t.Fail()
 	}
-	t1 := time.Now()
 	db, txnIds, g1 := ConstructGraph(txn.Opts{}, history, dbConsts)
 	require.Equal(t, g1.G1a, false)
 	require.Equal(t, g1.G1b, false)
-	t2 := time.Now()
-	constructTime := t2.Sub(t1).Nanoseconds() / 1e6
-	fmt.Printf("constructing graph: %d ms\n", constructTime)
 
 	return db, txnIds, dbConsts, history
 }
@@ -146,50 +129,16 @@ func mustParseOp(opString string) core.Op {
 }
 
 func TestProfilingScalability(t *testing.T) {
-	var runtime [][]int64
+	dbConsts := testDBConsts()
+	levels := []string{"ser", "si", "psi", "pl-2", "pl-1"}
+	modes := []string{"sv", "sv-filter", "sp", "pregel"}
+	var records []bench.Record
 	for d := 10; d <= 200; d += 10 {
-		db, txnIds, dbConsts, _ := constructArangoGraph(strconv.Itoa(d), t)
-		var cur []int64
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckSERSV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckSERSVFilter, false)
-			t3 := Profile(db, dbConsts, txnIds, CheckSERSP, false)
-			tp := Profile(db, dbConsts, nil, CheckSERPregel, false)
-			cur = append(cur, t1, t2, t3, tp)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckSISV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckSISP, false)
-			cur = append(cur, t1, t2)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckPSISV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckPSISP, false)
-			cur = append(cur, t1, t2)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckPL2SV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckPL2SP, false)
-			cur = append(cur, t1, t2)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckPL1SV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckPL1SP, false)
-			cur = append(cur, t1, t2)
-		}
-		runtime = append(runtime, cur)
-	}
-	for _, row := range runtime {
-		for _, col := range row {
-			fmt.Print(col)
-			fmt.Print("\t")
-		}
-		fmt.Println()
+		content, err := os.ReadFile(fmt.Sprintf("../histories/collection-time/%d.edn", d))
+		require.NoError(t, err)
+		records = append(records, Bench(fmt.Sprintf("%d.edn", d), string(content), dbConsts, levels, modes, bench.DefaultOptions())...)
 	}
+	require.NoError(t, bench.WriteCSV(os.Stdout, records))
 }
 
 func TestCountCycles(t *testing.T) {
@@ -351,7 +300,7 @@ func testSER(t *testing.T, h core.History, db driver.Database, dbConsts DBConsts
 }
 
 func TestChecker(t *testing.T) {
-	dbConsts := DBConsts{"starter", 8529, "checker_db", "txn_g", "evt_g", "txn", "a_evt", "r_evt", "dep", "evt_dep"}
+	dbConsts := testDBConsts()
 
 	{
 		// G0 (write cycles) ~ violates PL-1
@@ -514,7 +463,7 @@ func TestChecker(t *testing.T) {
 G1a aborted read
 */
 func TestG1aCases(t *testing.T) {
-	dbConsts := DBConsts{"starter", 8529, "checker_db", "txn_g", "evt_g", "txn", "a_evt", "r_evt", "dep", "evt_dep"}
+	dbConsts := testDBConsts()
 
 	t1 := mustParseOp(`{:type :fail, :value [[:append x 1]]}`)
 	t2 := mustParseOp(`{:type :ok, :value [[:r x [1]] [:append x 2]]}`)
@@ -531,7 +480,7 @@ func TestG1aCases(t *testing.T) {
 G1b intermediate read
 */
 func TestG1bCases(t *testing.T) {
-	dbConsts := DBConsts{"starter", 8529, "checker_db", "txn_g", "evt_g", "txn", "a_evt", "r_evt", "dep", "evt_dep"}
+	dbConsts := testDBConsts()
 
 	h := []core.Op{
 		mustParseOp(`{:type :ok, :value [[:append x 1] [:append x 2]]}`),
@@ -601,3 +550,39 @@ func TestG1bCases(t *testing.T) {
 	require.Equal(t, g1.G1b, false) // G1b not detected
 
 }
+
+func TestPerRun(t *testing.T) {
+	dbConsts := DefaultDBConsts()
+	run := dbConsts.PerRun()
+	require.NotEmpty(t, run.RunID)
+	require.Equal(t, "checker_db_"+run.RunID, run.DB)
+	require.False(t, run.Overwrite)
+	// the original consts are left untouched
+	require.Equal(t, "checker_db", dbConsts.DB)
+}
+
+func TestHistoryNamespace(t *testing.T) {
+	require.Equal(t, "h_10", historyNamespace("../histories/collection-time/10.edn"))
+	require.Equal(t, "h_list_append_1_4f1247fd", historyNamespace("list-append.1.edn"))
+	// names that map to the same identifier keep their own collections
+	require.Equal(t, "h_a_b", historyNamespace("a_b.edn"))
+	require.Equal(t, "h_a_b_2a89df63", historyNamespace("a-b.edn"))
+	require.Equal(t, "h_a_b_108bf50c", historyNamespace("a.b.edn"))
+
+	ns := DefaultDBConsts().Namespaced("h_10")
+	require.Equal(t, "h_10_txn", ns.TxnNode)
+	require.Equal(t, "h_10_txn_g", ns.TxnGraph)
+	require.Equal(t, "checker_db", ns.DB)
+}
+
+// go test -v -timeout 600s -run ^TestCheckDirectory$ github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append
+func TestCheckDirectory(t *testing.T) {
+	dbConsts := testDBConsts()
+	results := CheckDirectory("../histories/collection-time", dbConsts, BatchOpts{
+		Levels:     []string{"ser", "si", "psi"},
+		Mode:       "sv",
+		Workers:    4,
+		MaxQueries: 2,
+	})
+	require.NoError(t, WriteBatchCSV(results, os.Stdout))
+}
@@ -0,0 +1,57 @@
+package listappend
+
+import (
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongForks(t *testing.T) {
+	history := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:append x 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x [1]] [:r y nil]]}`),
+		mustParseOp(`{:type :ok, :value [[:append y 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x nil] [:r y [1]]]}`),
+	}
+	g := BuildDepGraph(history, DefaultDBConsts())
+	forks := LongForks(LongForkEdges(g.EvtDepEdges, g.DBConsts))
+	require.Len(t, forks, 1)
+	require.Equal(t, "long fork T0 (wr) T1 (rw) T2 (wr) T3 (rw) T0 on x, y", forks[0].String())
+	require.Equal(t, []string{
+		"T0 wrote key x and T2 key y concurrently, T1 observed T0 but not T2, T3 observed T2 but not T0",
+		"T0 appended 1 to key x, T1 read [1] of x",
+		"T1 read y before 1 was appended by T2",
+		"T2 appended 1 to key y, T3 read [1] of y",
+		"T3 read x before 1 was appended by T0",
+	}, g.EvtDocs().ExplainLongFork(forks[0]))
+
+	// the witness is the same in the SQLite backend, and PSI allows it
+	db, txnIds, _ := constructSQLite(t, history)
+	require.Equal(t, forks, CheckLongForksSQLite(db, g.DBConsts, false))
+	require.Equal(t, forks, g.CheckLongForks(false))
+	testSQLite(t, db, txnIds, "psi", true)
+	testSQLite(t, db, txnIds, "si", false)
+
+	// once one reader sees both writes, the order is no longer forked
+	history[3] = mustParseOp(`{:type :ok, :value [[:r x [1]] [:r y [1]]]}`)
+	g = BuildDepGraph(history, DefaultDBConsts())
+	require.Empty(t, LongForks(LongForkEdges(g.EvtDepEdges, g.DBConsts)))
+}
+
+func TestLongForksTwoKeys(t *testing.T) {
+	// T3 depends on T0 by both x and a, the txn edge T3 -rw-> T0 keeps only one of them
+	history := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:append x 1] [:append a 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x [1]] [:r y nil]]}`),
+		mustParseOp(`{:type :ok, :value [[:append y 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r y [1]] [:r a nil] [:r x nil]]}`),
+	}
+	g := BuildDepGraph(history, DefaultDBConsts())
+	forks := LongForks(LongForkEdges(g.EvtDepEdges, g.DBConsts))
+	require.Len(t, forks, 1)
+	require.Equal(t, "long fork T0 (wr) T1 (rw) T2 (wr) T3 (rw) T0 on x, y", forks[0].String())
+
+	db, _, _ := constructSQLite(t, history)
+	require.Equal(t, forks, CheckLongForksSQLite(db, g.DBConsts, false))
+}
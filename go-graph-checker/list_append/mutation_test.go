@@ -0,0 +1,32 @@
+package listappend
+
+import (
+	"os"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/mutation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutationKillRates(t *testing.T) {
+	content, err := os.ReadFile("../histories/collection-time/10.edn")
+	require.NoError(t, err)
+	history, err := core.ParseHistory(string(content))
+	require.NoError(t, err)
+
+	checkers := map[string]mutation.Checker{
+		"grail-sqlite": MutationCheckerSQLite("sv", DefaultDBConsts()),
+		"go-elle":      mutation.ElleListAppend,
+	}
+	report, err := mutation.Run("10.edn", history, checkers, mutation.Options{Mutants: 5, Seed: 1})
+	require.NoError(t, err)
+	require.Len(t, report.Scores, 2*len(mutation.Operators))
+	for _, s := range report.Scores {
+		require.Equal(t, 5, s.Mutants, s)
+		if s.Checker == "grail-sqlite" {
+			// non-traceable, G1a or new cycles
+			require.Equal(t, 1.0, s.Rate, s)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package listappend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/mvcc"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMVCCGuarantees(t *testing.T) {
+	for _, isolation := range mvcc.Isolations {
+		guaranteed := make(map[string]bool)
+		for _, level := range mvcc.Guarantees[isolation] {
+			guaranteed[level] = true
+		}
+		violated := make(map[string]bool)
+		untraceable := 0
+		for seed := int64(1); seed <= 20; seed++ {
+			opts := mvcc.DefaultOpts(isolation)
+			opts.Txns, opts.Seed = 30, seed
+			r, err := mvcc.Run(opts)
+			require.NoError(t, err)
+
+			var edn strings.Builder
+			for _, op := range r.History {
+				edn.WriteString(op.String() + "\n")
+			}
+			history, err := core.ParseHistory(edn.String())
+			require.NoError(t, err)
+
+			// read uncommitted rolls dirty appends back, other txns may have read them: the lists read
+			// are then no prefixes of each other, GRAIL cannot order the appends of such a history
+			if _, err := TryBuildDepGraph(history, DefaultDBConsts()); err != nil {
+				require.Equal(t, mvcc.ReadUncommitted, isolation, "seed %d: %v", seed, err)
+				require.Contains(t, err.Error(), "Non-traceable")
+				untraceable++
+				continue
+			}
+			verdicts := DiffCheckerSQLite("sv", DefaultDBConsts())(history)
+			for _, level := range differential.Levels {
+				if guaranteed[level] {
+					require.True(t, verdicts[level].Valid, "%s seed %d %s: %s", isolation, seed, level, verdicts[level].Witness)
+				} else if !verdicts[level].Valid {
+					violated[level] = true
+				}
+			}
+		}
+		require.Less(t, untraceable, 20, isolation)
+		// exactly: every level the isolation does not guarantee is violated by some history
+		for _, level := range differential.Levels {
+			require.Equal(t, !guaranteed[level], violated[level], "%s %s", isolation, level)
+		}
+	}
+}
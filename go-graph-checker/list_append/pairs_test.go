@@ -0,0 +1,51 @@
+package listappend
+
+import (
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairCases(t *testing.T) {
+	history := []core.Op{
+		// T0 and T1 both read x before appending to it, T1's append is lost
+		mustParseOp(`{:type :ok, :value [[:r x nil] [:append x 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x nil] [:append x 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x [1 2]]]}`),
+		// T3 and T4 each append to the key the other read, a write skew
+		mustParseOp(`{:type :ok, :value [[:r y nil] [:append z 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r z nil] [:append y 1]]}`),
+		// T5 and T6 each read a key before the other appended to it, but T6 also overwrote T5 on w:
+		// neither a write skew nor a lost update, whose rw and ww edges are on the same key
+		mustParseOp(`{:type :ok, :value [[:append u 1] [:r v nil] [:append w 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r u nil] [:append v 1] [:append w 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r w [1 2]]]}`),
+		// T8 read a and b before T9 appended to them, then appended to b after T9: the lost update
+		// is on b only
+		mustParseOp(`{:type :ok, :value [[:r a nil] [:r b nil] [:append b 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:append a 1] [:append b 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r b [1 2]]]}`),
+	}
+	g := BuildDepGraph(history, DefaultDBConsts())
+	cases := PairCases(PairEdges(g.EvtDepEdges, g.DBConsts))
+	var names []string
+	for _, c := range cases {
+		names = append(names, c.String())
+	}
+	require.Equal(t, []string{
+		"lost-update T1 (rw) T0 (ww) T1 on x",
+		"lost-update T8 (rw) T9 (ww) T8 on b",
+		"write-skew T3 (rw) T4 (rw) T3 on y, z",
+	}, names)
+
+	db, _, _ := constructSQLite(t, history)
+	sqliteCases, err := QuerySQLitePairCases(db, g.DBConsts)
+	require.NoError(t, err)
+	require.Equal(t, cases, sqliteCases)
+
+	reports := ReportPairCases(append(cases, cases...), 1)
+	require.Len(t, reports, 2)
+	require.Equal(t, PairReport{Type: LostUpdate, Count: 4, Keys: []string{"b", "x"}, Examples: cases[:1]}, reports[0])
+	require.Equal(t, PairReport{Type: WriteSkew, Count: 2, Keys: []string{"y", "z"}, Examples: cases[2:]}, reports[1])
+}
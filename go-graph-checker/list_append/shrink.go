@@ -0,0 +1,47 @@
+package listappend
+
+import (
+	"log"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/shrink"
+)
+
+func g1Names(g1 G1Anomalies) []string {
+	var names []string
+	if g1.G1a {
+		names = append(names, "G1a")
+	}
+	if g1.G1b {
+		names = append(names, "G1b")
+	}
+	return names
+}
+
+/*
+ShrinkOracle checks the candidate histories of the shrinker against a level, offline
+with the SQLite backend in the given mode (sv or all); the candidates that cannot be traced do not reproduce
+*/
+func ShrinkOracle(level string, mode string, dbConsts DBConsts) shrink.Oracle {
+	spec, ok := LookupLevel(level)
+	if !ok {
+		log.Fatalf("invalid level: %s, not from any of the registered levels\n", level)
+	}
+	return func(history core.History) (shrink.Violation, bool) {
+		// a candidate GRAIL cannot trace does not reproduce the violation
+		g, err := TryBuildDepGraph(history, dbConsts)
+		if err != nil {
+			return shrink.Violation{}, false
+		}
+		db, err := LoadSQLite(g, ":memory:")
+		if err != nil {
+			log.Fatalf("Failed to load SQLite database: %v\n", err)
+		}
+		defer db.Close()
+		valid, cycle := CheckLevelSQLite(db, g.TxnIds, false, spec, mode)
+		if valid {
+			return shrink.Violation{}, false
+		}
+		return shrink.NewViolation(level, exportEdges(cycle), g1Names(g.G1)), true
+	}
+}
@@ -0,0 +1,63 @@
+package listappend
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/shrink"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShrinkOracle(t *testing.T) {
+	// G-single buried in unrelated txns and micro-ops
+	history := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:append 5 1] [:r 6 []]]}`),
+		mustParseOp(`{:type :ok, :value [[:append 1 1] [:append 2 1] [:append 7 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r 5 [1]] [:append 6 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:append 1 2] [:r 7 [1]] [:append 2 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r 6 [1]] [:append 5 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r 1 [1 2]] [:r 8 []] [:r 2 [1]]]}`),
+		mustParseOp(`{:type :ok, :value [[:r 5 [1 2]]]}`),
+	}
+	oracle := ShrinkOracle("si", "sv", DefaultDBConsts())
+	r, err := shrink.Shrink(history, oracle, shrink.Options{})
+	require.NoError(t, err)
+	require.Equal(t, "G-single", r.Violation.Class)
+	require.Empty(t, r.Violation.G1)
+
+	var buf bytes.Buffer
+	require.NoError(t, shrink.WriteEDN(&buf, r.History))
+	require.Equal(t, `{:type :ok, :value [[:append 1 1] [:append 2 1]], :index 0}
+{:type :ok, :value [[:append 1 2] [:append 2 2]], :index 1}
+{:type :ok, :value [[:r 1 [1 2]] [:r 2 [1]]], :index 2}
+`, buf.String())
+
+	// the emitted history reproduces the violation
+	minimal, err := core.ParseHistory(buf.String())
+	require.NoError(t, err)
+	v, violated := oracle(minimal)
+	require.True(t, violated)
+	require.True(t, v.Same(r.Violation))
+
+	// a candidate appending the same value twice cannot be traced, it does not reproduce
+	_, violated = oracle(append(minimal, mustParseOp(`{:type :ok, :value [[:append 1 2]], :index 3}`)))
+	require.False(t, violated)
+}
+
+func TestShrinkOracleNamespaced(t *testing.T) {
+	history := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:append 1 1] [:append 2 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:append 3 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:append 1 2] [:append 2 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r 1 [1 2]] [:r 2 [1]]]}`),
+	}
+	dbConsts := DefaultDBConsts().Namespaced("nightly")
+	oracle := ShrinkOracle("si", "sv", dbConsts)
+	r, err := shrink.Shrink(history, oracle, shrink.Options{TxnNode: dbConsts.TxnNode})
+	require.NoError(t, err)
+	require.Equal(t, "G-single", r.Violation.Class)
+	require.Len(t, r.History, 3)
+	require.True(t, strings.HasPrefix(r.Violation.Cycle[0].From, "nightly_txn/"))
+}
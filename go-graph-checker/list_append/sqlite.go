@@ -0,0 +1,261 @@
+package listappend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+/*
+the SQLite backend stores the same nodes and edges as the ArangoDB collections,
+ids keep the ArangoDB form so that cycles read the same in both backends
+*/
+var sqliteSchema = []string{
+	`CREATE TABLE txn (id TEXT PRIMARY KEY)`,
+	`CREATE TABLE append_evt (id TEXT PRIMARY KEY, obj TEXT NOT NULL, arg INTEGER NOT NULL, idx INTEGER NOT NULL)`,
+	`CREATE TABLE read_evt (id TEXT PRIMARY KEY, obj TEXT NOT NULL, v TEXT NOT NULL)`,
+	`CREATE TABLE evt_dep (from_evt TEXT NOT NULL, to_evt TEXT NOT NULL, obj TEXT NOT NULL, type TEXT NOT NULL)`,
+	`CREATE TABLE txn_dep (id INTEGER PRIMARY KEY, from_txn TEXT NOT NULL, to_txn TEXT NOT NULL,
+		from_evt TEXT NOT NULL, to_evt TEXT NOT NULL, obj TEXT NOT NULL, type TEXT NOT NULL)`,
+	`CREATE INDEX txn_dep_from ON txn_dep (from_txn)`,
+}
+
+/*
+LoadSQLite creates the tables in a new SQLite database at path (":memory:" for an in-memory one)
+and inserts the nodes and edges of the graph; an existing checker database is not overwritten
+*/
+func LoadSQLite(g DepGraph, path string) (*sql.DB, error) {
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := loadSQLite(db, g); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// every connection to :memory: opens a database of its own
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+func loadSQLite(db *sql.DB, g DepGraph) error {
+	return inSQLiteTx(db, func(tx *sql.Tx) error {
+		if err := createSQLiteSchema(tx); err != nil {
+			return err
+		}
+		if err := insertSQLiteNodes(tx, g); err != nil {
+			return err
+		}
+		return insertSQLiteEdges(tx, g)
+	})
+}
+
+func inSQLiteTx(db *sql.DB, f func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func createSQLiteSchema(tx *sql.Tx) error {
+	for _, stmt := range sqliteSchema {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create schema: %v", err)
+		}
+	}
+	return nil
+}
+
+func insertSQLiteRows(tx *sql.Tx, query string, rows int, args func(i int) []interface{}) error {
+	stmt, err := tx.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for i := 0; i < rows; i++ {
+		if _, err := stmt.Exec(args(i)...); err != nil {
+			return fmt.Errorf("failed to insert: %v", err)
+		}
+	}
+	return nil
+}
+
+/*
+inserts the txn and evt nodes
+*/
+func insertSQLiteNodes(tx *sql.Tx, g DepGraph) error {
+	dbConsts := g.DBConsts
+	if err := insertSQLiteRows(tx, `INSERT INTO txn (id) VALUES (?)`, len(g.Txns), func(i int) []interface{} {
+		return []interface{}{docId(dbConsts.TxnNode, g.Txns[i].Key)}
+	}); err != nil {
+		return err
+	}
+	if err := insertSQLiteRows(tx, `INSERT INTO append_evt (id, obj, arg, idx) VALUES (?, ?, ?, ?)`, len(g.AppendEvts), func(i int) []interface{} {
+		e := g.AppendEvts[i]
+		return []interface{}{docId(dbConsts.AppendEvtNode, e.Key), e.Obj, e.Arg, e.Index}
+	}); err != nil {
+		return err
+	}
+	return insertSQLiteRows(tx, `INSERT INTO read_evt (id, obj, v) VALUES (?, ?, ?)`, len(g.ReadEvts), func(i int) []interface{} {
+		e := g.ReadEvts[i]
+		v, _ := json.Marshal(e.V)
+		return []interface{}{docId(dbConsts.ReadEvtNode, e.Key), e.Obj, string(v)}
+	})
+}
+
+/*
+inserts the evt and txn dependency edges
+*/
+func insertSQLiteEdges(tx *sql.Tx, g DepGraph) error {
+	if err := insertSQLiteRows(tx, `INSERT INTO evt_dep (from_evt, to_evt, obj, type) VALUES (?, ?, ?, ?)`, len(g.EvtDepEdges), func(i int) []interface{} {
+		e := g.EvtDepEdges[i]
+		return []interface{}{e.From, e.To, e.Obj, e.Type}
+	}); err != nil {
+		return err
+	}
+	return insertSQLiteRows(tx, `INSERT INTO txn_dep (from_txn, to_txn, from_evt, to_evt, obj, type) VALUES (?, ?, ?, ?, ?, ?)`, len(g.TxnDepEdges), func(i int) []interface{} {
+		e := g.TxnDepEdges[i]
+		return []interface{}{e.From, e.To, e.FromEvt, e.ToEvt, e.Obj, e.Type}
+	})
+}
+
+/*
+ConstructSQLite is the SQLite counterpart of ConstructGraph, the graph is derived in memory
+and stored in an embedded database at path, no server is involved
+*/
+func ConstructSQLite(opts txn.Opts, history core.History, dbConsts DBConsts, path string) (*sql.DB, []int, G1Anomalies) {
+	g := BuildDepGraph(history, dbConsts)
+	if opts.Graphs&txn.RealtimeGraph != 0 {
+		g = g.WithRealtime(opts, history)
+	}
+	db, err := LoadSQLite(g, path)
+	if err != nil {
+		log.Fatalf("Failed to load SQLite database %s: %v\n", path, err)
+	}
+	return db, g.TxnIds, g.G1
+}
+
+/*
+the cycle search as a recursive CTE: paths grow along txn_dep without revisiting a txn
+until they return to their start; types holds the antipattern.SQLToken of every edge,
+first the token of the first edge, edges the ids of the txn_dep rows
+
+	WITH RECURSIVE paths(start, node, depth, types, first, edges, visited) AS (
+		SELECT from_txn, to_txn, 1, ... FROM txn_dep
+		UNION ALL
+		SELECT p.start, d.to_txn, p.depth + 1, ... FROM paths p JOIN txn_dep d ON d.from_txn = p.node
+		WHERE p.node != p.start AND p.depth < :max_depth AND <d.to_txn closes or is not visited>
+	)
+	SELECT edges FROM paths WHERE node = start AND depth >= :min_depth AND <level filter> LIMIT 1
+*/
+func sqliteCycleQuery(spec LevelSpec) string {
+	edgeFilter := spec.Pattern.SQLEdgeFilter("type")
+	stepFilter := spec.Pattern.SQLEdgeFilter("d.type")
+	levelFilter := spec.Pattern.SQLFilter("types", "first")
+
+	var b strings.Builder
+	b.WriteString(`WITH RECURSIVE paths(start, node, depth, types, first, edges, visited) AS (
+	SELECT from_txn, to_txn, 1, '|' || type || '|', '|' || type || '|', '|' || id || '|', '|' || from_txn || '|' || to_txn || '|'
+	FROM txn_dep
+`)
+	if edgeFilter != "" {
+		b.WriteString("\tWHERE " + edgeFilter + "\n")
+	}
+	b.WriteString(`	UNION ALL
+	SELECT p.start, d.to_txn, p.depth + 1, p.types || '|' || d.type || '|', p.first, p.edges || d.id || '|', p.visited || d.to_txn || '|'
+	FROM paths p JOIN txn_dep d ON d.from_txn = p.node
+	WHERE p.node != p.start AND p.depth < :max_depth
+		AND (d.to_txn = p.start OR instr(p.visited, '|' || d.to_txn || '|') = 0)
+`)
+	if stepFilter != "" {
+		b.WriteString("\t\tAND " + stepFilter + "\n")
+	}
+	b.WriteString(")\nSELECT edges FROM paths\nWHERE node = start AND depth >= :min_depth")
+	if levelFilter != "" {
+		b.WriteString(" AND " + levelFilter)
+	}
+	b.WriteString("\nLIMIT 1\n")
+	return b.String()
+}
+
+func readSQLiteCycle(db *sql.DB, edges string) ([]TxnDepEdge, error) {
+	var cycle []TxnDepEdge
+	for _, idStr := range strings.Split(strings.Trim(edges, "|"), "|") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return nil, err
+		}
+		var e TxnDepEdge
+		err = db.QueryRow(`SELECT from_txn, to_txn, from_evt, to_evt, obj, type FROM txn_dep WHERE id = ?`, id).
+			Scan(&e.From, &e.To, &e.FromEvt, &e.ToEvt, &e.Obj, &e.Type)
+		if err != nil {
+			return nil, err
+		}
+		cycle = append(cycle, e)
+	}
+	return cycle, nil
+}
+
+/*
+checks the anti-pattern of a level in the SQLite backend, modes:
+  - sv: cycles of MIN_DEPTH..MAX_DEPTH_SV_SIMPLE edges, as the ArangoDB traversals
+  - all: cycles of any length, an exhaustive (exponential) search
+*/
+func CheckLevelSQLite(db *sql.DB, txnIds []int, output bool, spec LevelSpec, mode string) (bool, []TxnDepEdge) {
+	maxDepth := MAX_DEPTH_SV_SIMPLE
+	switch mode {
+	case "sv":
+	case "all":
+		maxDepth = len(txnIds)
+	default:
+		log.Fatalf("invalid mode: %s, not from any of the following:\nsv, all\n", mode)
+	}
+
+	var edges string
+	err := db.QueryRow(sqliteCycleQuery(spec), sql.Named("min_depth", MIN_DEPTH), sql.Named("max_depth", maxDepth)).Scan(&edges)
+	if err == sql.ErrNoRows {
+		return true, nil
+	} else if err != nil {
+		log.Fatalf("Failed to check %s: %v\n", spec.name(), err)
+	}
+
+	cycle, err := readSQLiteCycle(db, edges)
+	if err != nil {
+		log.Fatalf("Cannot read return values: %v\n", err)
+	}
+	reportCycle(spec, "SQLite-"+strings.ToUpper(mode), cycle, output, sqliteEvts(db))
+	return false, cycle
+}
+
+/*
+SQLiteIsolationLevelChecker is IsolationLevelChecker for the SQLite backend
+*/
+func SQLiteIsolationLevelChecker(db *sql.DB, txnIds []int, output bool, level string, mode string) (bool, []TxnDepEdge) {
+	spec, ok := LookupLevel(level)
+	if !ok {
+		log.Fatalf("invalid level: %s, not from any of the registered levels\n", level)
+		return false, []TxnDepEdge{}
+	}
+	return CheckLevelSQLite(db, txnIds, output, spec, mode)
+}
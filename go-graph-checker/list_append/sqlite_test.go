@@ -0,0 +1,188 @@
+package listappend
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/stretchr/testify/require"
+)
+
+func constructSQLite(t *testing.T, h core.History) (*sql.DB, []int, G1Anomalies) {
+	db, txnIds, g1 := ConstructSQLite(txn.Opts{}, h, DefaultDBConsts(), ":memory:")
+	t.Cleanup(func() { db.Close() })
+	return db, txnIds, g1
+}
+
+func testSQLite(t *testing.T, db *sql.DB, txnIds []int, level string, expected bool) {
+	for _, mode := range []string{"sv", "all"} {
+		valid, cycle := SQLiteIsolationLevelChecker(db, txnIds, false, level, mode)
+		require.Equal(t, expected, valid, "%s %s", level, mode)
+		if !valid {
+			require.Equal(t, cycle[0].From, cycle[len(cycle)-1].To)
+		}
+	}
+}
+
+func TestSQLiteChecker(t *testing.T) {
+	{
+		// G0 ~ violates PL-1
+		db, txnIds, _ := constructSQLite(t, []core.Op{
+			mustParseOp(`{:type :ok, :value [[:append x 1] [:append y 1]]}`),
+			mustParseOp(`{:type :ok, :value [[:append x 2] [:append y 2]]}`),
+			mustParseOp(`{:type :ok, :value [[:r x [1 2]] [:r y [2 1]]]}`),
+		})
+		testSQLite(t, db, txnIds, "pl-1", false)
+	}
+
+	{
+		// G1c ~ violates PL-2 but not PL-1
+		db, txnIds, g1 := constructSQLite(t, []core.Op{
+			mustParseOp(`{:type :ok, :value [[:append x 1] [:r y [1]]]}`),
+			mustParseOp(`{:type :ok, :value [[:append x 2] [:append y 1]]}`),
+			mustParseOp(`{:type :ok, :value [[:r x [1 2]] [:r y [1]]]}`),
+		})
+		require.Equal(t, G1Anomalies{}, g1)
+		testSQLite(t, db, txnIds, "pl-2", false)
+		testSQLite(t, db, txnIds, "pl-1", true)
+	}
+
+	{
+		// G-single ~ violates SER, SI and PSI but not PL-2
+		db, txnIds, _ := constructSQLite(t, []core.Op{
+			mustParseOp(`{:type :ok, :value [[:append 1 1] [:append 2 1]]}`),
+			mustParseOp(`{:type :ok, :value [[:append 1 2] [:append 2 2]]}`),
+			mustParseOp(`{:type :ok, :value [[:r 1 [1 2]] [:r 2 [1]]]}`),
+		})
+		testSQLite(t, db, txnIds, "pl-2", true)
+		testSQLite(t, db, txnIds, "ser", false)
+		testSQLite(t, db, txnIds, "si", false)
+		testSQLite(t, db, txnIds, "psi", false)
+	}
+
+	{
+		// write skew ~ violates SER but not SI
+		db, txnIds, _ := constructSQLite(t, []core.Op{
+			mustParseOp(`{:type :ok, :value [[:r x []] [:r y []] [:append x 1]]}`),
+			mustParseOp(`{:type :ok, :value [[:r x []] [:r y []] [:append y 1]]}`),
+		})
+		testSQLite(t, db, txnIds, "ser", false)
+		testSQLite(t, db, txnIds, "si", true)
+		testSQLite(t, db, txnIds, "psi", true)
+	}
+}
+
+func TestBuildDepGraphG1(t *testing.T) {
+	g := BuildDepGraph([]core.Op{
+		mustParseOp(`{:type :ok, :value [[:r x [1]] [:append x 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x [1 2]] [:r y [3]]]}`),
+		mustParseOp(`{:type :fail, :value [[:append x 1]]}`),
+	}, DefaultDBConsts())
+	require.True(t, g.G1.G1a)
+
+	g = BuildDepGraph([]core.Op{
+		mustParseOp(`{:type :ok, :value [[:append x 1] [:append x 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x [1]]]}`),
+	}, DefaultDBConsts())
+	require.True(t, g.G1.G1b)
+}
+
+func TestSQLiteHistoryFile(t *testing.T) {
+	content, err := os.ReadFile("../histories/collection-time/10.edn")
+	require.NoError(t, err)
+	history, err := core.ParseHistory(string(content))
+	require.NoError(t, err)
+
+	path := t.TempDir() + "/10.sqlite"
+	db, txnIds, _ := ConstructSQLite(txn.Opts{}, history, DefaultDBConsts(), path)
+	defer db.Close()
+
+	var edges int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM txn_dep`).Scan(&edges))
+	require.Greater(t, edges, 0)
+	for _, level := range []string{"ser", "si", "psi", "pl-2", "pl-1"} {
+		SQLiteIsolationLevelChecker(db, txnIds, false, level, "sv")
+	}
+
+	// the tables exist already, a second load must not touch them
+	_, err = LoadSQLite(BuildDepGraph(history, DefaultDBConsts()), path)
+	require.Error(t, err)
+}
+
+func TestSQLiteRealtime(t *testing.T) {
+	// T3 misses the append of T1, it is invoked after T1 completed by the history but 5ns before by its :time
+	h := []core.Op{
+		mustParseOp(`{:index 0, :type :invoke, :process 0, :time 0, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 1, :type :ok, :process 0, :time 100, :value [[:append x 1]]}`),
+		mustParseOp(`{:index 2, :type :invoke, :process 1, :time 95, :value [[:r x nil]]}`),
+		mustParseOp(`{:index 3, :type :ok, :process 1, :time 200, :value [[:r x []]]}`),
+	}
+	dbConsts := DefaultDBConsts()
+	require.Equal(t, []TxnDepEdge{{From: "txn/1", To: "txn/3", Type: "realtime"}},
+		RealtimeTxnDepEdges(txn.Opts{}, h, dbConsts))
+	require.Empty(t, RealtimeTxnDepEdges(txn.Opts{RealtimeEpsilon: 10}, h, dbConsts))
+
+	db, txnIds, _ := constructSQLite(t, h)
+	testSQLite(t, db, txnIds, "ser", true)
+
+	for _, tc := range []struct {
+		opts  txn.Opts
+		valid bool
+	}{
+		{txn.Opts{Graphs: txn.RealtimeGraph}, false},
+		{txn.Opts{Graphs: txn.RealtimeGraph, RealtimeEpsilon: 10}, true},
+	} {
+		db, txnIds, _ := ConstructSQLite(tc.opts, h, dbConsts, ":memory:")
+		testSQLite(t, db, txnIds, "ser", tc.valid)
+		db.Close()
+	}
+}
+
+func TestSQLiteCycleAnomaly(t *testing.T) {
+	// T1 and T2 each miss the append of the other: write skew
+	db, txnIds, _ := constructSQLite(t, []core.Op{
+		mustParseOp(`{:type :ok, :value [[:r x []] [:append y 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r y []] [:append x 1]]}`),
+	})
+	valid, cycle := SQLiteIsolationLevelChecker(db, txnIds, false, "ser", "all")
+	require.False(t, valid)
+	a := CycleAnomaly(cycle)
+	require.Equal(t, "G2-item", a.Name)
+	require.Equal(t, []string{"repeatable-read"}, a.Not)
+	require.Contains(t, a.AlsoNot, "serializable")
+}
+
+func TestExplainCycle(t *testing.T) {
+	h := []core.Op{
+		mustParseOp(`{:type :ok, :value [[:r x []] [:append y 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:r y []] [:append x 1]]}`),
+		mustParseOp(`{:type :ok, :value [[:append x 2]]}`),
+		mustParseOp(`{:type :ok, :value [[:r x [1 2]]]}`),
+	}
+	g := BuildDepGraph(h, DefaultDBConsts())
+	docs := g.EvtDocs()
+	explanations := make(map[string]bool)
+	for _, e := range g.TxnDepEdges {
+		explanations[docs.ExplainEdge(e)] = true
+	}
+	for _, expected := range []string{
+		"T0 read x before 1 was appended by T1",
+		"T1 read y before 1 was appended by T0",
+		"T1 appended 1 to key x, T2 appended 2 right after it",
+		"T2 appended 2 to key x, T3 read [..,2] of x",
+	} {
+		require.True(t, explanations[expected], "%s not in %v", expected, explanations)
+	}
+
+	// the SQLite backend explains its cycles the same way
+	db, txnIds, _ := constructSQLite(t, h)
+	valid, cycle := SQLiteIsolationLevelChecker(db, txnIds, false, "ser", "all")
+	require.False(t, valid)
+	sqliteDocs, err := QuerySQLiteEvtDocs(db, cycle)
+	require.NoError(t, err)
+	require.Equal(t, docs.ExplainCycle(cycle), sqliteDocs.ExplainCycle(cycle))
+
+	require.Equal(t, "T1 (ww) T2 on key x", EvtDocs{}.ExplainEdge(TxnDepEdge{From: "txn/1", To: "txn/2", Obj: "x", Type: "ww"}))
+}
@@ -8,6 +8,8 @@ import (
 
 	"github.com/goccy/go-graphviz"
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/report"
 )
 
 type record struct {
@@ -78,9 +80,13 @@ func renderOp(nodeMap map[core.Op]string, op core.Op) record {
 
 func renderEdge(nodeMap map[core.Op]string, e TxnDepEdge) edge {
 	a, b := getEdgeEnds(e)
-	ami, bmi := getEdgeMopIndices(e)
-	an := fmt.Sprintf("T%s:f%s", a, ami)
-	bn := fmt.Sprintf("T%s:f%s", b, bmi)
+	an, bn := fmt.Sprintf("T%s", a), fmt.Sprintf("T%s", b)
+	// realtime edges are between whole txns
+	if e.FromEvt != "" {
+		ami, bmi := getEdgeMopIndices(e)
+		an += fmt.Sprintf(":f%s", ami)
+		bn += fmt.Sprintf(":f%s", bmi)
+	}
 	return edge{
 		from:      an,
 		to:        bn,
@@ -158,3 +164,13 @@ func PlotCycle(history core.History, cycle []TxnDepEdge, directory string, filen
 
 	return nil
 }
+
+/*
+PlotCycleHTML writes a cycle as a self-contained interactive HTML page to <directory>/<filename>.html,
+with the SCC of g around the cycle (g may be empty, e.g. DepGraph.Export() for the whole graph)
+*/
+func PlotCycleHTML(history core.History, name string, cycle []TxnDepEdge, g export.Graph, directory string, filename string) error {
+	r := report.New(filename, history)
+	r.AddCycle(name, exportEdges(cycle), g)
+	return r.WriteFile(fmt.Sprintf("%s/%s.html", directory, filename))
+}
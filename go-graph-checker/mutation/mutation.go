@@ -0,0 +1,252 @@
+/*
+Package mutation measures the sensitivity of the checkers: it takes a valid list-append or rw-register history,
+applies semantic mutations to it (see Operators) and checks whether each checker flags the mutant,
+i.e. reports a level violated that held on the original history, or fails to check it at all.
+The report gives the kill rate of every operator for every checker, so that blind spots show up
+as low rates. Some mutants are equivalent to the original (e.g. swapping the reads of concurrent
+txns), no checker can kill them, the surviving mutants are listed to tell them apart.
+
+	checkers := map[string]mutation.Checker{
+		"grail":   listappend.MutationChecker("sv", listappend.DefaultDBConsts().PerRun()),
+		"go-elle": mutation.ElleListAppend,
+	}
+	report, err := mutation.Run("10.edn", history, checkers, mutation.Options{Mutants: 20})
+*/
+package mutation
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+)
+
+/*
+Checker checks a history against all differential.Levels and G1, an error means the history
+could not be checked (e.g. GRAIL cannot trace it), which kills the mutant
+*/
+type Checker func(history core.History) (differential.Verdicts, error)
+
+/*
+FromDifferential adapts a differential.Checker, turning its panics into errors
+*/
+func FromDifferential(c differential.Checker) Checker {
+	return func(history core.History) (verdicts differential.Verdicts, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("checker panicked: %v", r)
+			}
+		}()
+		return c(history), nil
+	}
+}
+
+/*
+ElleListAppend is the go-elle list-append checker
+*/
+var ElleListAppend = FromDifferential(differential.ElleListAppend)
+
+/*
+ElleRWRegister is the go-elle rw-register checker
+*/
+var ElleRWRegister = FromDifferential(differential.ElleRWRegister)
+
+/*
+Options configures a run, Operators defaults to all of them
+*/
+type Options struct {
+	// Mutants per operator
+	Mutants   int
+	Seed      int64
+	Operators []Operator
+}
+
+/*
+Score is how many mutants of an operator a checker killed
+*/
+type Score struct {
+	Operator string  `json:"operator"`
+	Checker  string  `json:"checker"`
+	Mutants  int     `json:"mutants"`
+	Killed   int     `json:"killed"`
+	Rate     float64 `json:"rate"`
+}
+
+/*
+Survivor is a mutant a checker did not flag
+*/
+type Survivor struct {
+	Operator string `json:"operator"`
+	Checker  string `json:"checker"`
+	Mutation string `json:"mutation"`
+}
+
+/*
+Report is the outcome of a run on a history, scores are sorted by operator then checker
+*/
+type Report struct {
+	History   string     `json:"history"`
+	Scores    []Score    `json:"scores"`
+	Survivors []Survivor `json:"survivors"`
+}
+
+/*
+Run mutates a history Mutants times with every operator and checks every mutant with every
+checker, the history should be valid; the levels it already violates cannot kill a mutant
+*/
+func Run(name string, history core.History, checkers map[string]Checker, opts Options) (Report, error) {
+	operators := opts.Operators
+	if len(operators) == 0 {
+		operators = Operators
+	}
+	names := make([]string, 0, len(checkers))
+	for n := range checkers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	baselines := make(map[string]differential.Verdicts, len(checkers))
+	for _, n := range names {
+		verdicts, err := checkers[n](history)
+		if err != nil {
+			return Report{}, fmt.Errorf("%s cannot check %s: %v", n, name, err)
+		}
+		baselines[n] = verdicts
+	}
+
+	rnd := rand.New(rand.NewSource(opts.Seed))
+	report := Report{History: name}
+	for _, operator := range operators {
+		scores := make(map[string]*Score, len(names))
+		for _, n := range names {
+			scores[n] = &Score{Operator: operator.Name, Checker: n}
+		}
+		for i := 0; i < opts.Mutants; i++ {
+			mutant, mutation, ok := operator.Apply(history, rnd)
+			if !ok {
+				break
+			}
+			for _, n := range names {
+				score := scores[n]
+				score.Mutants++
+				verdicts, err := checkers[n](mutant)
+				if err != nil || killed(baselines[n], verdicts) {
+					score.Killed++
+				} else {
+					report.Survivors = append(report.Survivors, Survivor{Operator: operator.Name, Checker: n, Mutation: mutation})
+				}
+			}
+		}
+		for _, n := range names {
+			score := scores[n]
+			if score.Mutants > 0 {
+				score.Rate = float64(score.Killed) / float64(score.Mutants)
+			}
+			report.Scores = append(report.Scores, *score)
+		}
+	}
+	return report, nil
+}
+
+// whether the mutant violates a level or reports an anomaly that held on the original
+func killed(baseline differential.Verdicts, verdicts differential.Verdicts) bool {
+	for name, verdict := range verdicts {
+		if original, ok := baseline[name]; !verdict.Valid && (!ok || original.Valid) {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+Merge sums the scores of reports on several histories into a single report
+*/
+func Merge(name string, reports []Report) Report {
+	merged := Report{History: name}
+	index := make(map[[2]string]int)
+	for _, r := range reports {
+		for _, s := range r.Scores {
+			key := [2]string{s.Operator, s.Checker}
+			i, ok := index[key]
+			if !ok {
+				i = len(merged.Scores)
+				index[key] = i
+				merged.Scores = append(merged.Scores, Score{Operator: s.Operator, Checker: s.Checker})
+			}
+			merged.Scores[i].Mutants += s.Mutants
+			merged.Scores[i].Killed += s.Killed
+		}
+		for _, s := range r.Survivors {
+			s.Mutation = r.History + ": " + s.Mutation
+			merged.Survivors = append(merged.Survivors, s)
+		}
+	}
+	for i := range merged.Scores {
+		if s := &merged.Scores[i]; s.Mutants > 0 {
+			s.Rate = float64(s.Killed) / float64(s.Mutants)
+		}
+	}
+	return merged
+}
+
+/*
+String renders the scores as a table, one row per operator and one column per checker
+*/
+func (r Report) String() string {
+	var checkers []string
+	rows := make(map[string]map[string]Score)
+	var operators []string
+	for _, s := range r.Scores {
+		if _, ok := rows[s.Operator]; !ok {
+			rows[s.Operator] = make(map[string]Score)
+			operators = append(operators, s.Operator)
+		}
+		if len(rows) == 1 {
+			checkers = append(checkers, s.Checker)
+		}
+		rows[s.Operator][s.Checker] = s
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-16s", "operator")
+	for _, c := range checkers {
+		fmt.Fprintf(&b, " %16s", c)
+	}
+	b.WriteString("\n")
+	for _, o := range operators {
+		fmt.Fprintf(&b, "%-16s", o)
+		for _, c := range checkers {
+			s := rows[o][c]
+			fmt.Fprintf(&b, " %16s", fmt.Sprintf("%d/%d %3.0f%%", s.Killed, s.Mutants, 100*s.Rate))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+/*
+WriteCSV writes the scores of reports as CSV, one row per history, operator and checker
+*/
+func WriteCSV(w io.Writer, reports ...Report) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"history", "operator", "checker", "mutants", "killed", "rate"}); err != nil {
+		return err
+	}
+	for _, r := range reports {
+		for _, s := range r.Scores {
+			row := []string{r.History, s.Operator, s.Checker, strconv.Itoa(s.Mutants), strconv.Itoa(s.Killed),
+				strconv.FormatFloat(s.Rate, 'f', 4, 64)}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
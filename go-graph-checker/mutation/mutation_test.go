@@ -0,0 +1,158 @@
+package mutation_test
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/mutation"
+	"github.com/stretchr/testify/require"
+)
+
+func parseHistory(t *testing.T, ops ...string) core.History {
+	var history core.History
+	for i, s := range ops {
+		op, err := core.ParseOp(s)
+		require.NoError(t, err)
+		op.Index = core.NewOptInt(i)
+		history = append(history, op)
+	}
+	return history
+}
+
+// a serial history: T0 appends x 1, T1 appends x 2, T2 reads both
+func serialHistory(t *testing.T) core.History {
+	return parseHistory(t,
+		`{:type :ok, :value [[:append x 1] [:r y [1]]]}`,
+		`{:type :ok, :value [[:r x [1]] [:append x 2]]}`,
+		`{:type :ok, :value [[:r x [1 2]] [:append y 1]]}`,
+	)
+}
+
+func TestOperators(t *testing.T) {
+	history := serialHistory(t)
+	original := fmt.Sprint(history)
+	rnd := rand.New(rand.NewSource(1))
+
+	mutant, _, ok := mutation.SwapReads.Apply(history, rnd)
+	require.True(t, ok)
+	require.Contains(t, mutant[1].String(), "[:r x [1 2]]")
+	require.Contains(t, mutant[2].String(), "[:r x [1]]")
+
+	mutant, _, ok = mutation.DropAppend.Apply(history, rnd)
+	require.True(t, ok)
+	require.Contains(t, mutant[2].String(), "[:r x [2]]")
+
+	mutant, _, ok = mutation.ReorderList.Apply(history, rnd)
+	require.True(t, ok)
+	require.Contains(t, mutant[2].String(), "[:r x [2 1]]")
+
+	mutant, _, ok = mutation.FlipOkFail.Apply(history, rnd)
+	require.True(t, ok)
+	require.Equal(t, 1, len(mutant.FilterType(core.OpTypeFail)))
+
+	mutant, desc, ok := mutation.DuplicateWrite.Apply(history, rnd)
+	require.True(t, ok)
+	require.Equal(t, 7, mutant[0].ValueLength()+mutant[1].ValueLength()+mutant[2].ValueLength(), desc)
+
+	// the original is left untouched
+	require.Equal(t, original, fmt.Sprint(history))
+}
+
+func TestOperatorsRegister(t *testing.T) {
+	var history core.History
+	for i, s := range []string{
+		`{:type :ok, :value [[:w x 1]]}`,
+		`{:type :ok, :value [[:r x 1] [:w x 2]]}`,
+		`{:type :ok, :value [[:r x 2]]}`,
+	} {
+		op, err := core.ParseOpRW(s)
+		require.NoError(t, err)
+		op.Index = core.NewOptInt(i)
+		history = append(history, op)
+	}
+	rnd := rand.New(rand.NewSource(1))
+
+	mutant, _, ok := mutation.SwapReads.Apply(history, rnd)
+	require.True(t, ok)
+	require.Contains(t, mutant[1].String(), "[:r x 2]")
+	require.Contains(t, mutant[2].String(), "[:r x 1]")
+
+	_, _, ok = mutation.ReorderList.Apply(history, rnd)
+	require.False(t, ok)
+
+	mutant, _, ok = mutation.FlipOkFail.Apply(history, rnd)
+	require.True(t, ok)
+	require.Equal(t, 1, len(mutant.FilterType(core.OpTypeFail)))
+
+	mutant, desc, ok := mutation.DuplicateWrite.Apply(history, rnd)
+	require.True(t, ok)
+	require.Equal(t, 5, mutant[0].ValueLength()+mutant[1].ValueLength()+mutant[2].ValueLength(), desc)
+}
+
+func TestOperatorsWithoutSites(t *testing.T) {
+	history := parseHistory(t, `{:type :ok, :value [[:append x 1]]}`)
+	for _, operator := range mutation.Operators {
+		_, _, ok := operator.Apply(history, rand.New(rand.NewSource(1)))
+		require.False(t, ok, operator.Name)
+	}
+}
+
+func TestRun(t *testing.T) {
+	// kills every mutant whose reads differ from the original ones
+	reads := func(history core.History) string {
+		var b strings.Builder
+		for _, op := range history {
+			b.WriteString(string(op.Type))
+			for _, mop := range *op.Value {
+				if mop.IsRead() {
+					b.WriteString(mop.String())
+				}
+			}
+		}
+		return b.String()
+	}
+	history := serialHistory(t)
+	valid := reads(history)
+	readChecker := func(h core.History) (differential.Verdicts, error) {
+		return differential.Verdicts{"ser": {Valid: reads(h) == valid}}, nil
+	}
+	blind := mutation.FromDifferential(func(core.History) differential.Verdicts {
+		return differential.Verdicts{"ser": {Valid: true}}
+	})
+
+	report, err := mutation.Run("serial", history, map[string]mutation.Checker{"reads": readChecker, "blind": blind},
+		mutation.Options{Mutants: 3, Seed: 1})
+	require.NoError(t, err)
+	require.Len(t, report.Scores, 2*len(mutation.Operators))
+	for _, s := range report.Scores {
+		require.Equal(t, 3, s.Mutants)
+		switch {
+		case s.Checker == "blind":
+			require.Equal(t, 0, s.Killed)
+		case s.Operator == "duplicate-write":
+			require.Equal(t, 0, s.Killed)
+		default:
+			require.Equal(t, 1.0, s.Rate, s.Operator)
+		}
+	}
+	require.Len(t, report.Survivors, 3*len(mutation.Operators)+3)
+	require.Contains(t, report.String(), "swap-reads")
+
+	merged := mutation.Merge("all", []mutation.Report{report, report})
+	require.Equal(t, 6, merged.Scores[0].Mutants)
+
+	var buf bytes.Buffer
+	require.NoError(t, mutation.WriteCSV(&buf, report))
+	require.Equal(t, 1+len(report.Scores), strings.Count(buf.String(), "\n"))
+}
+
+func TestFromDifferentialRecovers(t *testing.T) {
+	c := mutation.FromDifferential(func(core.History) differential.Verdicts { panic("boom") })
+	_, err := c(nil)
+	require.Error(t, err)
+}
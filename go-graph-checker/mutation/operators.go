@@ -0,0 +1,240 @@
+package mutation
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+/*
+Operator is a semantic mutation of a list-append or rw-register history: Apply mutates a copy of the
+history at a random site and describes the mutation, it returns false if the history has no site for it
+*/
+type Operator struct {
+	Name  string
+	Apply func(history core.History, rnd *rand.Rand) (core.History, string, bool)
+}
+
+/*
+Operators are the mutations applied by default, all of them mutate ok txns only.
+drop-append and reorder-list need lists, they find no site in rw-register histories
+*/
+var Operators = []Operator{SwapReads, DropAppend, ReorderList, FlipOkFail, DuplicateWrite}
+
+// attempts to find a site before giving up
+const maxAttempts = 100
+
+// a read of an ok txn: the positions of the op in the history and of the mop in the op;
+// a register read is a list of its single value
+type site struct {
+	op  int
+	mop int
+}
+
+/*
+SwapReads swaps the lists (or register values) two ok txns read on the same key
+*/
+var SwapReads = Operator{
+	Name: "swap-reads",
+	Apply: func(history core.History, rnd *rand.Rand) (core.History, string, bool) {
+		byKey := make(map[string][]site)
+		var keys []string
+		for _, s := range readSites(history, 0) {
+			k := mopAt(history, s).GetKey()
+			if _, ok := byKey[k]; !ok {
+				keys = append(keys, k)
+			}
+			byKey[k] = append(byKey[k], s)
+		}
+		for attempt := 0; attempt < maxAttempts && len(keys) > 0; attempt++ {
+			sites := byKey[keys[rnd.Intn(len(keys))]]
+			a, b := sites[rnd.Intn(len(sites))], sites[rnd.Intn(len(sites))]
+			va, vb := readValue(history, a), readValue(history, b)
+			if a.op == b.op || fmt.Sprint(va) == fmt.Sprint(vb) {
+				continue
+			}
+			mutant := copyHistory(history, a.op, b.op)
+			setRead(mutant, a, vb)
+			setRead(mutant, b, va)
+			return mutant, fmt.Sprintf("%s reads %v and %s reads %v, swapped", opName(history, a.op), va,
+				opName(history, b.op), vb), true
+		}
+		return nil, "", false
+	},
+}
+
+/*
+DropAppend removes a value from the middle of a read list, so that it is no longer a prefix
+of the lists read after it
+*/
+var DropAppend = Operator{
+	Name: "drop-append",
+	Apply: func(history core.History, rnd *rand.Rand) (core.History, string, bool) {
+		sites := readSites(history, 2)
+		if len(sites) == 0 {
+			return nil, "", false
+		}
+		s := sites[rnd.Intn(len(sites))]
+		v := readValue(history, s)
+		i := rnd.Intn(len(v) - 1)
+		dropped := append(append([]int{}, v[:i]...), v[i+1:]...)
+		mutant := copyHistory(history, s.op)
+		setRead(mutant, s, dropped)
+		return mutant, fmt.Sprintf("%s reads %v, %d dropped", opName(history, s.op), v, v[i]), true
+	},
+}
+
+/*
+ReorderList swaps two adjacent values of a read list
+*/
+var ReorderList = Operator{
+	Name: "reorder-list",
+	Apply: func(history core.History, rnd *rand.Rand) (core.History, string, bool) {
+		sites := readSites(history, 2)
+		if len(sites) == 0 {
+			return nil, "", false
+		}
+		s := sites[rnd.Intn(len(sites))]
+		v := readValue(history, s)
+		i := rnd.Intn(len(v) - 1)
+		reordered := append([]int{}, v...)
+		reordered[i], reordered[i+1] = reordered[i+1], reordered[i]
+		mutant := copyHistory(history, s.op)
+		setRead(mutant, s, reordered)
+		return mutant, fmt.Sprintf("%s reads %v, %d and %d swapped", opName(history, s.op), v, v[i], v[i+1]), true
+	},
+}
+
+/*
+FlipOkFail turns an ok txn whose appends or writes are read by other txns into a failed one
+*/
+var FlipOkFail = Operator{
+	Name: "flip-ok-fail",
+	Apply: func(history core.History, rnd *rand.Rand) (core.History, string, bool) {
+		readers := make(map[string]map[int]bool)
+		for _, s := range readSites(history, 1) {
+			k := mopAt(history, s).GetKey()
+			for _, v := range readValue(history, s) {
+				kv := fmt.Sprintf("%s/%d", k, v)
+				if readers[kv] == nil {
+					readers[kv] = make(map[int]bool)
+				}
+				readers[kv][s.op] = true
+			}
+		}
+		var candidates []int
+		for i, op := range history {
+			if op.Type != core.OpTypeOk || op.Value == nil {
+				continue
+			}
+		mops:
+			for _, mop := range *op.Value {
+				if !mop.IsAppend() && !mop.IsWrite() {
+					continue
+				}
+				for reader := range readers[fmt.Sprintf("%s/%d", mop.GetKey(), mop.GetValue())] {
+					if reader != i {
+						candidates = append(candidates, i)
+						break mops
+					}
+				}
+			}
+		}
+		if len(candidates) == 0 {
+			return nil, "", false
+		}
+		i := candidates[rnd.Intn(len(candidates))]
+		mutant := copyHistory(history, i)
+		mutant[i].Type = core.OpTypeFail
+		return mutant, fmt.Sprintf("%s flipped to fail", opName(history, i)), true
+	},
+}
+
+/*
+DuplicateWrite copies the append or write of an ok txn into another ok txn
+*/
+var DuplicateWrite = Operator{
+	Name: "duplicate-write",
+	Apply: func(history core.History, rnd *rand.Rand) (core.History, string, bool) {
+		var appends []site
+		var oks []int
+		for i, op := range history {
+			if op.Type != core.OpTypeOk || op.Value == nil {
+				continue
+			}
+			oks = append(oks, i)
+			for j, mop := range *op.Value {
+				if mop.IsAppend() || mop.IsWrite() {
+					appends = append(appends, site{op: i, mop: j})
+				}
+			}
+		}
+		if len(appends) == 0 || len(oks) < 2 {
+			return nil, "", false
+		}
+		s := appends[rnd.Intn(len(appends))]
+		to := oks[rnd.Intn(len(oks))]
+		for to == s.op {
+			to = oks[rnd.Intn(len(oks))]
+		}
+		mop := mopAt(history, s)
+		mutant := copyHistory(history, to)
+		*mutant[to].Value = append(*mutant[to].Value, mop.Copy())
+		return mutant, fmt.Sprintf("%s of %s duplicated into %s", mop, opName(history, s.op), opName(history, to)), true
+	},
+}
+
+// the non-nil reads of ok txns with at least min values
+func readSites(history core.History, min int) []site {
+	var sites []site
+	for i, op := range history {
+		if op.Type != core.OpTypeOk || op.Value == nil {
+			continue
+		}
+		for j, mop := range *op.Value {
+			s := site{op: i, mop: j}
+			if mop.IsRead() && mop.GetValue() != nil && len(readValue(history, s)) >= min {
+				sites = append(sites, s)
+			}
+		}
+	}
+	return sites
+}
+
+func mopAt(history core.History, s site) core.Mop {
+	return (*history[s.op].Value)[s.mop]
+}
+
+func readValue(history core.History, s site) []int {
+	if v, ok := mopAt(history, s).GetValue().(int); ok {
+		return []int{v}
+	}
+	return mopAt(history, s).GetValue().([]int)
+}
+
+func setRead(history core.History, s site, value []int) {
+	mop := mopAt(history, s)
+	if _, ok := mop.GetValue().(int); ok {
+		(*history[s.op].Value)[s.mop] = core.ReadRW(mop.GetKey(), value[0])
+		return
+	}
+	(*history[s.op].Value)[s.mop] = core.Read(mop.GetKey(), value)
+}
+
+// a shallow copy of the history with the ops at the given positions deeply copied
+func copyHistory(history core.History, positions ...int) core.History {
+	mutant := make(core.History, len(history))
+	copy(mutant, history)
+	for _, i := range positions {
+		mutant[i] = history[i].Copy()
+	}
+	return mutant
+}
+
+func opName(history core.History, i int) string {
+	if history[i].Index.Present() {
+		return fmt.Sprintf("op %d", history[i].Index.MustGet())
+	}
+	return fmt.Sprintf("op #%d", i)
+}
@@ -0,0 +1,336 @@
+/*
+Package report writes violating cycles as a self-contained, interactive HTML page:
+the cycles, the strongly connected component (SCC) around each of them, the micro-ops of every txn
+and an explanation of every edge, with pan/zoom and click-to-expand, and no external resources,
+so that the page can be attached to bug reports.
+
+	r := report.New("list-append 10.edn", history)
+	r.AddCycle("SI", cycle, depGraph.Export())
+	err := r.WriteFile("10.html")
+*/
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+)
+
+//go:embed explorer.html
+var explorerHTML string
+
+var explorerTemplate = template.Must(template.New("explorer").Parse(explorerHTML))
+
+/*
+Txn is a txn of the report with its micro-ops rendered as in the history
+*/
+type Txn struct {
+	ID      string   `json:"id"`
+	Index   int      `json:"index"`
+	Process string   `json:"process"`
+	Type    string   `json:"type"`
+	Error   string   `json:"error,omitempty"`
+	Mops    []string `json:"mops"`
+}
+
+/*
+Edge is an exported edge with the mop indexes of its evts (-1 if unknown) and a rendered explanation
+*/
+type Edge struct {
+	export.Edge
+	FromMop     int    `json:"from_mop"`
+	ToMop       int    `json:"to_mop"`
+	Explanation string `json:"explanation"`
+}
+
+/*
+Cycle is a violating cycle, Edges are in cycle order and
+Neighbourhood holds the other edges of the SCC the cycle is in
+*/
+type Cycle struct {
+	Name          string `json:"name"`
+	Edges         []Edge `json:"edges"`
+	Neighbourhood []Edge `json:"neighbourhood"`
+}
+
+/*
+Report collects the cycles of a history, Txns holds every txn any cycle or neighbourhood touches
+*/
+type Report struct {
+	Title  string         `json:"title"`
+	Txns   map[string]Txn `json:"txns"`
+	Cycles []Cycle        `json:"cycles"`
+	ops    map[int]core.Op
+}
+
+/*
+New creates an empty report of a history, ops are looked up by their index
+*/
+func New(title string, history core.History) *Report {
+	r := &Report{Title: title, Txns: make(map[string]Txn), ops: make(map[int]core.Op, len(history))}
+	for _, op := range history {
+		if op.Index.Present() {
+			r.ops[op.Index.MustGet()] = op
+		}
+	}
+	return r
+}
+
+// the op index of a txn or evt id, "txn/3" and "r_evt/3,1" -> 3
+func idIndex(id string) (int, bool) {
+	if i := strings.IndexByte(id, '/'); i >= 0 {
+		id = id[i+1:]
+	}
+	idx, err := strconv.Atoi(strings.SplitN(id, ",", 2)[0])
+	return idx, err == nil
+}
+
+// the mop index of an evt id, "r_evt/3,1" -> 1, -1 if none
+func evtMop(id string) int {
+	parts := strings.SplitN(id, ",", 2)
+	if len(parts) != 2 {
+		return -1
+	}
+	i, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return -1
+	}
+	return i
+}
+
+func (r *Report) addTxn(id string) {
+	if _, ok := r.Txns[id]; ok {
+		return
+	}
+	txn := Txn{ID: id, Index: -1}
+	if idx, ok := idIndex(id); ok {
+		txn.Index = idx
+		if op, ok := r.ops[idx]; ok {
+			if op.Process.Present() {
+				txn.Process = op.Process.String()
+			}
+			txn.Type = string(op.Type)
+			txn.Error = op.Error
+			if op.Value != nil {
+				for _, mop := range *op.Value {
+					txn.Mops = append(txn.Mops, mop.String())
+				}
+			}
+		}
+	}
+	r.Txns[id] = txn
+}
+
+func (r *Report) mop(txnId string, i int) string {
+	txn := r.Txns[txnId]
+	if i < 0 || i >= len(txn.Mops) {
+		return ""
+	}
+	return txn.Mops[i]
+}
+
+// the explanation of an edge from the mops of its evts
+func (r *Report) explain(e export.Edge, fromMop int, toMop int) string {
+	a, b := "T?", "T?"
+	if i, ok := idIndex(e.From); ok {
+		a = fmt.Sprintf("T%d", i)
+	}
+	if i, ok := idIndex(e.To); ok {
+		b = fmt.Sprintf("T%d", i)
+	}
+	ma, mb := r.mop(e.From, fromMop), r.mop(e.To, toMop)
+	if ma == "" || mb == "" {
+		return fmt.Sprintf("%s < %s by %s", a, b, e.Type)
+	}
+	switch e.Type {
+	case "ww":
+		return fmt.Sprintf("%s wrote key %s with %s (%s), which %s overwrote with %s (%s)", a, e.Obj, ma, e.FromEvt, b, mb, e.ToEvt)
+	case "wr":
+		return fmt.Sprintf("%s read key %s with %s (%s), observing the write %s (%s) of %s", b, e.Obj, mb, e.ToEvt, ma, e.FromEvt, a)
+	case "rw":
+		return fmt.Sprintf("%s read key %s with %s (%s), missing the write %s (%s) of %s", a, e.Obj, ma, e.FromEvt, mb, e.ToEvt, b)
+	default:
+		return fmt.Sprintf("%s < %s by %s: %s (%s), %s (%s)", a, b, e.Type, ma, e.FromEvt, mb, e.ToEvt)
+	}
+}
+
+func (r *Report) edge(e export.Edge, explanation string) Edge {
+	r.addTxn(e.From)
+	r.addTxn(e.To)
+	re := Edge{Edge: e, FromMop: evtMop(e.FromEvt), ToMop: evtMop(e.ToEvt), Explanation: explanation}
+	if re.Explanation == "" {
+		re.Explanation = r.explain(e, re.FromMop, re.ToMop)
+	}
+	return re
+}
+
+/*
+AddCycle adds a cycle found in graph g, the edges of the SCC of g containing the cycle
+become its neighbourhood; g may be empty to report the cycle alone
+*/
+func (r *Report) AddCycle(name string, cycle []export.Edge, g export.Graph) {
+	r.addCycle(name, cycle, g, nil)
+}
+
+func (r *Report) addCycle(name string, cycle []export.Edge, g export.Graph, explanations map[export.Edge]string) {
+	c := Cycle{Name: name}
+	inCycle := make(map[export.Edge]bool)
+	for _, e := range cycle {
+		inCycle[e] = true
+		c.Edges = append(c.Edges, r.edge(e, explanations[e]))
+	}
+	if len(cycle) > 0 {
+		scc := sccOf(g, cycle[0].From)
+		for _, e := range g.Edges {
+			if scc[e.From] && scc[e.To] && !inCycle[e] {
+				c.Neighbourhood = append(c.Neighbourhood, r.edge(e, explanations[e]))
+			}
+		}
+	}
+	r.Cycles = append(r.Cycles, c)
+}
+
+// the txns of the SCC of g containing id (Tarjan)
+func sccOf(g export.Graph, id string) map[string]bool {
+	outs := make(map[string][]string)
+	for _, e := range g.Edges {
+		outs[e.From] = append(outs[e.From], e.To)
+	}
+	index, low := make(map[string]int), make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var result map[string]bool
+	var visit func(v string)
+	visit = func(v string) {
+		index[v], low[v] = len(index), len(index)
+		stack = append(stack, v)
+		onStack[v] = true
+		for _, w := range outs[v] {
+			if _, ok := index[w]; !ok {
+				visit(w)
+				if low[w] < low[v] {
+					low[v] = low[w]
+				}
+			} else if onStack[w] && index[w] < low[v] {
+				low[v] = index[w]
+			}
+		}
+		if low[v] == index[v] {
+			scc := make(map[string]bool)
+			for {
+				w := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[w] = false
+				scc[w] = true
+				if w == v {
+					break
+				}
+			}
+			if scc[id] {
+				result = scc
+			}
+		}
+	}
+	visit(id)
+	return result
+}
+
+/*
+AddElleCycles adds the cycle anomalies go-elle found, named by their anomaly type,
+with go-elle's explanations; the SCCs of the result are their neighbourhoods
+*/
+func (r *Report) AddElleCycles(result core.CheckResult, collections export.ElleCollections) {
+	g := export.FromElle(result.Graph, result.Explainer, collections)
+	explanations := make(map[export.Edge]string)
+	explained := make(map[[2]string]core.ExplainResult)
+	for _, e := range g.Edges {
+		k := [2]string{e.From, e.To}
+		if _, ok := explained[k]; ok {
+			continue
+		}
+		a, _ := idIndex(e.From)
+		b, _ := idIndex(e.To)
+		explained[k] = result.Explainer.ExplainPairData(r.ops[a], r.ops[b])
+	}
+	for _, e := range g.Edges {
+		if ex := explained[[2]string{e.From, e.To}]; ex != nil && string(ex.Type()) == e.Type {
+			a, _ := idIndex(e.From)
+			b, _ := idIndex(e.To)
+			explanations[e] = result.Explainer.RenderExplanation(ex, fmt.Sprintf("T%d", a), fmt.Sprintf("T%d", b))
+		}
+	}
+
+	var names []string
+	for name := range result.Anomalies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, anomaly := range result.Anomalies[name] {
+			cr, ok := anomaly.(core.CycleExplainerResult)
+			if !ok {
+				continue
+			}
+			var cycle []export.Edge
+			for i := 1; i < len(cr.Circle.Path); i++ {
+				from := fmt.Sprintf("%s/%d", collections.Txn, cr.Circle.Path[i-1].Index.MustGet())
+				to := fmt.Sprintf("%s/%d", collections.Txn, cr.Circle.Path[i].Index.MustGet())
+				typ := ""
+				if i-1 < len(cr.Steps) && cr.Steps[i-1].Result != nil {
+					typ = string(cr.Steps[i-1].Result.Type())
+				}
+				cycle = append(cycle, findEdge(g, from, to, typ))
+			}
+			r.addCycle(name, cycle, g, explanations)
+		}
+	}
+}
+
+// the edge of g from -> to of type typ, or of any type if there is none
+func findEdge(g export.Graph, from string, to string, typ string) export.Edge {
+	var found *export.Edge
+	for i, e := range g.Edges {
+		if e.From == from && e.To == to {
+			if e.Type == typ {
+				return e
+			}
+			if found == nil {
+				found = &g.Edges[i]
+			}
+		}
+	}
+	if found != nil {
+		return *found
+	}
+	return export.Edge{From: from, To: to, Type: typ}
+}
+
+/*
+WriteHTML writes the report as a single HTML page
+*/
+func (r *Report) WriteHTML(w io.Writer) error {
+	return explorerTemplate.Execute(w, r)
+}
+
+/*
+WriteFile writes the report to an HTML file at path
+*/
+func (r *Report) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteHTML(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,112 @@
+package report_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	ellelistappend "github.com/grail/anti-pattern-graph-checker-single/go-elle/list_append"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+	listappend "github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/list_append"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/report"
+	"github.com/stretchr/testify/require"
+)
+
+func parseHistory(t *testing.T, ops ...string) core.History {
+	var history core.History
+	for i, s := range ops {
+		op, err := core.ParseOp(s)
+		require.NoError(t, err)
+		op.Index = core.NewOptInt(i)
+		history = append(history, op)
+	}
+	return history
+}
+
+// the report embedded in the page
+func embedded(t *testing.T, r *report.Report) report.Report {
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteHTML(&buf))
+	m := regexp.MustCompile(`(?s)const data = (.*?);\nconst colors`).FindSubmatch(buf.Bytes())
+	require.NotNil(t, m)
+	var data report.Report
+	require.NoError(t, json.Unmarshal(m[1], &data))
+	return data
+}
+
+func TestAddCycle(t *testing.T) {
+	// G-single plus T3, which is in the SCC of the cycle but not on it
+	history := parseHistory(t,
+		`{:type :ok, :value [[:append 1 1] [:append 2 1]]}`,
+		`{:type :ok, :value [[:append 1 2] [:append 2 2] [:r 4 [1]]]}`,
+		`{:type :ok, :value [[:r 1 [1 2]] [:r 2 [1]] [:append 3 1]]}`,
+		`{:type :ok, :value [[:r 3 [1]] [:append 4 1]]}`,
+	)
+	g := listappend.BuildDepGraph(history, listappend.DefaultDBConsts()).Export()
+	cycle := []export.Edge{
+		{From: "txn/1", To: "txn/2", FromEvt: "a_evt/1,0", ToEvt: "r_evt/2,0", Obj: "1", Type: "wr"},
+		{From: "txn/2", To: "txn/1", FromEvt: "r_evt/2,1", ToEvt: "a_evt/1,1", Obj: "2", Type: "rw"},
+	}
+	for _, e := range cycle {
+		require.Contains(t, g.Edges, e)
+	}
+
+	r := report.New("G-single", history)
+	r.AddCycle("SI", cycle, g)
+	data := embedded(t, r)
+	require.Equal(t, "G-single", data.Title)
+	require.Len(t, data.Cycles, 1)
+	c := data.Cycles[0]
+	require.Equal(t, "SI", c.Name)
+	require.Len(t, c.Edges, 2)
+	require.Equal(t, 1, c.Edges[1].FromMop)
+	require.Equal(t, "T2 read key 2 with [:r 2 [1]] (r_evt/2,1), missing the write [:append 2 2] (a_evt/1,1) of T1", c.Edges[1].Explanation)
+
+	// T0 only precedes the SCC, T3 is in it
+	require.NotEmpty(t, c.Neighbourhood)
+	for _, e := range c.Neighbourhood {
+		require.NotEqual(t, "txn/0", e.From)
+		require.NotEqual(t, "txn/0", e.To)
+	}
+	require.Contains(t, data.Txns, "txn/3")
+	require.NotContains(t, data.Txns, "txn/0")
+	require.Equal(t, []string{"[:r 3 [1]]", "[:append 4 1]"}, data.Txns["txn/3"].Mops)
+}
+
+func TestAddElleCycles(t *testing.T) {
+	// the realtime graph go-elle adds by default needs the invocations
+	history := parseHistory(t,
+		`{:type :invoke, :process 0, :value [[:append x 1] [:r y nil]]}`,
+		`{:type :invoke, :process 1, :value [[:append x 2] [:append y 1]]}`,
+		`{:type :ok, :process 0, :value [[:append x 1] [:r y [1]]]}`,
+		`{:type :ok, :process 1, :value [[:append x 2] [:append y 1]]}`,
+		`{:type :invoke, :process 2, :value [[:r x nil] [:r y nil]]}`,
+		`{:type :ok, :process 2, :value [[:r x [1 2]] [:r y [1]]]}`,
+	)
+	r := report.New("G1c", history)
+	r.AddElleCycles(ellelistappend.Analysis(txn.Opts{}, history), export.ListAppendCollections)
+	data := embedded(t, r)
+	require.NotEmpty(t, data.Cycles)
+	for _, c := range data.Cycles {
+		require.NotEmpty(t, c.Edges, c.Name)
+		require.Equal(t, c.Edges[0].From, c.Edges[len(c.Edges)-1].To)
+		for _, e := range c.Edges {
+			require.NotEmpty(t, e.Explanation)
+			require.NotEmpty(t, e.Obj)
+		}
+	}
+}
+
+func TestWriteHTMLEscapes(t *testing.T) {
+	history := parseHistory(t, `{:type :ok, :value [[:append x 1]]}`)
+	r := report.New("</script><script>alert(1)</script>", history)
+	r.AddCycle("SER", nil, export.Graph{})
+	var buf bytes.Buffer
+	require.NoError(t, r.WriteHTML(&buf))
+	require.False(t, strings.Contains(buf.String(), "<script>alert(1)"))
+	require.Equal(t, "</script><script>alert(1)</script>", embedded(t, r).Title)
+}
@@ -0,0 +1,250 @@
+package rwregister
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/antipattern"
+)
+
+/*
+AQL is a query text together with its bind parameters. Collection names are bound through
+"@@name" parameters, graph names, depths and start vertices through "@name" parameters,
+so no configurable value is ever spliced into the query text.
+*/
+type AQL struct {
+	Query    string
+	BindVars map[string]interface{}
+}
+
+type aqlBuilder struct {
+	lines    []string
+	bindVars map[string]interface{}
+}
+
+func newAQLBuilder() *aqlBuilder {
+	return &aqlBuilder{bindVars: make(map[string]interface{})}
+}
+
+// appends one clause at the given nesting depth
+func (b *aqlBuilder) line(depth int, clause string) *aqlBuilder {
+	b.lines = append(b.lines, strings.Repeat("\t", depth)+clause)
+	return b
+}
+
+// binds a value parameter, referred to as @name in the query
+func (b *aqlBuilder) bind(name string, value interface{}) *aqlBuilder {
+	b.bindVars[name] = value
+	return b
+}
+
+// binds a collection parameter, referred to as @@name in the query
+func (b *aqlBuilder) bindCollection(name string, collection string) *aqlBuilder {
+	b.bindVars["@"+name] = collection
+	return b
+}
+
+func (b *aqlBuilder) build() AQL {
+	return AQL{Query: strings.Join(b.lines, "\n") + "\n", BindVars: b.bindVars}
+}
+
+/*
+LevelSpec describes the anti-pattern of an isolation level for the query builder, the cycle and
+first-edge filters as well as the SP-AllCycles predicate are compiled from the pattern;
+MaxDepthRandom overrides MAX_DEPTH_SV_SIMPLE for SV-Random
+*/
+type LevelSpec struct {
+	Pattern        antipattern.Pattern
+	MaxDepthRandom int
+}
+
+var (
+	LevelSER = LevelSpec{Pattern: antipattern.SER}
+	LevelSI  = LevelSpec{Pattern: withoutPredicateRW(antipattern.SI)}
+	LevelPSI = LevelSpec{Pattern: withoutPredicateRW(antipattern.PSI)}
+	LevelPL2 = LevelSpec{Pattern: antipattern.PL2}
+	LevelPL1 = LevelSpec{Pattern: antipattern.PL1, MaxDepthRandom: 3}
+)
+
+/*
+the pattern restricted to the cycles without the rw-predicate edges of scans: phantoms (G2-predicate)
+are proscribed by SER only, the anti-patterns of SI and PSI count the item rw edges
+*/
+func withoutPredicateRW(p antipattern.Pattern) antipattern.Pattern {
+	p.Counts = append(append([]antipattern.Count{}, p.Counts...), antipattern.Count{Type: string(core.PredicateRW), Op: "==", N: 0})
+	return p
+}
+
+var levels = map[string]LevelSpec{}
+
+func init() {
+	RegisterLevel(LevelSER, "ser", "SER", "serializabilty", "SERIALIZABILITY")
+	RegisterLevel(LevelSI, "si", "SI", "snapshot isolation", "SNAPSHOT ISOLATION")
+	RegisterLevel(LevelPSI, "psi", "PSI", "parallel snapshot isolation", "PARALLEL SNAPSHOT ISOLATION")
+	RegisterLevel(LevelPL2, "pl-2", "PL-2")
+	RegisterLevel(LevelPL1, "pl-1", "PL-1")
+}
+
+/*
+makes a level, e.g. a custom one defined by an anti-pattern, available to IsolationLevelChecker under the names
+*/
+func RegisterLevel(spec LevelSpec, names ...string) {
+	for _, name := range names {
+		levels[name] = spec
+	}
+}
+
+/*
+returns the level specification of one of the registered level names
+*/
+func LookupLevel(level string) (LevelSpec, bool) {
+	spec, ok := levels[level]
+	return spec, ok
+}
+
+func (spec LevelSpec) name() string {
+	return spec.Pattern.Name
+}
+
+// whether a cycle found by SP-AllCycles is an anti-pattern of the level
+func (spec LevelSpec) isAntiPattern(cycle []TxnDepEdge) bool {
+	return spec.Pattern.Match(cycleTypes(cycle))
+}
+
+/*
+CycleAnomaly names the Adya anomaly of a witness cycle and the consistency models it rules out,
+in the vocabulary of go-elle (see antipattern.Classify)
+*/
+func CycleAnomaly(cycle []TxnDepEdge) antipattern.Anomaly {
+	return antipattern.Classify(cycleTypes(cycle))
+}
+
+func cycleTypes(cycle []TxnDepEdge) []string {
+	types := make([]string, len(cycle))
+	for i, e := range cycle {
+		types[i] = e.Type
+	}
+	return types
+}
+
+/*
+single-vertex traversal queries, one per mode:
+
+	sv:        FOR start IN @@txn FOR vertex, edge, path IN @min_depth..@max_depth OUTBOUND start._id
+	           GRAPH @graph FILTER edge._to == start._id [AND level filter] LIMIT 1 RETURN path.edges
+	sv-filter: as sv, with the cycle closed on LAST(path.edges[*]._to)
+	sv-random: one traversal from the bound @start vertex, issued per shuffled start
+*/
+func svQuery(spec LevelSpec, mode string, dbConsts DBConsts) AQL {
+	b := newAQLBuilder()
+	maxDepth := MAX_DEPTH_SV_SIMPLE
+
+	start := "start._id"
+	depth := 0
+	switch mode {
+	case "sv-filter":
+		maxDepth = MAX_DEPTH_SV
+		fallthrough
+	case "sv":
+		b.line(0, "FOR start IN @@txn").bindCollection("txn", dbConsts.TxnNode)
+		depth = 1
+	case "sv-random":
+		start = "@start"
+		if spec.MaxDepthRandom > 0 {
+			maxDepth = spec.MaxDepthRandom
+		}
+	}
+
+	closing := fmt.Sprintf("LAST(path.edges[*]._to) == %s", start)
+	if mode == "sv" {
+		closing = "edge._to == start._id"
+	}
+	filter := closing
+	if cond := spec.Pattern.AQLFilter("path.edges"); cond != "" {
+		filter += " AND " + cond
+	}
+
+	return b.line(depth, "FOR vertex, edge, path").
+		line(depth+1, "IN @min_depth..@max_depth").
+		line(depth+1, "OUTBOUND "+start).
+		line(depth+1, "GRAPH @graph").
+		line(depth+1, "FILTER "+filter).
+		line(depth+1, "LIMIT 1").
+		line(depth+1, "RETURN path.edges").
+		bind("min_depth", MIN_DEPTH).
+		bind("max_depth", maxDepth).
+		bind("graph", dbConsts.TxnGraph).
+		build()
+}
+
+// the shortest path back from the head of each dependency edge to its tail
+func spPaths(b *aqlBuilder, spec LevelSpec, depth int, ret string) *aqlBuilder {
+	b.line(depth, "FOR edge IN @@dep")
+	if cond := spec.Pattern.AQLEdgeFilter("edge"); cond != "" {
+		b.line(depth+1, "FILTER "+cond)
+	}
+	b.line(depth+1, "FOR p IN OUTBOUND K_SHORTEST_PATHS").
+		line(depth+2, "edge._to TO edge._from").
+		line(depth+2, "GRAPH @graph")
+	return b.line(depth+2, ret)
+}
+
+const spPathReturn = "RETURN {edges: UNSHIFT(p.edges, edge), vertices: UNSHIFT(p.vertices, p.vertices[LENGTH(p.vertices) - 1])}"
+
+/*
+direct query a type of cycle and return in ArangoDB format
+*/
+func spQuery(spec LevelSpec, dbConsts DBConsts) AQL {
+	b := newAQLBuilder().
+		bindCollection("dep", dbConsts.TxnDepEdge).
+		bind("graph", dbConsts.TxnGraph)
+
+	if spec.Pattern.Any() {
+		b.line(0, "FOR edge IN @@dep").
+			line(1, "FOR p IN OUTBOUND K_SHORTEST_PATHS").
+			line(2, "edge._to TO edge._from").
+			line(2, "GRAPH @graph").
+			line(2, "LIMIT 1").
+			line(2, spPathReturn)
+		return b.build()
+	}
+
+	b.line(0, "LET cycles = (")
+	spPaths(b, spec, 1, spPathReturn)
+	return b.line(0, ")").
+		line(0, "FOR cycle IN cycles").
+		line(1, "FILTER "+spec.Pattern.AQLFilter("cycle.edges")).
+		line(1, "LIMIT 1").
+		line(1, "RETURN cycle").
+		build()
+}
+
+/*
+query using BFS-based shortest path, the cycles are parsed in Go
+
+	FOR edge IN @@dep
+		FOR p IN OUTBOUND K_SHORTEST_PATHS
+			edge._to TO edge._from
+			GRAPH @graph
+			RETURN UNSHIFT(p.edges, edge)
+*/
+func spAllCyclesQuery(spec LevelSpec, dbConsts DBConsts) AQL {
+	b := newAQLBuilder().
+		bindCollection("dep", dbConsts.TxnDepEdge).
+		bind("graph", dbConsts.TxnGraph)
+	return spPaths(b, spec, 0, "RETURN UNSHIFT(p.edges, edge)").build()
+}
+
+/*
+strongly connected components labelled by Pregel with more than one transaction
+*/
+func pregelCycleQuery(dbConsts DBConsts) AQL {
+	return newAQLBuilder().
+		line(0, "FOR t IN @@txn").
+		line(1, "COLLECT cycle = t.scc INTO cycles").
+		line(1, "FILTER LENGTH(cycles) > 1").
+		line(1, "RETURN cycle").
+		bindCollection("txn", dbConsts.TxnNode).
+		build()
+}
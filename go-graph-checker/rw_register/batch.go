@@ -0,0 +1,254 @@
+package rwregister
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+)
+
+type BatchOpts struct {
+	Levels     []string // e.g. ser, si, psi, pl-2, pl-1
+	Mode       string   // see IsolationLevelChecker
+	Workers    int      // histories checked at the same time
+	MaxQueries int      // graph constructions and checks in flight across all workers
+	KeepGraphs bool     // keep the namespaced graphs after checking
+}
+
+/*
+one row per history and level
+*/
+type BatchResult struct {
+	History     string `json:"history"`
+	Level       string `json:"level"`
+	Mode        string `json:"mode"`
+	Txns        int    `json:"txns"`
+	G1a         bool   `json:"g1a"`
+	G1b         bool   `json:"g1b"`
+	Valid       bool   `json:"valid"`
+	Cycle       string `json:"cycle,omitempty"`
+	Anomaly     string `json:"anomaly,omitempty"` // the Adya anomaly of the cycle, e.g. G-single
+	LostUpdates int    `json:"lost_updates"`
+	WriteSkews  int    `json:"write_skews"`
+	LongForks   int    `json:"long_forks"`
+	ConstructMs int64  `json:"construct_ms"`
+	CheckMs     int64  `json:"check_ms"`
+	// the lost updates and write skews of the history, with their keys and a few examples
+	Pairs []PairReport `json:"pairs,omitempty"`
+}
+
+// examples of every pair anomaly in BatchResult.Pairs
+const batchPairExamples = 3
+
+var nonIdentChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+/*
+turns a history file name into a collection prefix, e.g. "10.edn" -> "h_10".
+a name with other characters than letters, digits and _ gets a short hash of the original name,
+so that "a-b.edn" and "a.b.edn" don't share their collections, e.g. "a-b.edn" -> "h_a_b_2a89df63"
+*/
+func historyNamespace(fileName string) string {
+	base := strings.TrimSuffix(filepath.Base(fileName), filepath.Ext(fileName))
+	ns := "h_" + nonIdentChars.ReplaceAllString(base, "_")
+	if !nonIdentChars.MatchString(base) {
+		return ns
+	}
+	h := fnv.New32a()
+	h.Write([]byte(base))
+	return fmt.Sprintf("%s_%08x", ns, h.Sum32())
+}
+
+/*
+checks every .edn history under dir against all opts.Levels,
+the WAL of each history is read from the .log file of the same name.
+all histories share one database of a fresh run, each history gets its own namespaced graphs.
+*/
+func CheckDirectory(dir string, dbConsts DBConsts, opts BatchOpts) []BatchResult {
+	files, err := filepath.Glob(filepath.Join(dir, "*.edn"))
+	if err != nil {
+		log.Fatalf("Cannot list histories in %s: %v\n", dir, err)
+	}
+	if opts.Workers < 1 {
+		opts.Workers = 1
+	}
+	if opts.MaxQueries < 1 {
+		opts.MaxQueries = opts.Workers
+	}
+
+	if dbConsts.RunID == "" {
+		dbConsts = dbConsts.PerRun()
+	}
+	// create the run database up front, workers reuse it afterwards
+	getOrCreateDB(startClient(dbConsts), dbConsts)
+
+	namespaces := make(map[string]string)
+	for _, fileName := range files {
+		ns := historyNamespace(fileName)
+		if other, ok := namespaces[ns]; ok {
+			log.Fatalf("Histories %s and %s share the namespace %s\n", other, fileName, ns)
+		}
+		namespaces[ns] = fileName
+	}
+
+	queries := make(chan struct{}, opts.MaxQueries)
+	jobs := make(chan string)
+	var (
+		mu      sync.Mutex
+		results []BatchResult
+		wg      sync.WaitGroup
+	)
+
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for fileName := range jobs {
+				rows := checkHistoryFile(fileName, dbConsts.Namespaced(historyNamespace(fileName)), opts, queries)
+				mu.Lock()
+				results = append(results, rows...)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, fileName := range files {
+		jobs <- fileName
+	}
+	close(jobs)
+	wg.Wait()
+
+	levelOrder := make(map[string]int)
+	for i, level := range opts.Levels {
+		levelOrder[level] = i
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].History != results[j].History {
+			return results[i].History < results[j].History
+		}
+		return levelOrder[results[i].Level] < levelOrder[results[j].Level]
+	})
+	return results
+}
+
+func checkHistoryFile(fileName string, dbConsts DBConsts, opts BatchOpts, queries chan struct{}) []BatchResult {
+	log.Printf("Checking %s...\n", fileName)
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		log.Fatalf("Cannot read edn file %s\n", fileName)
+	}
+	history, err := core.ParseHistoryRW(string(content))
+	if err != nil {
+		log.Fatalf("Cannot parse edn file %s\n", fileName)
+	}
+	walFileName := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".log"
+	walContent, err := os.ReadFile(walFileName)
+	if err != nil {
+		log.Fatalf("Cannot read wal log %s\n", walFileName)
+	}
+	wal, err := ParseWAL(string(walContent))
+	if err != nil {
+		log.Fatalf("Cannot parse wal log %s\n", walFileName)
+	}
+
+	queries <- struct{}{}
+	t1 := time.Now()
+	db, txnIds, g1 := ConstructGraph(txn.Opts{}, history, wal, dbConsts)
+	constructMs := time.Since(t1).Milliseconds()
+	<-queries
+
+	queries <- struct{}{}
+	pairs := ReportPairCases(QueryPairCases(db, dbConsts), batchPairExamples)
+	longForks := len(QueryLongForks(db, dbConsts))
+	<-queries
+	pairCounts := make(map[string]int)
+	for _, r := range pairs {
+		pairCounts[r.Type] = r.Count
+	}
+
+	rows := make([]BatchResult, 0, len(opts.Levels))
+	for _, level := range opts.Levels {
+		queries <- struct{}{}
+		t2 := time.Now()
+		valid, cycle := IsolationLevelChecker(db, dbConsts, txnIds, false, level, opts.Mode)
+		checkMs := time.Since(t2).Milliseconds()
+		<-queries
+
+		row := BatchResult{
+			History:     filepath.Base(fileName),
+			Level:       level,
+			Mode:        opts.Mode,
+			Txns:        len(txnIds),
+			G1a:         g1.G1a,
+			G1b:         g1.G1b,
+			Valid:       valid,
+			LostUpdates: pairCounts[LostUpdate],
+			WriteSkews:  pairCounts[WriteSkew],
+			LongForks:   longForks,
+			ConstructMs: constructMs,
+			CheckMs:     checkMs,
+			Pairs:       pairs,
+		}
+		if len(cycle) > 0 {
+			row.Cycle = cycleToStr(cycle)
+			row.Anomaly = CycleAnomaly(cycle).Name
+		}
+		rows = append(rows, row)
+	}
+
+	if !opts.KeepGraphs {
+		queries <- struct{}{}
+		dropGraphs(db, dbConsts)
+		<-queries
+	}
+	return rows
+}
+
+func WriteBatchCSV(results []BatchResult, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	header := []string{"history", "level", "mode", "txns", "g1a", "g1b", "valid", "cycle", "anomaly", "lost_updates", "write_skews", "long_forks", "construct_ms", "check_ms"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, r := range results {
+		record := []string{
+			r.History,
+			r.Level,
+			r.Mode,
+			strconv.Itoa(r.Txns),
+			strconv.FormatBool(r.G1a),
+			strconv.FormatBool(r.G1b),
+			strconv.FormatBool(r.Valid),
+			r.Cycle,
+			r.Anomaly,
+			strconv.Itoa(r.LostUpdates),
+			strconv.Itoa(r.WriteSkews),
+			strconv.Itoa(r.LongForks),
+			fmt.Sprint(r.ConstructMs),
+			fmt.Sprint(r.CheckMs),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func WriteBatchJSON(results []BatchResult, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
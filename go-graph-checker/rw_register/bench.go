@@ -0,0 +1,164 @@
+package rwregister
+
+import (
+	"database/sql"
+	"log"
+
+	"github.com/arangodb/go-driver"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/bench"
+)
+
+/*
+Bench measures the phases of checking the EDN content of a history and its WAL with ArangoDB: parsing,
+inserting the nodes, deriving the evt dependency edges, inserting the edges (with their projection
+on txns) and the query of every level in every mode. The mode pregel times CheckSERPregel, it is only
+run for ser. The insert phases start from an empty graph each run, so the graphs of dbConsts are
+dropped and recreated (and dropped once the queries are done): the caller opts in with
+dbConsts.Overwrite or with the database of its own run, see DBConsts.PerRun
+*/
+func Bench(name string, content string, wal WAL, dbConsts DBConsts, levels []string, modes []string, opts bench.Options) []bench.Record {
+	if opts.Repetitions < 1 {
+		log.Fatalf("Invalid repetitions: %d\n", opts.Repetitions)
+	}
+	r := &bench.Recorder{Backend: "arango", History: name, Opts: opts}
+
+	history := benchParse(r, name, content)
+	okHistory := core.FilterOkHistory(preProcessHistory(history))
+
+	client := startClient(dbConsts)
+	var db driver.Database
+	var txnGraph, evtGraph driver.Graph
+	var txnIds []int
+	reset := func() {
+		if db != nil {
+			dropGraphs(db, dbConsts)
+		}
+		db, txnGraph, evtGraph = createGraph(client, dbConsts)
+	}
+	rec := r.Phase(bench.NodeInsert, "", reset, func() {
+		txnIds = createNodes(txnGraph, evtGraph, okHistory, dbConsts)
+	})
+	rec.DB = bench.ArangoStats(db, dbConsts.TxnNode, dbConsts.WriteEvtNode, dbConsts.ReadEvtNode)
+
+	var evtDepEdges []EvtDepEdge
+	r.Phase(bench.EdgeDerivation, "", nil, func() {
+		evtDepEdges, _ = getEvtDepEdges(db, ConstructWALWriteMap(wal, "rwAttr"), dbConsts)
+	})
+
+	rec = r.Phase(bench.EdgeInsert, "", func() {
+		reset()
+		createNodes(txnGraph, evtGraph, okHistory, dbConsts)
+	}, func() {
+		addDepEdges(db, txnGraph, evtGraph, dbConsts, evtDepEdges)
+	})
+	rec.DB = bench.ArangoStats(db, dbConsts.TxnNode, dbConsts.WriteEvtNode, dbConsts.ReadEvtNode,
+		dbConsts.EvtDepEdge, dbConsts.TxnDepEdge)
+	defer dropGraphs(db, dbConsts)
+
+	for _, level := range levels {
+		spec := benchLevel(level)
+		for _, mode := range modes {
+			if mode == "pregel" {
+				// the SCCs of the txn graph, there is no pregel check of the weaker levels
+				if spec.name() == LevelSER.name() {
+					r.Phase(bench.Query, level+"/"+mode, nil, func() {
+						CheckSERPregel(db, dbConsts, txnIds, false)
+					})
+				}
+				continue
+			}
+			r.Phase(bench.Query, level+"/"+mode, nil, func() {
+				CheckLevel(db, dbConsts, txnIds, false, spec, mode)
+			})
+		}
+	}
+	return benchRecords(r, len(okHistory))
+}
+
+/*
+BenchSQLite is Bench with the SQLite backend: the edges are derived in memory, before
+the nodes and the edges are inserted into an in-memory database
+*/
+func BenchSQLite(name string, content string, wal WAL, dbConsts DBConsts, levels []string, modes []string, opts bench.Options) []bench.Record {
+	if opts.Repetitions < 1 {
+		log.Fatalf("Invalid repetitions: %d\n", opts.Repetitions)
+	}
+	r := &bench.Recorder{Backend: "sqlite", History: name, Opts: opts}
+
+	history := benchParse(r, name, content)
+
+	var g DepGraph
+	r.Phase(bench.EdgeDerivation, "", nil, func() {
+		g = BuildDepGraph(history, wal, dbConsts)
+	})
+
+	var db *sql.DB
+	open := func(nodes bool) {
+		if db != nil {
+			db.Close()
+		}
+		var err error
+		if db, err = openSQLite(":memory:"); err != nil {
+			log.Fatalf("Failed to open SQLite database: %v\n", err)
+		}
+		err = inSQLiteTx(db, func(tx *sql.Tx) error {
+			if err := createSQLiteSchema(tx); err != nil || !nodes {
+				return err
+			}
+			return insertSQLiteNodes(tx, g)
+		})
+		if err != nil {
+			log.Fatalf("Failed to load SQLite database: %v\n", err)
+		}
+	}
+	insert := func(f func(tx *sql.Tx, g DepGraph) error) func() {
+		return func() {
+			if err := inSQLiteTx(db, func(tx *sql.Tx) error { return f(tx, g) }); err != nil {
+				log.Fatalf("Failed to load SQLite database: %v\n", err)
+			}
+		}
+	}
+	rec := r.Phase(bench.NodeInsert, "", func() { open(false) }, insert(insertSQLiteNodes))
+	rec.DB = bench.SQLiteStats(db)
+	rec = r.Phase(bench.EdgeInsert, "", func() { open(true) }, insert(insertSQLiteEdges))
+	rec.DB = bench.SQLiteStats(db)
+	defer db.Close()
+
+	for _, level := range levels {
+		spec := benchLevel(level)
+		for _, mode := range modes {
+			r.Phase(bench.Query, level+"/"+mode, nil, func() {
+				CheckLevelSQLite(db, g.TxnIds, false, spec, mode)
+			})
+		}
+	}
+	return benchRecords(r, len(g.TxnIds))
+}
+
+func benchParse(r *bench.Recorder, name string, content string) core.History {
+	var history core.History
+	r.Phase(bench.Parse, "", nil, func() {
+		var err error
+		if history, err = core.ParseHistoryRW(content); err != nil {
+			log.Fatalf("Cannot parse %s: %v\n", name, err)
+		}
+	})
+	return history
+}
+
+func benchLevel(level string) LevelSpec {
+	spec, ok := LookupLevel(level)
+	if !ok {
+		log.Fatalf("invalid level: %s, not from any of the registered levels\n", level)
+	}
+	return spec
+}
+
+// the number of txns is known once the history is parsed
+func benchRecords(r *bench.Recorder, txns int) []bench.Record {
+	for i := range r.Records {
+		r.Records[i].Txns = txns
+	}
+	return r.Records
+}
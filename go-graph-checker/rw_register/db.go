@@ -0,0 +1,164 @@
+package rwregister
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	driver "github.com/arangodb/go-driver"
+)
+
+/*
+every database created by the checker carries a marker document,
+so that stale checker databases can be told apart from real data
+*/
+const (
+	MarkerCollection = "grail_meta"
+	MarkerKey        = "owner"
+	MarkerOwner      = "grail-checker"
+)
+
+type CheckerMarker struct {
+	Key       string `json:"_key"`
+	Owner     string `json:"owner"`
+	RunID     string `json:"run_id"`
+	CreatedAt int64  `json:"created_at"` // unix seconds
+}
+
+/*
+returns a fresh run id, e.g. "20230412T101502_1a2b3c"
+*/
+func NewRunID() string {
+	now := time.Now()
+	return fmt.Sprintf("%s_%06x", now.Format("20060102T150405"), now.UnixNano()&0xffffff)
+}
+
+/*
+returns a copy of dbConsts bound to a new run:
+the database name gets the run id as suffix, so nothing existing is dropped
+*/
+func (dbConsts DBConsts) PerRun() DBConsts {
+	dbConsts.RunID = NewRunID()
+	dbConsts.DB = fmt.Sprintf("%s_%s", dbConsts.DB, dbConsts.RunID)
+	dbConsts.Overwrite = false
+	return dbConsts
+}
+
+func writeMarker(db driver.Database, runID string) {
+	col, err := db.CreateCollection(context.Background(), MarkerCollection, &driver.CreateCollectionOptions{
+		NumberOfShards: 1,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create collection: %v\n", err)
+	}
+	_, err = col.CreateDocument(context.Background(), CheckerMarker{
+		Key:       MarkerKey,
+		Owner:     MarkerOwner,
+		RunID:     runID,
+		CreatedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Fatalf("Failed to create marker: %v\n", err)
+	}
+}
+
+/*
+returns the marker of db and whether db is owned by the checker,
+a db without the marker is not owned and any other failure to read it is an error
+*/
+func readMarker(db driver.Database) (CheckerMarker, bool, error) {
+	var marker CheckerMarker
+	col, err := db.Collection(context.Background(), MarkerCollection)
+	if driver.IsNotFound(err) {
+		return marker, false, nil
+	} else if err != nil {
+		return marker, false, fmt.Errorf("failed to open collection %s: %v", MarkerCollection, err)
+	}
+	_, err = col.ReadDocument(context.Background(), MarkerKey, &marker)
+	if driver.IsNotFound(err) {
+		return marker, false, nil
+	} else if err != nil {
+		return marker, false, fmt.Errorf("failed to read marker: %v", err)
+	}
+	return marker, marker.Owner == MarkerOwner, nil
+}
+
+/*
+drops all checker-owned databases created more than `retention` ago,
+and returns their names; with dryRun, nothing is dropped.
+A database whose marker cannot be read is logged and skipped
+*/
+func CleanupCheckerDBs(dbConsts DBConsts, retention time.Duration, dryRun bool) []string {
+	client := startClient(dbConsts)
+	dbs, err := client.Databases(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to list databases: %v\n", err)
+	}
+
+	deadline := time.Now().Add(-retention).Unix()
+	var dropped []string
+	for _, db := range dbs {
+		if db.Name() == "_system" {
+			continue
+		}
+		marker, owned, err := readMarker(db)
+		if err != nil {
+			log.Printf("Skipping database %s: %v\n", db.Name(), err)
+			continue
+		}
+		if !owned || marker.CreatedAt > deadline {
+			continue
+		}
+		if !dryRun {
+			if err := db.Remove(context.Background()); err != nil {
+				log.Fatalf("Failed to drop database %s: %v\n", db.Name(), err)
+			}
+		}
+		dropped = append(dropped, db.Name())
+	}
+	return dropped
+}
+
+/*
+returns a copy of dbConsts whose graphs and collections are prefixed by ns,
+so that several histories can be checked side by side in one database
+*/
+func (dbConsts DBConsts) Namespaced(ns string) DBConsts {
+	prefix := func(name string) string {
+		return fmt.Sprintf("%s_%s", ns, name)
+	}
+	dbConsts.TxnGraph = prefix(dbConsts.TxnGraph)
+	dbConsts.EvtGraph = prefix(dbConsts.EvtGraph)
+	dbConsts.TxnNode = prefix(dbConsts.TxnNode)
+	dbConsts.WriteEvtNode = prefix(dbConsts.WriteEvtNode)
+	dbConsts.ReadEvtNode = prefix(dbConsts.ReadEvtNode)
+	dbConsts.TxnDepEdge = prefix(dbConsts.TxnDepEdge)
+	dbConsts.EvtDepEdge = prefix(dbConsts.EvtDepEdge)
+	return dbConsts
+}
+
+/*
+removes both graphs of dbConsts together with their collections
+*/
+func dropGraphs(db driver.Database, dbConsts DBConsts) {
+	for _, name := range []string{dbConsts.TxnGraph, dbConsts.EvtGraph} {
+		g, err := db.Graph(context.Background(), name)
+		if err != nil {
+			log.Fatalf("Failed to open graph %s: %v\n", name, err)
+		}
+		if err := g.Remove(context.Background()); err != nil {
+			log.Fatalf("Failed to remove graph %s: %v\n", name, err)
+		}
+	}
+	for _, name := range []string{dbConsts.TxnDepEdge, dbConsts.EvtDepEdge,
+		dbConsts.TxnNode, dbConsts.WriteEvtNode, dbConsts.ReadEvtNode} {
+		col, err := db.Collection(context.Background(), name)
+		if err != nil {
+			log.Fatalf("Failed to open collection %s: %v\n", name, err)
+		}
+		if err := col.Remove(context.Background()); err != nil {
+			log.Fatalf("Failed to remove collection %s: %v\n", name, err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package rwregister
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+)
+
+/*
+DepGraph holds the nodes and dependency edges GRAIL stores in ArangoDB, derived in memory.
+Ids follow the ArangoDB document ids ("<collection>/<key>") of dbConsts, so that edges
+of both sources can be compared and fed to the same checkers.
+*/
+type DepGraph struct {
+	TxnIds      []int
+	Txns        []TxnNode
+	WriteEvts   []WriteEvt
+	ReadEvts    []ReadEvt
+	EvtDepEdges []EvtDepEdge
+	TxnDepEdges []TxnDepEdge
+	G1          G1Anomalies
+	DBConsts    DBConsts
+}
+
+/*
+BuildDepGraph derives the dependency graph of a history without a database,
+the counterpart of ConstructGraph for the embedded and in-memory backends
+*/
+func BuildDepGraph(history core.History, wal WAL, dbConsts DBConsts) DepGraph {
+	g, err := TryBuildDepGraph(history, wal, dbConsts)
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	return g
+}
+
+/*
+TryBuildDepGraph is BuildDepGraph returning an error instead of exiting
+when the history cannot be traced (the same value written twice to an object)
+*/
+func TryBuildDepGraph(history core.History, wal WAL, dbConsts DBConsts) (DepGraph, error) {
+	history = preProcessHistory(history)
+	okHistory := core.FilterOkHistory(history)
+
+	txns, txnIds, writeEvts, readEvts := collectNodes(okHistory)
+	wm := ConstructWALWriteMap(wal, "rwAttr")
+	writeMap, itmdMap, err := groupWriteEvts(writeEvts, dbConsts)
+	if err != nil {
+		return DepGraph{}, err
+	}
+	evtDepEdges, g1 := deriveEvtDepEdges(wm, groupReadEvts(readEvts, dbConsts), writeMap, itmdMap)
+	evtDepEdges = append(evtDepEdges, derivePredicateEvtDepEdges(wm, readEvts, writeMap, dbConsts)...)
+
+	return DepGraph{
+		TxnIds:      txnIds,
+		Txns:        txns,
+		WriteEvts:   writeEvts,
+		ReadEvts:    readEvts,
+		EvtDepEdges: evtDepEdges,
+		TxnDepEdges: projectTxnDepEdges(evtDepEdges, dbConsts),
+		G1:          g1,
+		DBConsts:    dbConsts,
+	}, nil
+}
+
+func docId(collection string, key string) string {
+	return fmt.Sprintf("%s/%s", collection, key)
+}
+
+/*
+in-memory counterpart of queryReadEvts: {obj: {value read: read evt ids}}
+*/
+func groupReadEvts(readEvts []ReadEvt, dbConsts DBConsts) map[string]map[int][]string {
+	readMap := make(map[string]map[int][]string)
+	for _, evt := range readEvts {
+		if evt.IsScan() {
+			continue
+		}
+		if _, ok := readMap[evt.Obj]; !ok {
+			readMap[evt.Obj] = make(map[int][]string)
+		}
+		readMap[evt.Obj][evt.V] = append(readMap[evt.Obj][evt.V], docId(dbConsts.ReadEvtNode, evt.Key))
+	}
+	return readMap
+}
+
+/*
+in-memory counterpart of queryWriteEvts
+*/
+func groupWriteEvts(writeEvts []WriteEvt, dbConsts DBConsts) (map[string]map[int]string, map[string]map[int]bool, error) {
+	writeMap := make(map[string]map[int]string)
+	itmdMap := make(map[string]map[int]bool)
+	for _, evt := range writeEvts {
+		if _, ok := writeMap[evt.Obj]; !ok {
+			writeMap[evt.Obj] = make(map[int]string)
+			itmdMap[evt.Obj] = make(map[int]bool)
+		}
+		id := docId(dbConsts.WriteEvtNode, evt.Key)
+		if prev, ok := writeMap[evt.Obj][evt.Arg]; ok {
+			return nil, nil, fmt.Errorf("Anomaly: Multiple events %v write the same value %v to the same object %v. Non-recoverable.",
+				[]string{prev, id}, evt.Arg, evt.Obj)
+		}
+		writeMap[evt.Obj][evt.Arg] = id
+		if evt.Index != -1 {
+			itmdMap[evt.Obj][evt.Arg] = true
+		}
+	}
+	return writeMap, itmdMap, nil
+}
+
+/*
+derives the "rw-predicate" evt edges of the scans: a scan which did not observe a key in its range
+read it before its first version was written, these anti-dependencies are on the predicate of the
+scan rather than an item (phantoms). The keys a scan observed are read evts, with item edges.
+*/
+func derivePredicateEvtDepEdges(wm WALWriteMap, readEvts []ReadEvt, writeMap map[string]map[int]string, dbConsts DBConsts) []EvtDepEdge {
+	objs := make([]string, 0, len(wm))
+	for obj := range wm {
+		objs = append(objs, obj)
+	}
+	sort.Strings(objs)
+
+	// the keys observed by each scan: "i,j,x" is x observed by the scan "i,j"
+	observed := make(map[string]map[string]bool)
+	for _, evt := range readEvts {
+		if parts := strings.Split(evt.Key, ","); len(parts) == 3 {
+			scan := parts[0] + "," + parts[1]
+			if _, ok := observed[scan]; !ok {
+				observed[scan] = make(map[string]bool)
+			}
+			observed[scan][evt.Obj] = true
+		}
+	}
+
+	var evtDepEdges []EvtDepEdge
+	for _, evt := range readEvts {
+		if !evt.IsScan() {
+			continue
+		}
+		r := docId(dbConsts.ReadEvtNode, evt.Key)
+		for _, obj := range objs {
+			if observed[evt.Key][obj] || !core.KeyInRange(obj, evt.Lo, evt.Hi) || len(wm[obj]) == 0 {
+				continue
+			}
+			// rw-predicate: scan -> the write of the first version
+			if w, ok := writeMap[obj][wm[obj][0]]; ok && !happensBefore(r, w) {
+				evtDepEdges = append(evtDepEdges, EvtDepEdge{r, w, obj, "rw-predicate"})
+			}
+		}
+	}
+	return evtDepEdges
+}
+
+// the txn key of an evt id "<collection>/<txn>,<evt>"
+func evtTxnKey(id string) string {
+	return strings.Split(strings.SplitN(id, "/", 2)[1], ",")[0]
+}
+
+/*
+in-memory counterpart of getTxnDepEdges: evt edges between different txns projected
+to txn edges, one per (from, to, type) carrying the first pair of evts
+*/
+func projectTxnDepEdges(evtDepEdges []EvtDepEdge, dbConsts DBConsts) []TxnDepEdge {
+	type edgeKey struct{ from, to, typ string }
+	seen := make(map[edgeKey]bool)
+	txnDepEdges := make([]TxnDepEdge, 0, len(evtDepEdges))
+	for _, e := range evtDepEdges {
+		fromTxn, toTxn := evtTxnKey(e.From), evtTxnKey(e.To)
+		if fromTxn == toTxn {
+			continue
+		}
+		k := edgeKey{docId(dbConsts.TxnNode, fromTxn), docId(dbConsts.TxnNode, toTxn), e.Type}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		txnDepEdges = append(txnDepEdges, TxnDepEdge{From: k.from, To: k.to, FromEvt: e.From, ToEvt: e.To, Obj: e.Obj, Type: e.Type})
+	}
+	sort.SliceStable(txnDepEdges, func(i, j int) bool {
+		a, b := txnDepEdges[i], txnDepEdges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Type < b.Type
+	})
+	return txnDepEdges
+}
+
+/*
+RealtimeTxnDepEdges derives the "realtime" txn edges of the go-elle realtime graph: T1 -> T2 if T1
+completed before T2 was invoked, more than opts.RealtimeEpsilon before by the :time of the ops if it
+is set. The edges implied by transitivity are left out, they carry no evts.
+*/
+func RealtimeTxnDepEdges(opts txn.Opts, history core.History, dbConsts DBConsts) []TxnDepEdge {
+	history = preProcessHistory(history)
+	_, g, _ := txn.RealtimeAnalyzer(opts)(history)
+
+	txnId := func(v core.Vertex) (string, bool) {
+		op := v.Value.(core.Op)
+		return docId(dbConsts.TxnNode, strconv.Itoa(op.Index.MustGet())), op.Type == core.OpTypeOk
+	}
+	var txnDepEdges []TxnDepEdge
+	for from, outs := range g.Outs {
+		fromId, ok := txnId(from)
+		if !ok {
+			continue
+		}
+		for to := range outs {
+			// completions that failed or are unknown are no txns
+			if toId, ok := txnId(to); ok {
+				txnDepEdges = append(txnDepEdges, TxnDepEdge{From: fromId, To: toId, Type: string(core.Realtime)})
+			}
+		}
+	}
+	sort.Slice(txnDepEdges, func(i, j int) bool {
+		a, b := txnDepEdges[i], txnDepEdges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		return a.To < b.To
+	})
+	return txnDepEdges
+}
+
+/*
+WithRealtime returns the graph with the realtime edges of RealtimeTxnDepEdges added,
+its anti-patterns then include the violations of the realtime order (e.g. strict SER for SER)
+*/
+func (g DepGraph) WithRealtime(opts txn.Opts, history core.History) DepGraph {
+	edges := RealtimeTxnDepEdges(opts, history, g.DBConsts)
+	g.TxnDepEdges = append(append(make([]TxnDepEdge, 0, len(g.TxnDepEdges)+len(edges)), g.TxnDepEdges...), edges...)
+	return g
+}
+
+/*
+Export converts the txn graph for the exporters, edges keep their obj and evts
+*/
+func (g DepGraph) Export() export.Graph {
+	var eg export.Graph
+	for _, txn := range g.Txns {
+		eg.Nodes = append(eg.Nodes, export.Node{ID: docId(g.DBConsts.TxnNode, txn.Key), Key: txn.Key, Label: g.DBConsts.TxnNode})
+	}
+	eg.Edges = exportEdges(g.TxnDepEdges)
+	eg.Sort()
+	return eg
+}
+
+func exportEdges(edges []TxnDepEdge) []export.Edge {
+	exported := make([]export.Edge, 0, len(edges))
+	for _, e := range edges {
+		exported = append(exported, export.Edge{From: e.From, To: e.To, FromEvt: e.FromEvt, ToEvt: e.ToEvt, Obj: e.Obj, Type: e.Type})
+	}
+	return exported
+}
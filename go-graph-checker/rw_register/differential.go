@@ -0,0 +1,127 @@
+package rwregister
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+)
+
+/*
+DiffChecker checks a history with its WAL against all differential.Levels and its G1 anomalies with
+ConstructGraph and IsolationLevelChecker in the given mode (e.g. sv), the graphs are dropped
+afterwards. dbConsts needs a database of this run, see DBConsts.PerRun
+*/
+func DiffChecker(mode string, wal WAL, dbConsts DBConsts) differential.Checker {
+	return func(history core.History) differential.Verdicts {
+		db, txnIds, g1 := ConstructGraph(txn.Opts{}, history, wal, dbConsts)
+		defer dropGraphs(db, dbConsts)
+
+		return levelVerdicts(g1, func(level string) (bool, []TxnDepEdge) {
+			return IsolationLevelChecker(db, dbConsts, txnIds, false, level, mode)
+		})
+	}
+}
+
+/*
+DiffCheckerSQLite is the offline fallback of DiffChecker: the history is checked
+with the SQLite backend in the given mode (sv or all), without ArangoDB
+*/
+func DiffCheckerSQLite(mode string, wal WAL, dbConsts DBConsts) differential.Checker {
+	return func(history core.History) differential.Verdicts {
+		g := BuildDepGraph(history, wal, dbConsts)
+		db, err := LoadSQLite(g, ":memory:")
+		if err != nil {
+			log.Fatalf("Failed to load SQLite database: %v\n", err)
+		}
+		defer db.Close()
+
+		return levelVerdicts(g.G1, sqliteLevelChecker(db, g.TxnIds, mode))
+	}
+}
+
+// IsolationLevelChecker for the SQLite backend
+func sqliteLevelChecker(db *sql.DB, txnIds []int, mode string) func(level string) (bool, []TxnDepEdge) {
+	return func(level string) (bool, []TxnDepEdge) {
+		spec, ok := LookupLevel(level)
+		if !ok {
+			log.Fatalf("invalid level: %s, not from any of the registered levels\n", level)
+		}
+		return CheckLevelSQLite(db, txnIds, false, spec, mode)
+	}
+}
+
+// the verdicts of the G1 anomalies and of check on every differential.Levels
+func levelVerdicts(g1 G1Anomalies, check func(level string) (bool, []TxnDepEdge)) differential.Verdicts {
+	verdicts := differential.Verdicts{
+		"G1a": {Valid: !g1.G1a},
+		"G1b": {Valid: !g1.G1b},
+	}
+	for _, level := range differential.Levels {
+		valid, cycle := check(level)
+		verdict := differential.Verdict{Valid: valid}
+		if len(cycle) > 0 {
+			verdict.Witness = CycleAnomaly(cycle).Name + " " + cycleToStr(cycle)
+		}
+		verdicts[level] = verdict
+	}
+	return verdicts
+}
+
+/*
+DiffDirectory compares GRAIL on ArangoDB with go-elle on every .edn history under dir,
+the WAL of each history is read from the .log file of the same name.
+all histories share one database of a fresh run (unless dbConsts has a RunID), each history
+gets its own namespaced graphs, see CheckDirectory
+*/
+func DiffDirectory(dir string, mode string, dbConsts DBConsts) []differential.Diff {
+	if dbConsts.RunID == "" {
+		dbConsts = dbConsts.PerRun()
+	}
+	return diffDirectory(dir, func(fileName string, wal WAL) differential.Checker {
+		return DiffChecker(mode, wal, dbConsts.Namespaced(historyNamespace(fileName)))
+	})
+}
+
+/*
+DiffDirectorySQLite is DiffDirectory with the offline fallback DiffCheckerSQLite
+*/
+func DiffDirectorySQLite(dir string, mode string, dbConsts DBConsts) []differential.Diff {
+	return diffDirectory(dir, func(_ string, wal WAL) differential.Checker {
+		return DiffCheckerSQLite(mode, wal, dbConsts)
+	})
+}
+
+func diffDirectory(dir string, grail func(fileName string, wal WAL) differential.Checker) []differential.Diff {
+	files, err := filepath.Glob(filepath.Join(dir, "*.edn"))
+	if err != nil {
+		log.Fatalf("Cannot list histories in %s: %v\n", dir, err)
+	}
+	diffs := make([]differential.Diff, 0, len(files))
+	for _, fileName := range files {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			log.Fatalf("Cannot read edn file %s\n", fileName)
+		}
+		history, err := core.ParseHistoryRW(string(content))
+		if err != nil {
+			log.Fatalf("Cannot parse edn file %s\n", fileName)
+		}
+		walFileName := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".log"
+		walContent, err := os.ReadFile(walFileName)
+		if err != nil {
+			log.Fatalf("Cannot read wal log %s\n", walFileName)
+		}
+		wal, err := ParseWAL(string(walContent))
+		if err != nil {
+			log.Fatalf("Cannot parse wal log %s\n", walFileName)
+		}
+		diffs = append(diffs, differential.Compare(filepath.Base(fileName), history, grail(fileName, wal), differential.ElleRWRegister))
+	}
+	return diffs
+}
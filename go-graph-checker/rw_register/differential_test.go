@@ -0,0 +1,24 @@
+package rwregister
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffDirectorySQLite(t *testing.T) {
+	diffs := DiffDirectorySQLite("../histories/rw-register-test", "all", DefaultDBConsts())
+	byName := make(map[string][]string)
+	for _, d := range diffs {
+		for _, dis := range d.Disagreements {
+			byName[d.History] = append(byName[d.History], dis.Level)
+		}
+	}
+	// both checkers agree on the G1 anomalies
+	for _, name := range []string{"dirty-read.edn", "g1a.edn", "g1b-1.edn", "g1b-2.edn"} {
+		require.Empty(t, byName[name], name)
+	}
+	// without the WAL go-elle cannot order the versions, so the cycles are GRAIL's only
+	require.Equal(t, []string{"ser"}, byName["write-skew.edn"])
+	require.Equal(t, []string{"pl-1", "pl-2", "psi", "si", "ser"}, byName["g0.edn"])
+}
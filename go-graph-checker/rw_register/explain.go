@@ -0,0 +1,207 @@
+package rwregister
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/arangodb/go-driver"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+/*
+EvtDocs holds the evt documents the txn edges were derived from, keyed by their ids
+("<collection>/<key>"), to justify every edge of a witness cycle like the go-elle explainers
+*/
+type EvtDocs struct {
+	Writes map[string]WriteEvt
+	Reads  map[string]ReadEvt
+}
+
+/*
+reads the evt documents of edges from one of the backends, see reportCycle
+*/
+type evtSource func(edges []TxnDepEdge) EvtDocs
+
+/*
+EvtDocs returns the evt documents of the in-memory graph
+*/
+func (g DepGraph) EvtDocs() EvtDocs {
+	docs := EvtDocs{Writes: make(map[string]WriteEvt, len(g.WriteEvts)), Reads: make(map[string]ReadEvt, len(g.ReadEvts))}
+	for _, evt := range g.WriteEvts {
+		docs.Writes[docId(g.DBConsts.WriteEvtNode, evt.Key)] = evt
+	}
+	for _, evt := range g.ReadEvts {
+		docs.Reads[docId(g.DBConsts.ReadEvtNode, evt.Key)] = evt
+	}
+	return docs
+}
+
+/*
+the ids of the write and read evts of the edges, by the types of the edges:
+ww edges link writes, wr edges a write to a read and rw (or rw-predicate) edges a read to a write
+*/
+func evtIds(edges []TxnDepEdge) (writeIds []string, readIds []string) {
+	for _, e := range edges {
+		switch e.Type {
+		case "ww":
+			writeIds = append(writeIds, e.FromEvt, e.ToEvt)
+		case "wr":
+			writeIds = append(writeIds, e.FromEvt)
+			readIds = append(readIds, e.ToEvt)
+		case "rw", string(core.PredicateRW):
+			readIds = append(readIds, e.FromEvt)
+			writeIds = append(writeIds, e.ToEvt)
+		}
+	}
+	return writeIds, readIds
+}
+
+/*
+QueryEvtDocs reads the evt documents of the edges from ArangoDB
+*/
+func QueryEvtDocs(db driver.Database, edges []TxnDepEdge) EvtDocs {
+	writeIds, readIds := evtIds(edges)
+	return EvtDocs{Writes: queryDocs[WriteEvt](db, writeIds), Reads: queryDocs[ReadEvt](db, readIds)}
+}
+
+func arangoEvts(db driver.Database) evtSource {
+	return func(edges []TxnDepEdge) EvtDocs { return QueryEvtDocs(db, edges) }
+}
+
+// the documents of ids, missing documents are left out
+func queryDocs[T any](db driver.Database, ids []string) map[string]T {
+	docs := make(map[string]T, len(ids))
+	if len(ids) == 0 {
+		return docs
+	}
+	query := `
+		FOR id IN @ids
+			LET doc = DOCUMENT(id)
+			FILTER doc != null
+			RETURN {id: id, doc: doc}
+	`
+	cursor, err := db.Query(context.Background(), query, map[string]interface{}{"ids": ids})
+	if err != nil {
+		log.Fatalf("Query failed: %v\n", err)
+	}
+	defer cursor.Close()
+
+	for {
+		var found struct {
+			Id  string `json:"id"`
+			Doc T      `json:"doc"`
+		}
+		_, err := cursor.ReadDocument(context.Background(), &found)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			log.Fatalf("Cannot read return values: %v\n", err)
+		}
+		docs[found.Id] = found.Doc
+	}
+	return docs
+}
+
+/*
+QuerySQLiteEvtDocs reads the evt documents of the edges from the SQLite backend
+*/
+func QuerySQLiteEvtDocs(db *sql.DB, edges []TxnDepEdge) (EvtDocs, error) {
+	writeIds, readIds := evtIds(edges)
+	docs := EvtDocs{Writes: make(map[string]WriteEvt, len(writeIds)), Reads: make(map[string]ReadEvt, len(readIds))}
+	for _, id := range writeIds {
+		evt := WriteEvt{Key: evtDocKey(id)}
+		err := db.QueryRow(`SELECT obj, arg, idx FROM write_evt WHERE id = ?`, id).Scan(&evt.Obj, &evt.Arg, &evt.Index)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return EvtDocs{}, err
+		}
+		docs.Writes[id] = evt
+	}
+	for _, id := range readIds {
+		evt := ReadEvt{Key: evtDocKey(id)}
+		err := db.QueryRow(`SELECT obj, v, lo, hi FROM read_evt WHERE id = ?`, id).Scan(&evt.Obj, &evt.V, &evt.Lo, &evt.Hi)
+		if err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return EvtDocs{}, err
+		}
+		docs.Reads[id] = evt
+	}
+	return docs, nil
+}
+
+func sqliteEvts(db *sql.DB) evtSource {
+	return func(edges []TxnDepEdge) EvtDocs {
+		docs, err := QuerySQLiteEvtDocs(db, edges)
+		if err != nil {
+			log.Fatalf("Cannot read evt documents: %v\n", err)
+		}
+		return docs
+	}
+}
+
+// the document key of an id "<collection>/<key>"
+func evtDocKey(id string) string {
+	return strings.SplitN(id, "/", 2)[1]
+}
+
+// "T12" for the txn id "txn/12"
+func txnName(id string) string {
+	return "T" + evtDocKey(id)
+}
+
+/*
+ExplainEdge justifies a txn edge from its evt documents, e.g. "T12 wrote 5 to key x, T40 read 5 of x"
+for wr or "T40 read 5 of x before 7 was written by T55" for rw; without the documents, only the type and key are given
+*/
+func (docs EvtDocs) ExplainEdge(e TxnDepEdge) string {
+	a, b := txnName(e.From), txnName(e.To)
+	switch e.Type {
+	case "ww":
+		pre, preOk := docs.Writes[e.FromEvt]
+		post, postOk := docs.Writes[e.ToEvt]
+		if preOk && postOk {
+			return fmt.Sprintf("%s wrote %d to key %s, %s overwrote it with %d", a, pre.Arg, pre.Obj, b, post.Arg)
+		}
+	case "wr":
+		w, wOk := docs.Writes[e.FromEvt]
+		r, rOk := docs.Reads[e.ToEvt]
+		if wOk && rOk {
+			return fmt.Sprintf("%s wrote %d to key %s, %s read %d of %s", a, w.Arg, w.Obj, b, r.V, r.Obj)
+		}
+	case "rw":
+		r, rOk := docs.Reads[e.FromEvt]
+		w, wOk := docs.Writes[e.ToEvt]
+		if rOk && wOk {
+			// 0 is the initial nil
+			if r.V == 0 {
+				return fmt.Sprintf("%s read %s before %d was written by %s", a, r.Obj, w.Arg, b)
+			}
+			return fmt.Sprintf("%s read %d of %s before %d was written by %s", a, r.V, r.Obj, w.Arg, b)
+		}
+	case string(core.PredicateRW):
+		r, rOk := docs.Reads[e.FromEvt]
+		w, wOk := docs.Writes[e.ToEvt]
+		if rOk && wOk {
+			return fmt.Sprintf("%s scanned [%s %s] without observing key %s before %d was written to it by %s", a, r.Lo, r.Hi, w.Obj, w.Arg, b)
+		}
+	case string(core.Realtime):
+		return fmt.Sprintf("%s completed before %s was invoked", a, b)
+	}
+	return fmt.Sprintf("%s (%s) %s on key %s", a, e.Type, b, e.Obj)
+}
+
+/*
+ExplainCycle justifies every edge of a cycle, see ExplainEdge
+*/
+func (docs EvtDocs) ExplainCycle(cycle []TxnDepEdge) []string {
+	lines := make([]string, len(cycle))
+	for i, e := range cycle {
+		lines[i] = docs.ExplainEdge(e)
+	}
+	return lines
+}
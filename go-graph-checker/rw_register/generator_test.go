@@ -0,0 +1,56 @@
+package rwregister
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeneratedHistories(t *testing.T) {
+	// the weakest level an injected anomaly violates, G1a and G1b are flags;
+	// G0, write skew and lost update cannot be injected into rw-register histories
+	weakest := map[generator.Anomaly]string{
+		generator.G1c:      "pl-2",
+		generator.GSingle:  "psi",
+		generator.LongFork: "si",
+	}
+	for _, a := range append([]generator.Anomaly{""}, generator.Anomalies...) {
+		opts := generator.DefaultOpts()
+		opts.Workload, opts.Txns = generator.RWRegister, 20
+		if a != "" {
+			opts.Inject = []generator.Anomaly{a}
+		}
+		r, err := generator.Generate(opts)
+		if a != "" && !generator.Injectable(generator.RWRegister, a) {
+			require.Error(t, err, a)
+			continue
+		}
+		require.NoError(t, err)
+
+		// through EDN and the WAL, as GRAIL reads them
+		var edn strings.Builder
+		for _, op := range r.History {
+			edn.WriteString(op.String() + "\n")
+		}
+		history, err := core.ParseHistoryRW(edn.String())
+		require.NoError(t, err)
+		var log bytes.Buffer
+		require.NoError(t, WriteWAL(&log, WALFromVersions(r.Versions)))
+		wal, err := ParseWAL(log.String())
+		require.NoError(t, err)
+
+		verdicts := DiffCheckerSQLite("all", wal, DefaultDBConsts())(history)
+		require.Equal(t, a != generator.G1a, verdicts["G1a"].Valid, a)
+		require.Equal(t, a != generator.G1b, verdicts["G1b"].Valid, a)
+		violated := false
+		for _, level := range differential.Levels {
+			violated = violated || level == weakest[a]
+			require.Equal(t, !violated, verdicts[level].Valid, "%s %s %s", a, level, verdicts[level].Witness)
+		}
+	}
+}
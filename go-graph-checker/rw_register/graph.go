@@ -4,11 +4,11 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/arangodb/go-driver"
-	"github.com/arangodb/go-driver/http"
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
 )
 
@@ -34,10 +34,23 @@ type WriteEvt struct {
 	Index int    `json:"index"` // -1 means last write
 }
 
+/*
+A scan [lo hi] of txn i at evt j is stored as the read evt "i,j" with the range and no obj,
+and a read evt "i,j,x" for every key x it observed, which is read like a single key
+*/
 type ReadEvt struct {
 	Key string `json:"_key"`
 	Obj string `json:"obj"`
 	V   int    `json:"v"` // 0 ~ nil, >=1 ~ possible values
+	Lo  string `json:"lo,omitempty"`
+	Hi  string `json:"hi,omitempty"`
+}
+
+/*
+IsScan tells whether the evt is the range of a scan
+*/
+func (e ReadEvt) IsScan() bool {
+	return e.Lo != ""
 }
 
 type DBConsts struct {
@@ -51,6 +64,9 @@ type DBConsts struct {
 	ReadEvtNode  string
 	TxnDepEdge   string
 	EvtDepEdge   string
+	Overwrite    bool   // drop an existing checker-owned DB of the same name
+	RunID        string // identifies the run that owns DB, see PerRun
+	Conn         ConnConfig
 }
 
 /*
@@ -65,41 +81,54 @@ func preProcessHistory(history core.History) core.History {
 /*
 returns a client instance of ArangoDB
 */
-func startClient(host string, port int) driver.Client {
-	endpoint := fmt.Sprintf("http://%s:%d", host, port)
-	conn, err := http.NewConnection(http.ConnectionConfig{
-		Endpoints: []string{endpoint},
-	})
-	if err != nil {
-		log.Fatalf("Failed to connect to the host %s at port %d: %v\n", host, port, err)
-	}
-	client, err := driver.NewClient(driver.ClientConfig{
-		Connection: conn,
-	})
+func startClient(dbConsts DBConsts) driver.Client {
+	client, err := newClient(dbConsts)
 	if err != nil {
-		log.Fatalf("Failed to create a new client: %v\n", err)
+		log.Fatalf("Failed to connect to %v: %v\n", dbConsts.endpoints(), err)
 	}
 	return client
 }
 
 /*
 get db if db already exists, otherwise create db
+
+an existing db is only dropped if it carries the checker marker
+and dbConsts.Overwrite is set; any other db is left untouched.
+a db created by the same run (dbConsts.RunID) is reused as it is
 */
-func getOrCreateDB(client driver.Client, dbName string) driver.Database {
+func getOrCreateDB(client driver.Client, dbConsts DBConsts) driver.Database {
+	dbName := dbConsts.DB
 	if dbExists, _ := client.DatabaseExists(context.Background(), dbName); dbExists {
-		log.Println("db exists already and will be dropped first...")
 		db, err := client.Database(context.Background(), dbName)
 		if err != nil {
 			log.Fatalf("Failed to open existing database: %v\n", err)
 		}
-		// forcibly drop the database for a new checker
-		db.Remove(context.Background())
+		marker, owned, err := readMarker(db)
+		if err != nil {
+			log.Fatalf("Database %s: %v\n", dbName, err)
+		}
+		if !owned {
+			log.Fatalf("Database %s exists but is not owned by the checker (no %s marker). Refusing to drop it.\n",
+				dbName, MarkerCollection)
+		}
+		if dbConsts.RunID != "" && marker.RunID == dbConsts.RunID {
+			return db
+		}
+		if !dbConsts.Overwrite {
+			log.Fatalf("Database %s was created by run %s. Set Overwrite to drop it or use PerRun for a fresh database.\n",
+				dbName, marker.RunID)
+		}
+		log.Printf("db %s of run %s exists already and will be dropped first...\n", dbName, marker.RunID)
+		if err := db.Remove(context.Background()); err != nil {
+			log.Fatalf("Failed to drop database %s: %v\n", dbName, err)
+		}
 	}
 
 	db, err := client.CreateDatabase(context.Background(), dbName, nil)
 	if err != nil {
 		log.Fatalf("Failed to create database: %v\n", err)
 	}
+	writeMarker(db, dbConsts.RunID)
 	return db
 }
 
@@ -111,7 +140,7 @@ func evtKey(i int, j int) string {
 returns db and graph
 */
 func createGraph(client driver.Client, dbConsts DBConsts) (driver.Database, driver.Graph, driver.Graph) {
-	db := getOrCreateDB(client, dbConsts.DB)
+	db := getOrCreateDB(client, dbConsts)
 
 	txnDepEdgeDef := driver.EdgeDefinition{
 		Collection: dbConsts.TxnDepEdge,
@@ -195,9 +224,9 @@ func createGraph(client driver.Client, dbConsts DBConsts) (driver.Database, driv
 }
 
 /*
-create nodes: txns, writeEvts & readEvts
+collect nodes from the ok history: txns, writeEvts & readEvts
 */
-func createNodes(txnGraph driver.Graph, evtGraph driver.Graph, okHistory core.History, dbConsts DBConsts) []int {
+func collectNodes(okHistory core.History) ([]TxnNode, []int, []WriteEvt, []ReadEvt) {
 	txns := make([]TxnNode, 0, len(okHistory))
 	txnIds := make([]int, 0, len(okHistory))
 	// init by assuming each txn has one write, two reads on avg
@@ -222,10 +251,26 @@ func createNodes(txnGraph driver.Graph, evtGraph driver.Graph, okHistory core.Hi
 				}
 				// mark those "first reads" with index 0
 				readEvts = append(readEvts, ReadEvt{
-					evtKey(txnId, j),
-					v.GetKey(),
-					readVal.(int),
+					Key: evtKey(txnId, j),
+					Obj: v.GetKey(),
+					V:   readVal.(int),
 				})
+			} else if v.IsScan() {
+				lo, hi := v.GetRange()
+				readEvts = append(readEvts, ReadEvt{Key: evtKey(txnId, j), Lo: lo, Hi: hi})
+				observed, _ := v.GetValue().(map[string]int)
+				objs := make([]string, 0, len(observed))
+				for obj := range observed {
+					objs = append(objs, obj)
+				}
+				sort.Strings(objs)
+				for _, obj := range objs {
+					readEvts = append(readEvts, ReadEvt{
+						Key: evtKey(txnId, j) + "," + obj,
+						Obj: obj,
+						V:   observed[obj],
+					})
+				}
 			} else if v.IsWrite() {
 				writeEvts = append(writeEvts, WriteEvt{
 					evtKey(txnId, j),
@@ -243,6 +288,15 @@ func createNodes(txnGraph driver.Graph, evtGraph driver.Graph, okHistory core.Hi
 		}
 	}
 
+	return txns, txnIds, writeEvts, readEvts
+}
+
+/*
+create nodes: txns, writeEvts & readEvts
+*/
+func createNodes(txnGraph driver.Graph, evtGraph driver.Graph, okHistory core.History, dbConsts DBConsts) []int {
+	txns, txnIds, writeEvts, readEvts := collectNodes(okHistory)
+
 	txnNodes, err := txnGraph.VertexCollection(context.Background(), dbConsts.TxnNode)
 	if err != nil {
 		log.Fatalf("Failed to get node collection: %v\n", err)
@@ -292,6 +346,7 @@ returns an array of read-events info
 */
 /*
 FOR e1 IN r_evt
+	FILTER e1.lo == null
 	COLLECT obj = e1.obj INTO objs
 	RETURN { obj, traces: (
 		FOR e2 in objs[*].e1
@@ -301,17 +356,19 @@ FOR e1 IN r_evt
 */
 
 func queryReadEvts(db driver.Database, dbConsts DBConsts) map[string]map[int][]string {
-	query := fmt.Sprintf(`
-		FOR e1 IN %s
+	query := `
+		FOR e1 IN @@evts
+			FILTER e1.lo == null
 			COLLECT obj = e1.obj INTO objs
 			RETURN { obj, traces: (
 				FOR e2 in objs[*].e1
 					COLLECT val = e2.v INTO vals
 					RETURN { val, ids: vals[*].e2._id }
 			)}
-	`, dbConsts.ReadEvtNode)
+	`
 
-	cursor, err := db.Query(context.Background(), query, nil)
+	bindVars := map[string]interface{}{"@evts": dbConsts.ReadEvtNode}
+	cursor, err := db.Query(context.Background(), query, bindVars)
 
 	if err != nil {
 		log.Fatalf("Query failed: %v\n", err)
@@ -357,17 +414,18 @@ type WriteEvtsElement struct {
 returns a write map {obj1: {key1: id1, key2: id2, ...}, ...}
 */
 func queryWriteEvts(db driver.Database, dbConsts DBConsts) (map[string]map[int]string, map[string]map[int]bool) {
-	query := fmt.Sprintf(`
-		FOR e1 IN %s
+	query := `
+		FOR e1 IN @@evts
 			COLLECT obj = e1.obj into objs
 			RETURN { obj, evts: (
 				FOR e2 in objs[*].e1
 					COLLECT element = e2.arg INTO elements
 					RETURN { element, ids: elements[*].e2._id, write_idx: elements[*].e2.index }
 			)}
-	`, dbConsts.WriteEvtNode)
+	`
 
-	cursor, err := db.Query(context.Background(), query, nil)
+	bindVars := map[string]interface{}{"@evts": dbConsts.WriteEvtNode}
+	cursor, err := db.Query(context.Background(), query, bindVars)
 
 	if err != nil {
 		log.Fatalf("Query failed: %v\n", err)
@@ -454,6 +512,14 @@ func happensBefore(id1 string, id2 string) bool {
 func getEvtDepEdges(db driver.Database, wm WALWriteMap, dbConsts DBConsts) ([]EvtDepEdge, G1Anomalies) {
 	readsInfoMap := queryReadEvts(db, dbConsts)
 	writesInfoMap, itmdMap := queryWriteEvts(db, dbConsts)
+	return deriveEvtDepEdges(wm, readsInfoMap, writesInfoMap, itmdMap)
+}
+
+/*
+derives the evt dependency edges from the WAL write map, the read map and the write map
+*/
+func deriveEvtDepEdges(wm WALWriteMap, readsInfoMap map[string]map[int][]string,
+	writesInfoMap map[string]map[int]string, itmdMap map[string]map[int]bool) ([]EvtDepEdge, G1Anomalies) {
 
 	evtDepEdges := make([]EvtDepEdge, 0, len(readsInfoMap)*3)
 
@@ -632,35 +698,39 @@ type TxnDepEdge struct {
 	To      string `json:"_to"`
 	FromEvt string `json:"from_evt"`
 	ToEvt   string `json:"to_evt"`
+	Obj     string `json:"obj"`
 	Type    string `json:"type"`
 }
 
 func getTxnDepEdges(db driver.Database, evtDepEdges []EvtDepEdge, dbConst DBConsts) []TxnDepEdge {
-	query := fmt.Sprintf(`
+	query := `
 		LET projs = (
-			FOR d IN %s
+			FOR d IN @@deps
 				LET from_txn = SPLIT(d._from, ["/", ","])[1]
 				LET to_txn = SPLIT(d._to, ["/", ","])[1]
 				FILTER from_txn != to_txn
-				RETURN { _from: CONCAT("txn/", from_txn), _to: CONCAT("txn/", to_txn),
-					from_evt: d._from, to_evt: d._to, type: d.type }
+				RETURN { _from: CONCAT(@txn, "/", from_txn), _to: CONCAT(@txn, "/", to_txn),
+					from_evt: d._from, to_evt: d._to, obj: d.obj, type: d.type }
 			)
 	
 		FOR proj IN projs
 			COLLECT from = proj._from, to = proj._to, type = proj.type INTO groups = {
 				"from_evt": proj.from_evt,
-				"to_evt": proj.to_evt
+				"to_evt": proj.to_evt,
+				"obj": proj.obj
 			}
 			RETURN {
 				"_from": from,
 				"_to": to,
 				"type": type,
 				"from_evt": groups[0].from_evt,
-				"to_evt": groups[0].to_evt
+				"to_evt": groups[0].to_evt,
+				"obj": groups[0].obj
 			}
-	`, dbConst.EvtDepEdge)
+	`
 
-	cursor, err := db.Query(context.Background(), query, nil)
+	bindVars := map[string]interface{}{"txn": dbConst.TxnNode, "@deps": dbConst.EvtDepEdge}
+	cursor, err := db.Query(context.Background(), query, bindVars)
 
 	if err != nil {
 		log.Fatalf("Query failed: %v\n", err)
@@ -701,8 +771,10 @@ func addDepEdges(db driver.Database, txnGraph driver.Graph, evtGraph driver.Grap
 	}
 
 	// projections from evts to txns
-	txnDepEdges := getTxnDepEdges(db, evtDepEdges, dbConsts)
+	addTxnDepEdges(txnGraph, dbConsts, getTxnDepEdges(db, evtDepEdges, dbConsts))
+}
 
+func addTxnDepEdges(txnGraph driver.Graph, dbConsts DBConsts, txnDepEdges []TxnDepEdge) {
 	txnDepEdgeCol, _, err := txnGraph.EdgeCollection(context.Background(), dbConsts.TxnDepEdge)
 	if err != nil {
 		log.Fatalf("Failed to get edge collection: %v\n", err)
@@ -0,0 +1,201 @@
+package rwregister
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/arangodb/go-driver"
+)
+
+/*
+LongFork is the four-txn witness of a long fork: T1 and T2 wrote the keys x and y concurrently,
+T3 observed the write of T1 but not that of T2, and T4 the write of T2 but not that of T1,
+so the two readers saw the writers in opposite orders. Edges are the cycle
+T1 -wr-> T3 -rw-> T2 -wr-> T4 -rw-> T1, and Keys are x and y.
+
+A long fork has two non-adjacent rw edges: PSI allows it, SI does not.
+*/
+type LongFork struct {
+	Keys  []string     `json:"keys"`
+	Edges []TxnDepEdge `json:"edges"`
+}
+
+/*
+Writers are T1 and T2
+*/
+func (f LongFork) Writers() (string, string) {
+	return f.Edges[0].From, f.Edges[2].From
+}
+
+/*
+Readers are T3 and T4
+*/
+func (f LongFork) Readers() (string, string) {
+	return f.Edges[0].To, f.Edges[2].To
+}
+
+/*
+e.g. "long fork T1 (wr) T3 (rw) T2 (wr) T4 (rw) T1 on x, y"
+*/
+func (f LongFork) String() string {
+	return fmt.Sprintf("long fork %s on %s, %s", cycleToStr(f.Edges), f.Keys[0], f.Keys[1])
+}
+
+/*
+whether the edges a, b, c and d, of the types wr, rw, wr and rw, are a long fork: a cycle through
+four distinct txns whose wr edges are on different keys, each read by the rw edge of the other reader.
+A long fork is the cycle from both of its writers, it is kept from the writer of the lower index.
+*/
+func isLongFork(a, b, c, d TxnDepEdge) bool {
+	return d.To == a.From && b.To != a.From && c.To != a.To && c.To != a.From &&
+		a.Obj == d.Obj && b.Obj == c.Obj && a.Obj != b.Obj &&
+		txnIndex(a.From) < txnIndex(b.To)
+}
+
+func newLongFork(a, b, c, d TxnDepEdge) LongFork {
+	return LongFork{Keys: []string{a.Obj, b.Obj}, Edges: []TxnDepEdge{a, b, c, d}}
+}
+
+func sortLongForks(forks []LongFork) {
+	sort.Slice(forks, func(i, j int) bool {
+		for k := range forks[i].Edges {
+			fi, fj := txnIndex(forks[i].Edges[k].From), txnIndex(forks[j].Edges[k].From)
+			if fi != fj {
+				return fi < fj
+			}
+		}
+		return false
+	})
+}
+
+/*
+LongForkEdges projects the wr and rw evt edges between different txns to one txn edge per
+(from, to, type, obj) like PairEdges: DepGraph.TxnDepEdges keep the key of a single evt edge of each type,
+so a txn pair depending on several keys would lose the keys the long fork is on
+*/
+func LongForkEdges(evtDepEdges []EvtDepEdge, dbConsts DBConsts) []TxnDepEdge {
+	return keyEdges(evtDepEdges, dbConsts, "wr", "rw")
+}
+
+/*
+LongForks finds the long forks among the txn edges of LongForkEdges, ordered by
+the indexes of T1, T3, T2 and T4
+*/
+func LongForks(edges []TxnDepEdge) []LongFork {
+	outs := make(map[string]map[string][]TxnDepEdge)
+	for _, e := range edges {
+		if e.Type != "wr" && e.Type != "rw" {
+			continue
+		}
+		if outs[e.Type] == nil {
+			outs[e.Type] = make(map[string][]TxnDepEdge)
+		}
+		outs[e.Type][e.From] = append(outs[e.Type][e.From], e)
+	}
+
+	var forks []LongFork
+	for _, a := range edges {
+		if a.Type != "wr" {
+			continue
+		}
+		for _, b := range outs["rw"][a.To] {
+			for _, c := range outs["wr"][b.To] {
+				for _, d := range outs["rw"][c.To] {
+					if isLongFork(a, b, c, d) {
+						forks = append(forks, newLongFork(a, b, c, d))
+					}
+				}
+			}
+		}
+	}
+	sortLongForks(forks)
+	return forks
+}
+
+/*
+the evt edges of LongForkEdges
+*/
+const sqliteLongForkEdgesQuery = `SELECT from_evt, to_evt, obj, type FROM evt_dep WHERE type IN ('wr', 'rw')`
+
+/*
+QuerySQLiteLongForks is LongForks on the evt edges of the SQLite backend
+*/
+func QuerySQLiteLongForks(db *sql.DB, dbConsts DBConsts) ([]LongFork, error) {
+	edges, err := querySQLiteEvtEdges(db, sqliteLongForkEdgesQuery)
+	if err != nil {
+		return nil, err
+	}
+	return LongForks(LongForkEdges(edges, dbConsts)), nil
+}
+
+/*
+the AQL counterpart of sqliteLongForkEdgesQuery
+*/
+func longForkEdgesQuery(dbConsts DBConsts) AQL {
+	return evtEdgesQuery(dbConsts, "wr", "rw")
+}
+
+/*
+QueryLongForks is LongForks on the evt edges in ArangoDB
+*/
+func QueryLongForks(db driver.Database, dbConsts DBConsts) []LongFork {
+	return LongForks(LongForkEdges(queryEvtEdges(db, longForkEdgesQuery(dbConsts)), dbConsts))
+}
+
+/*
+ExplainLongFork tells which writes each reader observed, then justifies every edge of the
+witness with the keys and the values read, see ExplainEdge
+*/
+func (docs EvtDocs) ExplainLongFork(f LongFork) []string {
+	w1, w2 := f.Writers()
+	r1, r2 := f.Readers()
+	lines := []string{fmt.Sprintf("%s wrote key %s and %s key %s concurrently, %s observed %s but not %s, %s observed %s but not %s",
+		txnName(w1), f.Keys[0], txnName(w2), f.Keys[1],
+		txnName(r1), txnName(w1), txnName(w2), txnName(r2), txnName(w2), txnName(w1))}
+	return append(lines, docs.ExplainCycle(f.Edges)...)
+}
+
+func reportLongForks(mode string, forks []LongFork, output bool, evts evtSource) {
+	if output {
+		log.Printf("Long forks detected by %s: %d.\n", mode, len(forks))
+		for _, f := range forks {
+			log.Println(f)
+			for _, line := range evts(f.Edges).ExplainLongFork(f) {
+				log.Println("  " + line)
+			}
+		}
+	}
+}
+
+/*
+CheckLongForks finds the long forks of the in-memory graph and, with output, logs every witness
+*/
+func (g DepGraph) CheckLongForks(output bool) []LongFork {
+	forks := LongForks(LongForkEdges(g.EvtDepEdges, g.DBConsts))
+	docs := g.EvtDocs()
+	reportLongForks("in-memory graph", forks, output, func([]TxnDepEdge) EvtDocs { return docs })
+	return forks
+}
+
+/*
+CheckLongForks finds the long forks in ArangoDB and, with output, logs every witness
+*/
+func CheckLongForks(db driver.Database, dbConsts DBConsts, output bool) []LongFork {
+	forks := QueryLongForks(db, dbConsts)
+	reportLongForks("AQL", forks, output, arangoEvts(db))
+	return forks
+}
+
+/*
+CheckLongForksSQLite is CheckLongForks for the SQLite backend
+*/
+func CheckLongForksSQLite(db *sql.DB, dbConsts DBConsts, output bool) []LongFork {
+	forks, err := QuerySQLiteLongForks(db, dbConsts)
+	if err != nil {
+		log.Fatalf("Failed to find long forks: %v\n", err)
+	}
+	reportLongForks("SQLite", forks, output, sqliteEvts(db))
+	return forks
+}
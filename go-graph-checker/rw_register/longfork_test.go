@@ -0,0 +1,44 @@
+package rwregister
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongForks(t *testing.T) {
+	history, wal := readTestHistory(t, "long-fork")
+	g := BuildDepGraph(history, wal, DefaultDBConsts())
+	forks := LongForks(LongForkEdges(g.EvtDepEdges, g.DBConsts))
+	require.Len(t, forks, 1)
+	require.Equal(t, "long fork T0 (wr) T1 (rw) T2 (wr) T3 (rw) T0 on 1, 2", forks[0].String())
+	require.Equal(t, []string{
+		"T0 wrote key 1 and T2 key 2 concurrently, T1 observed T0 but not T2, T3 observed T2 but not T0",
+		"T0 wrote 1 to key 1, T1 read 1 of 1",
+		"T1 read 2 before 1 was written by T2",
+		"T2 wrote 1 to key 2, T3 read 1 of 2",
+		"T3 read 1 before 1 was written by T0",
+	}, g.EvtDocs().ExplainLongFork(forks[0]))
+
+	db, _, _ := constructSQLite(t, "long-fork")
+	require.Equal(t, forks, CheckLongForksSQLite(db, g.DBConsts, false))
+
+	// the other histories have no long fork
+	for _, name := range []string{"g-single", "lost-update", "write-skew"} {
+		history, wal := readTestHistory(t, name)
+		g := BuildDepGraph(history, wal, DefaultDBConsts())
+		require.Empty(t, LongForks(LongForkEdges(g.EvtDepEdges, g.DBConsts)), name)
+	}
+}
+
+func TestLongForksTwoKeys(t *testing.T) {
+	// T3 depends on T0 by both keys 1 and 3, the txn edge T3 -rw-> T0 keeps only one of them
+	history, wal := readTestHistory(t, "long-fork-two-keys")
+	g := BuildDepGraph(history, wal, DefaultDBConsts())
+	forks := LongForks(LongForkEdges(g.EvtDepEdges, g.DBConsts))
+	require.Len(t, forks, 1)
+	require.Equal(t, "long fork T0 (wr) T1 (rw) T2 (wr) T3 (rw) T0 on 1, 2", forks[0].String())
+
+	db, _, _ := constructSQLite(t, "long-fork-two-keys")
+	require.Equal(t, forks, CheckLongForksSQLite(db, g.DBConsts, false))
+}
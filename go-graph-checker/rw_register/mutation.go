@@ -0,0 +1,42 @@
+package rwregister
+
+import (
+	"fmt"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/mutation"
+)
+
+/*
+MutationChecker is DiffChecker for mutants of a history with its WAL: the histories GRAIL cannot trace
+(the same value written twice) are reported as errors instead of exiting,
+the others are checked on ArangoDB with IsolationLevelChecker
+*/
+func MutationChecker(mode string, wal WAL, dbConsts DBConsts) mutation.Checker {
+	grail := DiffChecker(mode, wal, dbConsts)
+	return func(history core.History) (differential.Verdicts, error) {
+		if _, err := TryBuildDepGraph(history, wal, dbConsts); err != nil {
+			return nil, err
+		}
+		return grail(history), nil
+	}
+}
+
+/*
+MutationCheckerSQLite is the offline fallback of MutationChecker, see DiffCheckerSQLite
+*/
+func MutationCheckerSQLite(mode string, wal WAL, dbConsts DBConsts) mutation.Checker {
+	return func(history core.History) (differential.Verdicts, error) {
+		g, err := TryBuildDepGraph(history, wal, dbConsts)
+		if err != nil {
+			return nil, err
+		}
+		db, err := LoadSQLite(g, ":memory:")
+		if err != nil {
+			return nil, fmt.Errorf("failed to load SQLite database: %v", err)
+		}
+		defer db.Close()
+		return levelVerdicts(g.G1, sqliteLevelChecker(db, g.TxnIds, mode)), nil
+	}
+}
@@ -0,0 +1,50 @@
+package rwregister
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/mutation"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMutationKillRates(t *testing.T) {
+	opts := generator.DefaultOpts()
+	opts.Workload, opts.Txns, opts.FailRate, opts.InfoRate = generator.RWRegister, 30, 0, 0
+	r, err := generator.Generate(opts)
+	require.NoError(t, err)
+	var edn strings.Builder
+	for _, op := range r.History {
+		edn.WriteString(op.String() + "\n")
+	}
+	history, err := core.ParseHistoryRW(edn.String())
+	require.NoError(t, err)
+	var log bytes.Buffer
+	require.NoError(t, WriteWAL(&log, WALFromVersions(r.Versions)))
+	wal, err := ParseWAL(log.String())
+	require.NoError(t, err)
+
+	checkers := map[string]mutation.Checker{
+		"grail-sqlite": MutationCheckerSQLite("sv", wal, DefaultDBConsts()),
+		"go-elle":      mutation.ElleRWRegister,
+	}
+	report, err := mutation.Run("generated", history, checkers, mutation.Options{Mutants: 5, Seed: 1})
+	require.NoError(t, err)
+	require.Len(t, report.Scores, 2*len(mutation.Operators))
+	for _, s := range report.Scores {
+		switch s.Operator {
+		case mutation.DropAppend.Name, mutation.ReorderList.Name:
+			// registers hold no lists
+			require.Zero(t, s.Mutants, s)
+		default:
+			require.Equal(t, 5, s.Mutants, s)
+			if s.Checker == "grail-sqlite" {
+				// non-traceable, G1a or new cycles
+				require.Equal(t, 1.0, s.Rate, s)
+			}
+		}
+	}
+}
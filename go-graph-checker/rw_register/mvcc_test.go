@@ -0,0 +1,53 @@
+package rwregister
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/generator"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/mvcc"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/differential"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMVCCGuarantees(t *testing.T) {
+	for _, isolation := range mvcc.Isolations {
+		guaranteed := make(map[string]bool)
+		for _, level := range mvcc.Guarantees[isolation] {
+			guaranteed[level] = true
+		}
+		violated := make(map[string]bool)
+		for seed := int64(1); seed <= 20; seed++ {
+			opts := mvcc.DefaultOpts(isolation)
+			opts.Workload, opts.Txns, opts.Seed = generator.RWRegister, 30, seed
+			r, err := mvcc.Run(opts)
+			require.NoError(t, err)
+
+			var edn strings.Builder
+			for _, op := range r.History {
+				edn.WriteString(op.String() + "\n")
+			}
+			history, err := core.ParseHistoryRW(edn.String())
+			require.NoError(t, err)
+			var log bytes.Buffer
+			require.NoError(t, WriteWAL(&log, WALFromVersions(r.Versions)))
+			wal, err := ParseWAL(log.String())
+			require.NoError(t, err)
+
+			verdicts := DiffCheckerSQLite("sv", wal, DefaultDBConsts())(history)
+			for _, level := range differential.Levels {
+				if guaranteed[level] {
+					require.True(t, verdicts[level].Valid, "%s seed %d %s: %s", isolation, seed, level, verdicts[level].Witness)
+				} else if !verdicts[level].Valid {
+					violated[level] = true
+				}
+			}
+		}
+		// exactly: every level the isolation does not guarantee is violated by some history
+		for _, level := range differential.Levels {
+			require.Equal(t, !guaranteed[level], violated[level], "%s %s", isolation, level)
+		}
+	}
+}
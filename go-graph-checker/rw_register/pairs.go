@@ -0,0 +1,313 @@
+package rwregister
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/arangodb/go-driver"
+)
+
+const (
+	LostUpdate = "lost-update" // T1 -rw-> T2 -ww-> T1 on the same key, the P4 that SI prohibits
+	WriteSkew  = "write-skew"  // T1 -rw-> T2 -rw-> T1 without ww between them, the G2-item that SER prohibits
+)
+
+/*
+PairCase is a lost update or a write skew of two txns, Edges are the two edges of the
+cycle T1 -> T2 -> T1 and Keys the keys of the edges: for a lost update those of both
+an rw edge T1 -> T2 and a ww edge T2 -> T1, for a write skew those of the rw edges
+*/
+type PairCase struct {
+	Type  string       `json:"type"`
+	Keys  []string     `json:"keys"`
+	Edges []TxnDepEdge `json:"edges"`
+}
+
+/*
+e.g. "lost-update T3 (rw) T7 (ww) T3 on x"
+*/
+func (c PairCase) String() string {
+	return fmt.Sprintf("%s %s on %s", c.Type, cycleToStr(c.Edges), strings.Join(c.Keys, ", "))
+}
+
+// the index of a txn id "txn/12"
+func txnIndex(id string) int {
+	i, err := strconv.Atoi(evtDocKey(id))
+	if err != nil {
+		log.Fatalf("Invalid txn id %s\n", id)
+	}
+	return i
+}
+
+/*
+PairCases finds the lost updates and write skews among txn edges of one key each, those of PairEdges,
+ordered by type and the indexes of T1 and T2. A lost update is found from both of its txns
+if each one overwrote the other on a key, a write skew once, from the txn of the lower index.
+*/
+func PairCases(edges []TxnDepEdge) []PairCase {
+	between := make(map[[2]string][]TxnDepEdge)
+	for _, e := range edges {
+		if e.Type == "rw" || e.Type == "ww" {
+			between[[2]string{e.From, e.To}] = append(between[[2]string{e.From, e.To}], e)
+		}
+	}
+	ofType := func(es []TxnDepEdge, typ string) *TxnDepEdge {
+		for i := range es {
+			if es[i].Type == typ {
+				return &es[i]
+			}
+		}
+		return nil
+	}
+	onKey := func(es []TxnDepEdge, typ string, key string) *TxnDepEdge {
+		for i := range es {
+			if es[i].Type == typ && es[i].Obj == key {
+				return &es[i]
+			}
+		}
+		return nil
+	}
+
+	var cases []PairCase
+	for pair, es := range between {
+		rw := ofType(es, "rw")
+		if rw == nil {
+			continue
+		}
+		back := between[[2]string{pair[1], pair[0]}]
+		// the first edges of each type may be on different keys, the keys of all edges are intersected
+		var lost []string
+		wwKeys := edgeKeys(back, "ww")
+		for k := range edgeKeys(es, "rw") {
+			if wwKeys[k] {
+				lost = append(lost, k)
+			}
+		}
+		if len(lost) > 0 {
+			sort.Strings(lost)
+			cases = append(cases, PairCase{Type: LostUpdate, Keys: lost,
+				Edges: []TxnDepEdge{*onKey(es, "rw", lost[0]), *onKey(back, "ww", lost[0])}})
+		}
+		if rwBack := ofType(back, "rw"); rwBack != nil && ofType(back, "ww") == nil && ofType(es, "ww") == nil &&
+			txnIndex(pair[0]) < txnIndex(pair[1]) {
+			keys := edgeKeys(es, "rw")
+			for k := range edgeKeys(back, "rw") {
+				keys[k] = true
+			}
+			var skew []string
+			for k := range keys {
+				skew = append(skew, k)
+			}
+			sort.Strings(skew)
+			cases = append(cases, PairCase{Type: WriteSkew, Keys: skew, Edges: []TxnDepEdge{*rw, *rwBack}})
+		}
+	}
+
+	sort.Slice(cases, func(i, j int) bool {
+		if cases[i].Type != cases[j].Type {
+			return cases[i].Type < cases[j].Type
+		}
+		fi, fj := txnIndex(cases[i].Edges[0].From), txnIndex(cases[j].Edges[0].From)
+		if fi != fj {
+			return fi < fj
+		}
+		return txnIndex(cases[i].Edges[0].To) < txnIndex(cases[j].Edges[0].To)
+	})
+	return cases
+}
+
+/*
+PairEdges projects the rw and ww evt edges between different txns to txn edges like projectTxnDepEdges,
+but one per (from, to, type, obj) carrying the least pair of evts: DepGraph.TxnDepEdges keep the key
+of a single evt edge of each type, PairCases intersects the keys of all of them
+*/
+func PairEdges(evtDepEdges []EvtDepEdge, dbConsts DBConsts) []TxnDepEdge {
+	return keyEdges(evtDepEdges, dbConsts, "rw", "ww")
+}
+
+/*
+the evt edges of the types between different txns projected to one txn edge per (from, to, type, obj),
+carrying the least pair of evts and ordered by from, to, type and obj
+*/
+func keyEdges(evtDepEdges []EvtDepEdge, dbConsts DBConsts, types ...string) []TxnDepEdge {
+	wanted := make(map[string]bool)
+	for _, typ := range types {
+		wanted[typ] = true
+	}
+	type edgeKey struct{ from, to, typ, obj string }
+	least := make(map[edgeKey]TxnDepEdge)
+	for _, e := range evtDepEdges {
+		if !wanted[e.Type] {
+			continue
+		}
+		fromTxn, toTxn := evtTxnKey(e.From), evtTxnKey(e.To)
+		if fromTxn == toTxn {
+			continue
+		}
+		k := edgeKey{docId(dbConsts.TxnNode, fromTxn), docId(dbConsts.TxnNode, toTxn), e.Type, e.Obj}
+		if prev, ok := least[k]; ok && (prev.FromEvt < e.From || prev.FromEvt == e.From && prev.ToEvt <= e.To) {
+			continue
+		}
+		least[k] = TxnDepEdge{From: k.from, To: k.to, FromEvt: e.From, ToEvt: e.To, Obj: e.Obj, Type: e.Type}
+	}
+	edges := make([]TxnDepEdge, 0, len(least))
+	for _, e := range least {
+		edges = append(edges, e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.Obj < b.Obj
+	})
+	return edges
+}
+
+// the keys of the edges of a type
+func edgeKeys(es []TxnDepEdge, typ string) map[string]bool {
+	keys := make(map[string]bool)
+	for _, e := range es {
+		if e.Type == typ {
+			keys[e.Obj] = true
+		}
+	}
+	return keys
+}
+
+// the rw and ww evt edges, the edges of PairEdges
+const sqlitePairEdgesQuery = `SELECT from_evt, to_evt, obj, type FROM evt_dep WHERE type IN ('rw', 'ww')`
+
+/*
+QuerySQLitePairCases is PairCases on the evt edges of the SQLite backend
+*/
+func QuerySQLitePairCases(db *sql.DB, dbConsts DBConsts) ([]PairCase, error) {
+	edges, err := querySQLiteEvtEdges(db, sqlitePairEdgesQuery)
+	if err != nil {
+		return nil, err
+	}
+	return PairCases(PairEdges(edges, dbConsts)), nil
+}
+
+// the evt edges selected by query from the evt_dep table
+func querySQLiteEvtEdges(db *sql.DB, query string) ([]EvtDepEdge, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var edges []EvtDepEdge
+	for rows.Next() {
+		var e EvtDepEdge
+		if err := rows.Scan(&e.From, &e.To, &e.Obj, &e.Type); err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+/*
+the AQL counterpart of sqlitePairEdgesQuery
+*/
+func pairEdgesQuery(dbConsts DBConsts) AQL {
+	return evtEdgesQuery(dbConsts, "rw", "ww")
+}
+
+// the evt edges of the types in ArangoDB
+func evtEdgesQuery(dbConsts DBConsts, types ...string) AQL {
+	return newAQLBuilder().
+		line(0, "FOR d IN @@deps").
+		line(1, "FILTER d.type IN @types").
+		line(1, "RETURN d").
+		bindCollection("deps", dbConsts.EvtDepEdge).
+		bind("types", types).
+		build()
+}
+
+/*
+QueryPairCases is PairCases on the evt edges in ArangoDB
+*/
+func QueryPairCases(db driver.Database, dbConsts DBConsts) []PairCase {
+	return PairCases(PairEdges(queryEvtEdges(db, pairEdgesQuery(dbConsts)), dbConsts))
+}
+
+// the evt edges returned by q
+func queryEvtEdges(db driver.Database, q AQL) []EvtDepEdge {
+	cursor, err := db.Query(context.Background(), q.Query, q.BindVars)
+	if err != nil {
+		log.Fatalf("Query failed: %v\n", err)
+	}
+	defer cursor.Close()
+
+	var edges []EvtDepEdge
+	for {
+		var e EvtDepEdge
+		_, err := cursor.ReadDocument(context.Background(), &e)
+		if driver.IsNoMoreDocuments(err) {
+			break
+		} else if err != nil {
+			log.Fatalf("Cannot read return values: %v\n", err)
+		}
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+/*
+PairReport summarizes the PairCases of a type: how many there are, the keys involved
+and up to maxExamples of the pairs
+*/
+type PairReport struct {
+	Type     string     `json:"type"`
+	Count    int        `json:"count"`
+	Keys     []string   `json:"keys"`
+	Examples []PairCase `json:"examples"`
+}
+
+/*
+ReportPairCases summarizes cases by type, lost updates first
+*/
+func ReportPairCases(cases []PairCase, maxExamples int) []PairReport {
+	var reports []PairReport
+	for _, typ := range []string{LostUpdate, WriteSkew} {
+		report := PairReport{Type: typ}
+		keys := make(map[string]bool)
+		for _, c := range cases {
+			if c.Type != typ {
+				continue
+			}
+			report.Count++
+			for _, k := range c.Keys {
+				keys[k] = true
+			}
+			if len(report.Examples) < maxExamples {
+				report.Examples = append(report.Examples, c)
+			}
+		}
+		if report.Count == 0 {
+			continue
+		}
+		for k := range keys {
+			report.Keys = append(report.Keys, k)
+		}
+		sort.Strings(report.Keys)
+		reports = append(reports, report)
+	}
+	return reports
+}
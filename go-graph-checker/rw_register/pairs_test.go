@@ -0,0 +1,32 @@
+package rwregister
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPairCases(t *testing.T) {
+	for _, tc := range []struct {
+		history string
+		cases   []string
+	}{
+		{"lost-update", []string{"lost-update T1 (rw) T0 (ww) T1 on 1"}},
+		{"write-skew", []string{"write-skew T0 (rw) T1 (rw) T0 on 1, 2"}},
+		{"g-single", nil},
+	} {
+		history, wal := readTestHistory(t, tc.history)
+		g := BuildDepGraph(history, wal, DefaultDBConsts())
+		cases := PairCases(PairEdges(g.EvtDepEdges, g.DBConsts))
+		var names []string
+		for _, c := range cases {
+			names = append(names, c.String())
+		}
+		require.Equal(t, tc.cases, names, tc.history)
+
+		db, _, _ := constructSQLite(t, tc.history)
+		sqliteCases, err := QuerySQLitePairCases(db, g.DBConsts)
+		require.NoError(t, err)
+		require.Equal(t, cases, sqliteCases, tc.history)
+	}
+}
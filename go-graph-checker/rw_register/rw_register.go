@@ -19,7 +19,7 @@ func ConstructGraph(opts txn.Opts, history core.History, wal WAL, dbConsts DBCon
 	okHistory := core.FilterOkHistory(history)
 
 	// create db instances and graph
-	client := startClient(dbConsts.Host, dbConsts.Port)
+	client := startClient(dbConsts)
 	db, txnGraph, evtGraph := createGraph(client, dbConsts)
 
 	// create nodes
@@ -30,7 +30,16 @@ func ConstructGraph(opts txn.Opts, history core.History, wal WAL, dbConsts DBCon
 
 	// create evt and txn dependency edges
 	evtDepEdges, g1 := getEvtDepEdges(db, wm, dbConsts)
+	_, _, writeEvts, readEvts := collectNodes(okHistory)
+	writeMap, _, err := groupWriteEvts(writeEvts, dbConsts)
+	if err != nil {
+		log.Fatalf("%v\n", err)
+	}
+	evtDepEdges = append(evtDepEdges, derivePredicateEvtDepEdges(wm, readEvts, writeMap, dbConsts)...)
 	addDepEdges(db, txnGraph, evtGraph, dbConsts, evtDepEdges)
+	if opts.Graphs&txn.RealtimeGraph != 0 {
+		addTxnDepEdges(txnGraph, dbConsts, RealtimeTxnDepEdges(opts, history, dbConsts))
+	}
 
 	return db, txnIds, g1
 }
@@ -59,180 +68,116 @@ const (
 )
 
 func IsolationLevelChecker(db driver.Database, dbConsts DBConsts, txnIds []int, output bool, level string, mode string) (bool, []TxnDepEdge) {
-	switch level {
-	case "ser", "SER", "serializabilty", "SERIALIZABILITY":
-		switch mode {
-		case "sv":
-			return CheckSERSV(db, dbConsts, txnIds, output)
-		case "sv-filter":
-			return CheckSERSVFilter(db, dbConsts, txnIds, output)
-		case "sv-random":
-			return CheckSERSVRandom(db, dbConsts, txnIds, output)
-		case "sp", "sp-allcycles":
-			return CheckSERSP(db, dbConsts, txnIds, output)
-		default:
-			log.Fatalf("invalid mode: %s, not from any of the following:\nsv, sv-filter, sv-random, sp, sp-allcycles\n", mode)
-			return false, []TxnDepEdge{}
-		}
-	case "si", "SI", "snapshot isolation", "SNAPSHOT ISOLATION":
-		switch mode {
-		case "sv":
-			return CheckSISV(db, dbConsts, txnIds, output)
-		case "sv-filter":
-			return CheckSISVFilter(db, dbConsts, txnIds, output)
-		case "sv-random":
-			return CheckSISVRandom(db, dbConsts, txnIds, output)
-		case "sp":
-			return CheckSISP(db, dbConsts, txnIds, output)
-		case "sp-allcycles":
-			return CheckSISPAllCycles(db, dbConsts, txnIds, output)
-		default:
-			log.Fatalf("invalid mode: %s, not from any of the following:\nsv, sv-filter, sv-random, sp, sp-allcycles\n", mode)
-			return false, []TxnDepEdge{}
-		}
-	case "psi", "PSI", "parallel snapshot isolation", "PARALLEL SNAPSHOT ISOLATION":
-		switch mode {
-		case "sv":
-			return CheckPSISV(db, dbConsts, txnIds, output)
-		case "sv-filter":
-			return CheckPSISVFilter(db, dbConsts, txnIds, output)
-		case "sv-random":
-			return CheckPSISVRandom(db, dbConsts, txnIds, output)
-		case "sp":
-			return CheckPSISP(db, dbConsts, txnIds, output)
-		case "sp-allcycles":
-			return CheckPSISPAllCycles(db, dbConsts, txnIds, output)
-		default:
-			log.Fatalf("invalid mode: %s, not from any of the following:\nsv, sv-filter, sv-random, sp, sp-allcycles\n", mode)
-			return false, []TxnDepEdge{}
-		}
-	case "pl-2", "PL-2":
-		switch mode {
-		case "sv":
-			return CheckPL2SV(db, dbConsts, txnIds, output)
-		case "sv-filter":
-			return CheckPL2SVFilter(db, dbConsts, txnIds, output)
-		case "sv-random":
-			return CheckPL2SVRandom(db, dbConsts, txnIds, output)
-		case "sp":
-			return CheckPL2SP(db, dbConsts, txnIds, output)
-		case "sp-allcycles":
-			return CheckPL2SPAllCycles(db, dbConsts, txnIds, output)
-		default:
-			log.Fatalf("invalid mode: %s, not from any of the following:\nsv, sv-filter, sv-random, sp, sp-allcycles\n", mode)
-			return false, []TxnDepEdge{}
-		}
-	case "pl-1", "PL-1":
-		switch mode {
-		case "sv":
-			return CheckPL1SV(db, dbConsts, txnIds, output)
-		case "sv-filter":
-			return CheckPL1SVFilter(db, dbConsts, txnIds, output)
-		case "sv-random":
-			return CheckPL1SVRandom(db, dbConsts, txnIds, output)
-		case "sp":
-			return CheckPL1SP(db, dbConsts, txnIds, output)
-		case "sp-allcycles":
-			return CheckPL1SPAllCycles(db, dbConsts, txnIds, output)
-		default:
-			log.Fatalf("invalid mode: %s, not from any of the following:\nsv, sv-filter, sv-random, sp, sp-allcycles\n", mode)
-			return false, []TxnDepEdge{}
-		}
-	default:
-		log.Fatalf("invalid level: %s, not from any of the following:\n[ser, SER, serializabilty, SERIALIZABILITY, si, SI, snapshot isolation, SNAPSHOT ISOLATION, psi, PSI, parallel snapshot isolation, PARALLEL SNAPSHOT ISOLATION, pl-2, PL-2, pl-1, PL-1]\n", mode)
+	spec, ok := LookupLevel(level)
+	if !ok {
+		log.Fatalf("invalid level: %s, not from any of the following:\n[ser, SER, serializabilty, SERIALIZABILITY, si, SI, snapshot isolation, SNAPSHOT ISOLATION, psi, PSI, parallel snapshot isolation, PARALLEL SNAPSHOT ISOLATION, pl-2, PL-2, pl-1, PL-1]\n", level)
 		return false, []TxnDepEdge{}
 	}
+	return CheckLevel(db, dbConsts, txnIds, output, spec, mode)
 }
 
 /*
------------------------------------------------ANTI-PATTERNS-------------------------------------------------
+-----------------------------------------------DETAILS OF CHECKERS-------------------------------------------------
 */
 
-// any cycle violates SER / PL-3
-
-// any cycle without at least two consecutive RW edges violates SI
-// any cycle with at least two consecutive RW edges means it is NOT an anti-pattern
-func isAntiPatternSI(cycle []TxnDepEdge) bool {
-	for i, edge := range cycle {
-		if edge.Type == "rw" && cycle[(i+1)%len(cycle)].Type == "rw" {
-			return false
-		}
-	}
-	return true
-}
-
-// any cycle without at least two RW edges violates PSI
-func isAntiPatternPSI(cycle []TxnDepEdge) bool {
-	counter := 0
-	for _, edge := range cycle {
-		if edge.Type == "rw" {
-			counter++
-			if counter == 2 {
-				return false
-			}
-		}
-	}
-	return true
+var checkModes = map[string]string{
+	"sv":           "SV",
+	"sv-filter":    "SV-Filter",
+	"sv-random":    "SV-Random",
+	"sp":           "SP",
+	"sp-allcycles": "SP-AllCycles",
 }
 
-// G1c: any cycle without rw edges violates PL-2
-// any cycle with any rw edge means it is NOT an anti-pattern
-func isAntiPatternPL2(cycle []TxnDepEdge) bool {
-	for _, edge := range cycle {
-		if edge.Type == "rw" {
-			return false
-		}
+/*
+checks the anti-pattern of a level with one of the modes, the queries are built from the level specification
+*/
+func CheckLevel(db driver.Database, dbConsts DBConsts, txnIds []int, output bool, spec LevelSpec, mode string) (bool, []TxnDepEdge) {
+	switch mode {
+	case "sv", "sv-filter":
+		return checkSV(db, svQuery(spec, mode, dbConsts), spec, checkModes[mode], output)
+	case "sv-random":
+		return checkSVRandom(db, dbConsts, txnIds, spec, output)
+	case "sp":
+		return checkSP(db, spQuery(spec, dbConsts), spec, checkModes[mode], output)
+	case "sp-allcycles":
+		// any cycle is an anti-pattern, nothing to parse
+		if spec.Pattern.Any() {
+			return checkSP(db, spQuery(spec, dbConsts), spec, "SP / SP-AllCycles", output)
+		}
+		return checkSPAllCycles(db, spAllCyclesQuery(spec, dbConsts), spec, output)
+	default:
+		log.Fatalf("invalid mode: %s, not from any of the following:\nsv, sv-filter, sv-random, sp, sp-allcycles\n", mode)
+		return false, []TxnDepEdge{}
 	}
-	return true
 }
 
-// G0: any cycle with only ww edges violates PL-1
-// any cycle with egdes of types other than ww means it is NOT an anti-pattern
-func isAntiPatternPL1(cycle []TxnDepEdge) bool {
-	for _, edge := range cycle {
-		if edge.Type != "ww" {
-			return false
+func reportCycle(spec LevelSpec, mode string, cycle []TxnDepEdge, output bool, evts evtSource) {
+	if output {
+		log.Printf("Anti-Patterns of %s detected by %s: %s.\n", spec.name(), mode, CycleAnomaly(cycle))
+		log.Println(cycleToStr(cycle))
+		for _, line := range evts(cycle).ExplainCycle(cycle) {
+			log.Println("  " + line)
 		}
 	}
-	return true
 }
 
 /*
------------------------------------------------DETAILS OF CHECKERS-------------------------------------------------
+	We do not use the PRUNE keyword (https://www.arangodb.com/docs/3.9/aql/graphs-traversals.html#pruning)
+	because pruning does not take effect on the return result. Although it may achieve the early stopping
+	as it claims, usually we need to combine the usage of PRUNE and that of FILTER to achieve a correct
+	result set, which may induce the same filtering twice. This is because PRUNE just stops in the middle
+	of traversal only if a certain condition is found to be satisfied. Otherwise, PRUNE will still reach
+	the end of the current iteration to ensure the completeness of traversal, and therefore does not
+	overall achieve our goal to filter the correct result set during iterations.
+
+	Alternatively, "filtering on path"
+	(https://www.arangodb.com/docs/3.9/aql/graphs-traversals.html#filtering-on-the-path-vs-filtering-on-vertices-or-edges)
+	works in a simliar way so that the traversal stops early when the filtering condition is satisfied.
+	It tackles the drawback of PRUNE and does not induce repeating filtering. Therefore, we shall use
+	"filtering on path", like the queries built by svQuery.
 */
 
-func CheckSERSV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND start._id
-				GRAPH %s
-				FILTER edge._to == start._id
-				LIMIT 1
-				RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
+// returns the first cycle of a traversal query, if any
+func readFirstPath(db driver.Database, q AQL, spec LevelSpec) []TxnDepEdge {
+	cursor, err := db.Query(context.Background(), q.Query, q.BindVars)
 	if err != nil {
-		log.Fatalf("Failed to check SER with SV-Simple: %v\n", err)
+		log.Fatalf("Failed to check %s: %v\n", spec.name(), err)
 	}
 
 	defer cursor.Close()
 
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
+	var cycle []TxnDepEdge
+	_, err = cursor.ReadDocument(context.Background(), &cycle)
 
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of SER detected by SV.")
-				log.Println(cycleToStr(cycle))
-			}
+	if driver.IsNoMoreDocuments(err) {
+		return nil
+	} else if err != nil {
+		log.Fatalf("Cannot read return values: %v\n", err)
+	}
+	return cycle
+}
+
+func checkSV(db driver.Database, q AQL, spec LevelSpec, mode string, output bool) (bool, []TxnDepEdge) {
+	if cycle := readFirstPath(db, q, spec); cycle != nil {
+		reportCycle(spec, mode, cycle, output, arangoEvts(db))
+		return false, cycle
+	}
+	return true, nil
+}
+
+func checkSVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, spec LevelSpec, output bool) (bool, []TxnDepEdge) {
+	q := svQuery(spec, "sv-random", dbConsts)
+
+	starts := txnIds
+	// iterate randomly after shuffling the index array slice
+	rand.Seed(time.Now().UnixNano())
+	rand.Shuffle(len(txnIds), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
+
+	for _, start := range starts {
+		q.BindVars["start"] = fmt.Sprintf("%s/%d", dbConsts.TxnNode, start)
+		if cycle := readFirstPath(db, q, spec); cycle != nil {
+			// will early stop once a cycle is detected
+			reportCycle(spec, "SV-Random", cycle, output, arangoEvts(db))
 			return false, cycle
 		}
 	}
@@ -240,27 +185,16 @@ func CheckSERSV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool
 	return true, nil
 }
 
-func CheckSERSVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND start._id
-				GRAPH %s
-				FILTER LAST(path.edges[*]._to) == start._id
-				LIMIT 1
-				RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
+func checkSP(db driver.Database, q AQL, spec LevelSpec, mode string, output bool) (bool, []TxnDepEdge) {
+	cursor, err := db.Query(context.Background(), q.Query, q.BindVars)
 	if err != nil {
-		log.Fatalf("Failed to check SER: %v\n", err)
+		log.Fatalf("Failed to check %s: %v\n", spec.name(), err)
 	}
 
 	defer cursor.Close()
 
 	for {
-		var cycle []TxnDepEdge
+		var cycle ArangoPath
 		_, err := cursor.ReadDocument(context.Background(), &cycle)
 
 		if driver.IsNoMoreDocuments(err) {
@@ -268,58 +202,9 @@ func CheckSERSVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, outpu
 		} else if err != nil {
 			log.Fatalf("Cannot read return values: %v\n", err)
 		} else {
-			if output {
-				log.Println("Anti-Patterns of SER detected by SV-Filter.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
-}
-
-func CheckSERSVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND @start
-				GRAPH %s
-				FILTER LAST(path.edges[*]._to) == @start
-				LIMIT 1
-				RETURN path.edges
-		`, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	starts := txnIds
-	// iterate randomly after shuffling the index array slice
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(txnIds), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
-
-	bindVars := make(map[string]interface{})
-	for _, start := range starts {
-		bindVars["start"] = fmt.Sprintf("txn/%d", start)
-		cursor, err := db.Query(context.Background(), query, bindVars)
-		if err != nil {
-			log.Fatalf("Failed to check SER: %v\n", err)
-		}
-
-		defer cursor.Close()
-
-		for {
-			var cycle []TxnDepEdge
-			_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-			if driver.IsNoMoreDocuments(err) {
-				break
-			} else if err != nil {
-				log.Fatalf("Cannot read return values: %v\n", err)
-			} else {
-				if output {
-					log.Println("Anti-Patterns of SER detected by SV-Random.")
-					log.Println(cycleToStr(cycle))
-				}
-				// will early stop once a cycle is detected
-				return false, cycle
+			if len(cycle.Edges) > 0 {
+				reportCycle(spec, mode, cycle.Edges, output, arangoEvts(db))
+				return false, cycle.Edges
 			}
 		}
 	}
@@ -327,53 +212,56 @@ func CheckSERSVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, outpu
 	return true, nil
 }
 
-// SP / SP-AllCycles for SER
-func CheckSERSP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR edge IN %v
-			FOR p IN OUTBOUND K_SHORTEST_PATHS
-				edge._to TO edge._from
-				GRAPH %v
-				LIMIT 1
-				RETURN {edges: UNSHIFT(p.edges, edge), vertices: UNSHIFT(p.vertices, p.vertices[LENGTH(p.vertices) - 1])}
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
+func checkSPAllCycles(db driver.Database, q AQL, spec LevelSpec, output bool) (bool, []TxnDepEdge) {
+	cursor, err := db.Query(context.Background(), q.Query, q.BindVars)
 	if err != nil {
-		log.Fatalf("Failed to check SER: %v\n", err)
+		log.Fatalf("Failed to check %s: %v\n", spec.name(), err)
 	}
 
 	defer cursor.Close()
 
 	for {
-		var cycle ArangoPath
+		var cycle []TxnDepEdge
 		_, err := cursor.ReadDocument(context.Background(), &cycle)
 
 		if driver.IsNoMoreDocuments(err) {
 			break
 		} else if err != nil {
 			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if len(cycle.Edges) > 0 {
-				if output {
-					log.Println("Anti-Patterns of SER detected by SP / SP-AllCycles.")
-					log.Println(cycleToStr(cycle.Edges))
-				}
-				return false, cycle.Edges
-			}
+		} else if len(cycle) > 0 && spec.isAntiPattern(cycle) {
+			// found one anti-pattern
+			reportCycle(spec, "SP-AllCycles", cycle, output, arangoEvts(db))
+			return false, cycle
 		}
 	}
 
 	return true, nil
 }
 
+func CheckSERSV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSER, "sv")
+}
+
+func CheckSERSVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSER, "sv-filter")
+}
+
+func CheckSERSVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSER, "sv-random")
+}
+
+// SP / SP-AllCycles for SER
+func CheckSERSP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSER, "sp")
+}
+
 /*
 Pregel - will not output any cycle, just for the API uniformity
 
-	FOR t IN txn
+	FOR t IN @@txn
 		COLLECT cycle = t.scc INTO cycles
 		FILTER LENGTH(cycles) > 1
-		RETURN cycles[*].t._id
+		RETURN cycle
 */
 func CheckSERPregel(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
 	jobId, err := db.StartJob(context.Background(), driver.PregelJobOptions{
@@ -408,14 +296,8 @@ func CheckSERPregel(db driver.Database, dbConsts DBConsts, txnIds []int, output
 		}
 
 		if job.State == driver.PregelJobStateDone {
-			query := fmt.Sprintf(`
-				FOR t IN %s
-					COLLECT cycle = t.scc INTO cycles
-					FILTER LENGTH(cycles) > 1
-					RETURN cycle
-			`, dbConsts.TxnNode)
-
-			cursor, err := db.Query(context.Background(), query, nil)
+			q := pregelCycleQuery(dbConsts)
+			cursor, err := db.Query(context.Background(), q.Query, q.BindVars)
 			if err != nil {
 				log.Fatalf("Failed to check SER: %v\n", err)
 			}
@@ -449,941 +331,115 @@ func CheckSERPregel(db driver.Database, dbConsts DBConsts, txnIds []int, output
 }
 
 func CheckSISV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path IN %d..%d
-			OUTBOUND start._id
-			GRAPH %s
-			FILTER edge._to == start._id AND NOT REGEX_TEST(CONCAT_SEPARATOR(" ", path.edges[*].type), "(^rw.*rw$|rw rw)")
-			LIMIT 1
-			RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check SI: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of SI detected by SV.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSI, "sv")
 }
 
 func CheckSISVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path IN %d..%d
-			OUTBOUND start._id
-			GRAPH %s
-			FILTER LAST(path.edges[*]._to) == start._id AND NOT REGEX_TEST(CONCAT_SEPARATOR(" ", path.edges[*].type), "(^rw.*rw$|rw rw)")
-			LIMIT 1
-			RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check SI: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of SI detected by SV-Filter.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSI, "sv-filter")
 }
 
 func CheckSISVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND @start
-				GRAPH %s
-				FILTER LAST(path.edges[*]._to) == @start AND NOT REGEX_TEST(CONCAT_SEPARATOR(" ", path.edges[*].type), "(^rw.*rw$|rw rw)")
-				LIMIT 1
-				RETURN path.edges
-		`, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSI, "sv-random")
+}
 
-	starts := txnIds
-	// iterate randomly after shuffling the index array slice
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(txnIds), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
+func CheckSISP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSI, "sp")
+}
 
-	bindVars := make(map[string]interface{})
-	for _, start := range starts {
-		bindVars["start"] = fmt.Sprintf("txn/%d", start)
-		cursor, err := db.Query(context.Background(), query, bindVars)
-		if err != nil {
-			log.Fatalf("Failed to check SI: %v\n", err)
-		}
+func CheckSISPAllCycles(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelSI, "sp-allcycles")
+}
 
-		defer cursor.Close()
+func CheckPSISV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPSI, "sv")
+}
 
-		for {
-			var cycle []TxnDepEdge
-			_, err := cursor.ReadDocument(context.Background(), &cycle)
+func CheckPSISVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPSI, "sv-filter")
+}
 
-			if driver.IsNoMoreDocuments(err) {
-				break
-			} else if err != nil {
-				log.Fatalf("Cannot read return values: %v\n", err)
-			} else {
-				if output {
-					log.Println("Anti-Patterns of SI detected by SV-Random.")
-					log.Println(cycleToStr(cycle))
-				}
-				// will early stop once a cycle is detected
-				return false, cycle
-			}
-		}
-	}
+func CheckPSISVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPSI, "sv-random")
+}
 
-	return true, nil
+func CheckPSISP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPSI, "sp")
 }
 
-/*
-direct query a type of cycle and return in ArangoDB format
-*/
-func CheckSISP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		LET cycles = (
-			FOR edge IN %v
-				FOR p IN OUTBOUND K_SHORTEST_PATHS
-					edge._to TO edge._from
-					GRAPH %v
-					RETURN {edges: UNSHIFT(p.edges, edge), vertices: UNSHIFT(p.vertices, p.vertices[LENGTH(p.vertices) - 1])}
-		)
-		
-		FOR cycle IN cycles
-			FILTER NOT REGEX_TEST(CONCAT_SEPARATOR(" ", cycle.edges[*].type), "(^rw.*rw$|rw rw)")
-			LIMIT 1
-			RETURN cycle
-		
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check SI: %v\n", err)
-	}
+func CheckPSISPAllCycles(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPSI, "sp-allcycles")
+}
 
-	defer cursor.Close()
+/*
+G2: Anti-dependency Cycles [cycles with at least one RW edge]
+FOR start IN txn
+   FOR vertex, edge, path
+       IN 2..5
+       OUTBOUND start._id
+       GRAPH dep
+       FILTER path.edges[*].type ANY == "rw" AND edge._to == start._id
+       RETURN path.edges
 
-	for {
-		var cycle ArangoPath
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
+G1c: Circular Information Flow [cycles with only WW or WR edges]
+FOR start IN txn
+   FOR vertex, edge, path
+       IN 2..5
+       OUTBOUND start._id
+       GRAPH dep
+       FILTER path.edges[*].type NONE == "rw" AND edge._to == start._id
+       RETURN path.edges
 
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if len(cycle.Edges) > 0 {
-				if output {
-					log.Println("Anti-Patterns of SI detected by SP.")
-					log.Println(cycleToStr(cycle.Edges))
-				}
-				return false, cycle.Edges
-			}
-		}
-	}
+G0: Write Cycles [cycles with only WW edges]
+Requires a new graph with only WW cycles
 
-	return true, nil
-}
+FOR start IN txn
+    FOR vertex, edge, path
+        IN 2..5
+        OUTBOUND start._id
+        GRAPH dep
+        RETURN path.edges
+*/
 
 /*
-query using BFS-based shortest path + parsing the cycle results
-
-	FOR edge IN dep
-		FOR p IN OUTBOUND K_SHORTEST_PATHS
-			edge._to TO edge._from
-			GRAPH txn_g
-			RETURN UNSHIFT(p.edges, edge)
+the anti-pattern of PL-2 is G1 (G1a, G1b, G1c)
+only G1c will be checked as G1a and G1b are ensured not to happen during graph construction
 */
-func CheckSISPAllCycles(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR edge IN %s
-			FOR p IN OUTBOUND K_SHORTEST_PATHS
-				edge._to TO edge._from
-				GRAPH %s
-				RETURN UNSHIFT(p.edges, edge)
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check SI: %v\n", err)
-	}
+func CheckPL2SV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL2, "sv")
+}
 
-	defer cursor.Close()
+func CheckPL2SVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL2, "sv-filter")
+}
 
-	cycles := [][]TxnDepEdge{}
-	antiPatternFound := false
+func CheckPL2SVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL2, "sv-random")
+}
 
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
+func CheckPL2SP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL2, "sp")
+}
 
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			cycles = append(cycles, cycle)
-			if len(cycles) > 0 && isAntiPatternSI(cycles[len(cycles)-1]) {
-				// found one anti-pattern
-				antiPatternFound = true
-				break
-			}
-		}
-	}
+func CheckPL2SPAllCycles(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL2, "sp-allcycles")
+}
 
-	// if only one anti-pattern, do the final check
-	if antiPatternFound || (len(cycles) > 0 && isAntiPatternSI(cycles[len(cycles)-1])) {
-		if output {
-			log.Println("Anti-Patterns of SI detected by SP-AllCycles.")
-			log.Println(cycleToStr(cycles[len(cycles)-1]))
-		}
-		return false, cycles[len(cycles)-1]
-	}
+func CheckPL1SV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL1, "sv")
+}
 
-	return true, nil
+func CheckPL1SVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL1, "sv-filter")
 }
 
-func CheckPSISV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path IN %d..%d
-			OUTBOUND start._id
-			GRAPH %s
-			FILTER edge._to == start._id AND LENGTH(FOR e IN path.edges FILTER e.type == "rw" RETURN e) < 2
-			LIMIT 1
-			RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PSI: %v\n", err)
-	}
+func CheckPL1SVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL1, "sv-random")
+}
 
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of PSI detected by SV.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPSISVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path IN %d..%d
-			OUTBOUND start._id
-			GRAPH %s
-			FILTER LAST(path.edges[*]._to) == start._id AND LENGTH(FOR e IN path.edges FILTER e.type == "rw" RETURN e) < 2
-			LIMIT 1
-			RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PSI: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of PSI detected by SV-Filter.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPSISVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND @start
-				GRAPH %s
-				FILTER LAST(path.edges[*]._to) == @start AND LENGTH(FOR e IN path.edges FILTER e.type == "rw" RETURN e) < 2
-				LIMIT 1
-				RETURN path.edges
-		`, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	starts := txnIds
-	// iterate randomly after shuffling the index array slice
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(txnIds), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
-
-	bindVars := make(map[string]interface{})
-	for _, start := range starts {
-		bindVars["start"] = fmt.Sprintf("txn/%d", start)
-		cursor, err := db.Query(context.Background(), query, bindVars)
-		if err != nil {
-			log.Fatalf("Failed to check PSI: %v\n", err)
-		}
-
-		defer cursor.Close()
-
-		for {
-			var cycle []TxnDepEdge
-			_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-			if driver.IsNoMoreDocuments(err) {
-				break
-			} else if err != nil {
-				log.Fatalf("Cannot read return values: %v\n", err)
-			} else {
-				if output {
-					log.Println("Anti-Patterns of PSI detected by SV-Random.")
-					log.Println(cycleToStr(cycle))
-				}
-				// will early stop once a cycle is detected
-				return false, cycle
-			}
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPSISP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		LET cycles = (
-			FOR edge IN %v
-				FOR p IN OUTBOUND K_SHORTEST_PATHS
-					edge._to TO edge._from
-					GRAPH %v
-					RETURN {edges: UNSHIFT(p.edges, edge), vertices: UNSHIFT(p.vertices, p.vertices[LENGTH(p.vertices) - 1])}
-		)
-		
-		FOR cycle IN cycles
-			FILTER LENGTH(FOR e IN cycle.edges FILTER e.type == "rw" RETURN e) < 2
-			LIMIT 1
-			RETURN cycle
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PSI: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle ArangoPath
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if len(cycle.Edges) > 0 {
-				if output {
-					log.Println("Anti-Patterns of PSI detected by SP.")
-					log.Println(cycleToStr(cycle.Edges))
-				}
-				return false, cycle.Edges
-			}
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPSISPAllCycles(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR edge IN %s
-			FOR p IN OUTBOUND K_SHORTEST_PATHS
-				edge._to TO edge._from
-				GRAPH %s
-				RETURN UNSHIFT(p.edges, edge)
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PSI: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	cycles := [][]TxnDepEdge{}
-	antiPatternFound := false
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			cycles = append(cycles, cycle)
-			if len(cycles) > 0 && isAntiPatternPSI(cycles[len(cycles)-1]) {
-				// found one anti-pattern
-				antiPatternFound = true
-				break
-			}
-		}
-	}
-
-	// if only one anti-pattern, do the final check
-	if antiPatternFound || (len(cycles) > 0 && isAntiPatternPSI(cycles[len(cycles)-1])) {
-		if output {
-			log.Println("Anti-Patterns of PSI detected by SP-AllCycles.")
-			log.Println(cycleToStr(cycles[len(cycles)-1]))
-		}
-		return false, cycles[len(cycles)-1]
-	}
-
-	return true, nil
-}
-
-/*
-G2: Anti-dependency Cycles [cycles with at least one RW edge]
-FOR start IN txn
-   FOR vertex, edge, path
-       IN 2..5
-       OUTBOUND start._id
-       GRAPH dep
-       FILTER path.edges[*].type ANY == "rw" AND edge._to == start._id
-       RETURN path.edges
-
-G1c: Circular Information Flow [cycles with only WW or WR edges]
-FOR start IN txn
-   FOR vertex, edge, path
-       IN 2..5
-       OUTBOUND start._id
-       GRAPH dep
-       FILTER path.edges[*].type NONE == "rw" AND edge._to == start._id
-       RETURN path.edges
-
-G0: Write Cycles [cycles with only WW edges]
-Requires a new graph with only WW cycles
-
-FOR start IN txn
-    FOR vertex, edge, path
-        IN 2..5
-        OUTBOUND start._id
-        GRAPH dep
-        RETURN path.edges
-*/
-
-/*
-the anti-pattern of PL-2 is G1 (G1a, G1b, G1c)
-only G1c will be checked as G1a and G1b are ensured not to happen during graph construction
-*/
-func CheckPL2SV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND start._id
-				GRAPH %s
-				FILTER path.edges[*].type NONE == "rw" AND edge._to == start._id
-				LIMIT 1
-				RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-2: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of PL-2 detected by SV.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
-}
-
-/*
-the anti-pattern of PL-2 is G1 (G1a, G1b, G1c)
-only G1c will be checked as G1a and G1b are ensured not to happen during graph construction
-*/
-func CheckPL2SVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND start._id
-				GRAPH %s
-				FILTER path.edges[*].type NONE == "rw" AND LAST(path.edges[*]._to) == start._id
-				LIMIT 1
-				RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-2: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of PL-2 detected by SV-Filter.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPL2SVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND @start
-				GRAPH %s
-				FILTER path.edges[*].type NONE == "rw" and LAST(path.edges[*]._to) == @start
-				LIMIT 1
-				RETURN path.edges
-		`, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	starts := txnIds
-	// iterate randomly after shuffling the index array slice
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(txnIds), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
-
-	bindVars := make(map[string]interface{})
-	for _, start := range starts {
-		bindVars["start"] = fmt.Sprintf("txn/%d", start)
-		cursor, err := db.Query(context.Background(), query, bindVars)
-		if err != nil {
-			log.Fatalf("Failed to check PL-2: %v\n", err)
-		}
-
-		defer cursor.Close()
-
-		for {
-			var cycle []TxnDepEdge
-			_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-			if driver.IsNoMoreDocuments(err) {
-				break
-			} else if err != nil {
-				log.Fatalf("Cannot read return values: %v\n", err)
-			} else {
-				if output {
-					log.Println("Anti-Patterns of PL-2 detected by SV-Random.")
-					log.Println(cycleToStr(cycle))
-				}
-				// will early stop once a cycle is detected
-				return false, cycle
-			}
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPL2SP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		LET cycles = (
-			FOR edge IN %v
-				FILTER edge != "rw"
-				FOR p IN OUTBOUND K_SHORTEST_PATHS
-					edge._to TO edge._from
-					GRAPH %v
-					RETURN {edges: UNSHIFT(p.edges, edge), vertices: UNSHIFT(p.vertices, p.vertices[LENGTH(p.vertices) - 1])}
-		)
-		
-		FOR cycle IN cycles
-			FILTER cycle.edges[*].type NONE == "rw"
-			LIMIT 1
-			RETURN cycle
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-2: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle ArangoPath
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if len(cycle.Edges) > 0 {
-				if output {
-					log.Println("Anti-Patterns of PL-2 detected by SP.")
-					log.Println(cycleToStr(cycle.Edges))
-				}
-				return false, cycle.Edges
-			}
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPL2SPAllCycles(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR edge IN %s
-			FILTER edge != "rw"
-			FOR p IN OUTBOUND K_SHORTEST_PATHS
-				edge._to TO edge._from
-				GRAPH %s
-				RETURN UNSHIFT(p.edges, edge)
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-2: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	cycles := [][]TxnDepEdge{}
-	antiPatternFound := false
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			cycles = append(cycles, cycle)
-			if len(cycles) > 0 && isAntiPatternPL2(cycles[len(cycles)-1]) {
-				// found one anti-pattern
-				antiPatternFound = true
-				break
-			}
-		}
-	}
-
-	// if only one anti-pattern, do the final check
-	if antiPatternFound || (len(cycles) > 0 && isAntiPatternPL2(cycles[len(cycles)-1])) {
-		if output {
-			log.Println("Anti-Patterns of PL-2 detected by SP-AllCycles.")
-			log.Println(cycleToStr(cycles[len(cycles)-1]))
-		}
-		return false, cycles[len(cycles)-1]
-	}
-
-	return true, nil
-}
-
-/*
-with a new graph consisting of only WW edges
-any cycle would violate PL-1
-*/
-func CheckPL1SV(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND start._id
-				GRAPH %s
-				FILTER path.edges[*].type ALL == "ww" AND edge._to == start._id
-				LIMIT 1
-				RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV_SIMPLE, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-1: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of PL-1 detected by SV.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPL1SVFilter(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR start IN %s
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND start._id
-				GRAPH %s
-				FILTER path.edges[*].type ALL == "ww" AND LAST(path.edges[*]._to) == start._id
-				LIMIT 1
-				RETURN path.edges
-		`, dbConsts.TxnNode, MIN_DEPTH, MAX_DEPTH_SV, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-1: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if output {
-				log.Println("Anti-Patterns of PL-1 detected by SV-Filter.")
-				log.Println(cycleToStr(cycle))
-			}
-			return false, cycle
-		}
-	}
-
-	return true, nil
-}
-
-func CheckPL1SVRandom(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	minStep := 2
-	maxStep := 3
-	query := fmt.Sprintf(`
-			FOR vertex, edge, path
-				IN %d..%d
-				OUTBOUND @start
-				GRAPH %s
-				FILTER path.edges[*].type ALL == "ww" AND LAST(path.edges[*]._to) == @start
-				LIMIT 1
-				RETURN path.edges
-		`, minStep, maxStep, dbConsts.TxnGraph)
-
-	starts := txnIds
-	// iterate randomly after shuffling the index array slice
-	rand.Seed(time.Now().UnixNano())
-	rand.Shuffle(len(txnIds), func(i, j int) { starts[i], starts[j] = starts[j], starts[i] })
-
-	bindVars := make(map[string]interface{})
-	for _, start := range starts {
-		bindVars["start"] = fmt.Sprintf("txn/%d", start)
-		cursor, err := db.Query(context.Background(), query, bindVars)
-		if err != nil {
-			log.Fatalf("Failed to check PL-1: %v\n", err)
-		}
-
-		defer cursor.Close()
-
-		for {
-			var cycle []TxnDepEdge
-			_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-			if driver.IsNoMoreDocuments(err) {
-				break
-			} else if err != nil {
-				log.Fatalf("Cannot read return values: %v\n", err)
-			} else {
-				if output {
-					log.Println("Anti-Patterns of PL-1 detected by SV-Random.")
-					log.Println(cycleToStr(cycle))
-				}
-				// will early stop once a cycle is detected
-				return false, cycle
-			}
-		}
-	}
-
-	return true, nil
+func CheckPL1SP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL1, "sp")
 }
 
 func CheckPL1SPAllCycles(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		FOR edge IN %s
-			FILTER edge.type == "ww"
-			FOR p IN OUTBOUND K_SHORTEST_PATHS
-				edge._to TO edge._from
-				GRAPH %s
-				RETURN UNSHIFT(p.edges, edge)
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-1: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	cycles := [][]TxnDepEdge{}
-	antiPatternFound := false
-
-	for {
-		var cycle []TxnDepEdge
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			cycles = append(cycles, cycle)
-			if len(cycles) > 0 && isAntiPatternPL1(cycles[len(cycles)-1]) {
-				// found one anti-pattern
-				antiPatternFound = true
-				break
-			}
-		}
-	}
-
-	// if only one anti-pattern, do the final check
-	if antiPatternFound || (len(cycles) > 0 && isAntiPatternPL1(cycles[len(cycles)-1])) {
-		if output {
-			log.Println("Anti-Patterns of PL-1 detected by SP-AllCycles.")
-			log.Println(cycleToStr(cycles[len(cycles)-1]))
-		}
-		return false, cycles[len(cycles)-1]
-	}
-
-	return true, nil
-}
-
-/*
-direct query a type of cycle and return in ArangoDB format
-*/
-func CheckPL1SP(db driver.Database, dbConsts DBConsts, txnIds []int, output bool) (bool, []TxnDepEdge) {
-	query := fmt.Sprintf(`
-		LET cycles = (
-			FOR edge IN %v
-				FILTER edge.type == "ww"
-				FOR p IN OUTBOUND K_SHORTEST_PATHS
-					edge._to TO edge._from
-					GRAPH %v
-					RETURN {edges: UNSHIFT(p.edges, edge), vertices: UNSHIFT(p.vertices, p.vertices[LENGTH(p.vertices) - 1])}
-		)
-		
-		FOR cycle IN cycles
-			FILTER cycle.edges[*].type ALL == "ww"
-			LIMIT 1
-			RETURN cycle
-		`, dbConsts.TxnDepEdge, dbConsts.TxnGraph)
-
-	cursor, err := db.Query(context.Background(), query, nil)
-	if err != nil {
-		log.Fatalf("Failed to check PL-1: %v\n", err)
-	}
-
-	defer cursor.Close()
-
-	for {
-		var cycle ArangoPath
-		_, err := cursor.ReadDocument(context.Background(), &cycle)
-
-		if driver.IsNoMoreDocuments(err) {
-			break
-		} else if err != nil {
-			log.Fatalf("Cannot read return values: %v\n", err)
-		} else {
-			if len(cycle.Edges) > 0 {
-				if output {
-					log.Println("Anti-Patterns of PL-1 detected by SP.")
-					log.Println(cycleToStr(cycle.Edges))
-				}
-				return false, cycle.Edges
-			}
-		}
-	}
-
-	return true, nil
+	return CheckLevel(db, dbConsts, txnIds, output, LevelPL1, "sp-allcycles")
 }
@@ -7,27 +7,29 @@ import (
 	"os"
 	"strconv"
 	"testing"
-	"time"
 
 	"github.com/arangodb/go-driver"
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
 	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/bench"
 	"github.com/stretchr/testify/require"
 )
 
-func TestCheckExample(t *testing.T) {
-	dbConsts := DBConsts{
-		"starter",    // Host
-		8529,         // Port
-		"checker_db", // DB
-		"txn_g",      // TxnGraph
-		"evt_g",      // EvtGraph
-		"txn",        // TxnNode
-		"w_evt",      // WriteEvtNode
-		"r_evt",      // ReadEvtNode
-		"dep",        // TxnDepEdge
-		"evt_dep",    // EvtDepEdge
+/*
+the default constants, overridden by $GRAIL_CONFIG and the GRAIL_* environment variables;
+tests drop and recreate the checker database
+*/
+func testDBConsts() DBConsts {
+	dbConsts, err := LoadDBConsts("")
+	if err != nil {
+		log.Fatalf("Cannot load the checker config: %v", err)
 	}
+	dbConsts.Overwrite = true
+	return dbConsts
+}
+
+func TestCheckExample(t *testing.T) {
+	dbConsts := testDBConsts()
 	ednFileName := fmt.Sprintf("../histories/rw-register/%s.edn", "20")
 	walFileName := fmt.Sprintf("../histories/rw-register/%s.log", "20")
 	prompt := fmt.Sprintf("Checking %s...", ednFileName)
@@ -55,13 +57,9 @@ func TestCheckExample(t *testing.T) {
 		t.Fail()
 	}
 
-	t1 := time.Now()
 	db, txnIds, g1 := ConstructGraph(txn.Opts{}, history, wal, dbConsts)
 	require.Equal(t, g1.G1a, false)
 	require.Equal(t, g1.G1b, false)
-	t2 := time.Now()
-	constructTime := t2.Sub(t1).Nanoseconds() / 1e6
-	fmt.Printf("constructing graph: %d ms\n", constructTime)
 
 	{
 		valid, cycle := IsolationLevelChecker(db, dbConsts, txnIds, true, "ser", "sp")
@@ -115,18 +113,7 @@ func TestCheckExample(t *testing.T) {
 }
 
 func constructArangoGraph(fileName string, t *testing.T) (driver.Database, []int, DBConsts, core.History) {
-	dbConsts := DBConsts{
-		"starter",    // Host
-		8529,         // Port
-		"checker_db", // DB
-		"txn_g",      // TxnGraph
-		"evt_g",      // EvtGraph
-		"txn",        // TxnNode
-		"w_evt",      // WriteEvtNode
-		"r_evt",      // ReadEvtNode
-		"dep",        // TxnDepEdge
-		"evt_dep",    // EvtDepEdge
-	}
+	dbConsts := testDBConsts()
 	ednFileName := fmt.Sprintf("../histories/rw-register/%s.edn", fileName)
 	walFileName := fmt.Sprintf("../histories/rw-register/%s.log", fileName)
 	prompt := fmt.Sprintf("Checking %s...", ednFileName)
@@ -154,62 +141,28 @@ func constructArangoGraph(fileName string, t *testing.T) (driver.Database, []int
 		t.Fail()
 	}
 
-	t1 := time.Now()
 	db, txnIds, g1 := ConstructGraph(txn.Opts{}, history, wal, dbConsts)
 	require.Equal(t, g1.G1a, false)
 	require.Equal(t, g1.G1b, false)
-	t2 := time.Now()
-	constructTime := t2.Sub(t1).Nanoseconds() / 1e6
-	fmt.Printf("constructing graph: %d ms\n", constructTime)
 
 	return db, txnIds, dbConsts, history
 }
 
 func TestProfilingScalability(t *testing.T) {
-	var runtime [][]int64
+	dbConsts := testDBConsts()
+	levels := []string{"ser", "si", "psi", "pl-2", "pl-1"}
+	modes := []string{"sv", "sv-filter", "sp", "pregel"}
+	var records []bench.Record
 	for d := 10; d <= 200; d += 10 {
-		db, txnIds, dbConsts, _ := constructArangoGraph(strconv.Itoa(d), t)
-		var cur []int64
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckSERSV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckSERSVFilter, false)
-			t3 := Profile(db, dbConsts, txnIds, CheckSERSP, false)
-			tp := Profile(db, dbConsts, nil, CheckSERPregel, false)
-			cur = append(cur, t1, t2, t3, tp)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckSISV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckSISP, false)
-			cur = append(cur, t1, t2)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckPSISV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckPSISP, false)
-			cur = append(cur, t1, t2)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckPL2SV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckPL2SP, false)
-			cur = append(cur, t1, t2)
-		}
-
-		{
-			t1 := Profile(db, dbConsts, txnIds, CheckPL1SV, false)
-			t2 := Profile(db, dbConsts, txnIds, CheckPL1SP, false)
-			cur = append(cur, t1, t2)
-		}
-		runtime = append(runtime, cur)
-	}
-	for _, row := range runtime {
-		for _, col := range row {
-			fmt.Print(col)
-			fmt.Print("\t")
-		}
-		fmt.Println()
-	}
+		content, err := os.ReadFile(fmt.Sprintf("../histories/rw-register/%d.edn", d))
+		require.NoError(t, err)
+		walContent, err := os.ReadFile(fmt.Sprintf("../histories/rw-register/%d.log", d))
+		require.NoError(t, err)
+		wal, err := ParseWAL(string(walContent))
+		require.NoError(t, err)
+		records = append(records, Bench(fmt.Sprintf("%d.edn", d), string(content), wal, dbConsts, levels, modes, bench.DefaultOptions())...)
+	}
+	require.NoError(t, bench.WriteCSV(os.Stdout, records))
 }
 
 func TestCountCycles(t *testing.T) {
@@ -479,18 +432,7 @@ func TestChecker(t *testing.T) {
 }
 
 func testConstructArangoGraph(fileName string, t *testing.T) (driver.Database, []int, DBConsts, core.History, G1Anomalies) {
-	dbConsts := DBConsts{
-		"starter",    // Host
-		8529,         // Port
-		"checker_db", // DB
-		"txn_g",      // TxnGraph
-		"evt_g",      // EvtGraph
-		"txn",        // TxnNode
-		"w_evt",      // WriteEvtNode
-		"r_evt",      // ReadEvtNode
-		"dep",        // TxnDepEdge
-		"evt_dep",    // EvtDepEdge
-	}
+	dbConsts := testDBConsts()
 	ednFileName := fmt.Sprintf("../histories/rw-register-test/%s.edn", fileName)
 	walFileName := fmt.Sprintf("../histories/rw-register-test/%s.log", fileName)
 	prompt := fmt.Sprintf("Checking %s...", ednFileName)
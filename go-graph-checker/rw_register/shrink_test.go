@@ -0,0 +1,38 @@
+package rwregister
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/shrink"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShrinkOracle(t *testing.T) {
+	history, wal := readTestHistory(t, "write-skew")
+	oracle := ShrinkOracle("ser", "sv", wal, DefaultDBConsts())
+	r, err := shrink.Shrink(history, oracle, shrink.Options{})
+	require.NoError(t, err)
+	require.Equal(t, "G2-item", r.Violation.Class)
+	require.LessOrEqual(t, len(r.History), len(history))
+	require.Equal(t, r.Violation.Cycle[0].From, r.Violation.Cycle[len(r.Violation.Cycle)-1].To)
+
+	// the emitted history reproduces the violation
+	var buf bytes.Buffer
+	require.NoError(t, shrink.WriteEDN(&buf, r.History))
+	minimal, err := core.ParseHistoryRW(buf.String())
+	require.NoError(t, err)
+	v, violated := oracle(minimal)
+	require.True(t, violated)
+	require.True(t, v.Same(r.Violation))
+
+	// a candidate writing the same value twice to an object cannot be traced, it does not reproduce
+	w := (*minimal[0].Value)[len(*minimal[0].Value)-1]
+	require.True(t, w.IsWrite())
+	dup, err := core.ParseHistoryRW(buf.String() + fmt.Sprintf("{:type :ok, :value [[:w %s %d]], :index %d}\n", w.GetKey(), w.GetValue(), len(minimal)))
+	require.NoError(t, err)
+	_, violated = oracle(dup)
+	require.False(t, violated)
+}
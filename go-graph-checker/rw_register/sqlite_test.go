@@ -0,0 +1,111 @@
+package rwregister
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/txn"
+	"github.com/stretchr/testify/require"
+)
+
+func readTestHistory(t *testing.T, fileName string) (core.History, WAL) {
+	content, err := os.ReadFile(fmt.Sprintf("../histories/rw-register-test/%s.edn", fileName))
+	require.NoError(t, err)
+	history, err := core.ParseHistoryRW(string(content))
+	require.NoError(t, err)
+
+	walContent, err := os.ReadFile(fmt.Sprintf("../histories/rw-register-test/%s.log", fileName))
+	require.NoError(t, err)
+	wal, err := ParseWAL(string(walContent))
+	require.NoError(t, err)
+	return history, wal
+}
+
+func constructSQLite(t *testing.T, fileName string) (*sql.DB, []int, G1Anomalies) {
+	history, wal := readTestHistory(t, fileName)
+	db, txnIds, g1 := ConstructSQLite(txn.Opts{}, history, wal, DefaultDBConsts(), ":memory:")
+	t.Cleanup(func() { db.Close() })
+	return db, txnIds, g1
+}
+
+func TestSQLiteChecker(t *testing.T) {
+	// expected validity per level, as in TestChecker
+	for _, tc := range []struct {
+		history string
+		valid   map[string]bool
+	}{
+		{"g0", map[string]bool{"pl-1": false}},
+		{"g1c", map[string]bool{"pl-2": false, "pl-1": true}},
+		{"g-single", map[string]bool{"pl-2": true, "ser": false, "si": false, "psi": false}},
+		{"non-repeatable-read", map[string]bool{"ser": false, "si": false, "psi": false}},
+		{"lost-update", map[string]bool{"ser": false, "si": false, "psi": false}},
+		{"long-fork", map[string]bool{"ser": false, "si": false, "psi": true}},
+		{"write-skew", map[string]bool{"ser": false, "si": true, "psi": true}},
+	} {
+		db, txnIds, g1 := constructSQLite(t, tc.history)
+		require.Equal(t, G1Anomalies{}, g1, tc.history)
+		for level, expected := range tc.valid {
+			for _, mode := range []string{"sv", "all"} {
+				valid, cycle := SQLiteIsolationLevelChecker(db, txnIds, false, level, mode)
+				require.Equal(t, expected, valid, "%s %s %s", tc.history, level, mode)
+				if !valid {
+					require.Equal(t, cycle[0].From, cycle[len(cycle)-1].To)
+				}
+			}
+		}
+	}
+}
+
+func TestBuildDepGraphG1(t *testing.T) {
+	history, wal := readTestHistory(t, "g1a")
+	require.True(t, BuildDepGraph(history, wal, DefaultDBConsts()).G1.G1a)
+
+	history, wal = readTestHistory(t, "g1b-1")
+	require.True(t, BuildDepGraph(history, wal, DefaultDBConsts()).G1.G1b)
+
+	history, wal = readTestHistory(t, "g1b-2")
+	require.False(t, BuildDepGraph(history, wal, DefaultDBConsts()).G1.G1b)
+}
+
+func TestExplainCycle(t *testing.T) {
+	history, wal := readTestHistory(t, "write-skew")
+	g := BuildDepGraph(history, wal, DefaultDBConsts())
+	db, txnIds, _ := constructSQLite(t, "write-skew")
+	valid, cycle := SQLiteIsolationLevelChecker(db, txnIds, false, "ser", "all")
+	require.False(t, valid)
+
+	docs, err := QuerySQLiteEvtDocs(db, cycle)
+	require.NoError(t, err)
+	explanations := docs.ExplainCycle(cycle)
+	require.Equal(t, g.EvtDocs().ExplainCycle(cycle), explanations)
+	for i, e := range cycle {
+		require.Contains(t, explanations[i], "before", e)
+		require.Contains(t, explanations[i], "was written by "+txnName(e.To))
+	}
+}
+
+func TestSQLitePredicate(t *testing.T) {
+	// both txns scan [1 3], find nothing and write a key in the range
+	history, wal := readTestHistory(t, "phantom")
+	g := BuildDepGraph(history, wal, DefaultDBConsts())
+	require.Equal(t, []TxnDepEdge{
+		{From: "txn/0", To: "txn/1", FromEvt: "r_evt/0,0", ToEvt: "w_evt/1,1", Obj: "3", Type: "rw-predicate"},
+		{From: "txn/1", To: "txn/0", FromEvt: "r_evt/1,0", ToEvt: "w_evt/0,1", Obj: "2", Type: "rw-predicate"},
+	}, g.TxnDepEdges)
+
+	db, txnIds, _ := constructSQLite(t, "phantom")
+	for level, expected := range map[string]bool{"ser": false, "si": true, "psi": true, "pl-2": true} {
+		valid, _ := SQLiteIsolationLevelChecker(db, txnIds, false, level, "all")
+		require.Equal(t, expected, valid, level)
+	}
+	_, cycle := SQLiteIsolationLevelChecker(db, txnIds, false, "ser", "all")
+	require.Equal(t, "G2-predicate", CycleAnomaly(cycle).Name)
+
+	docs, err := QuerySQLiteEvtDocs(db, cycle)
+	require.NoError(t, err)
+	require.Equal(t, "T0 scanned [1 3] without observing key 3 before 1 was written to it by T1",
+		docs.ExplainEdge(g.TxnDepEdges[0]))
+}
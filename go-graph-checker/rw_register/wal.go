@@ -2,6 +2,9 @@ package rwregister
 
 import (
 	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -83,3 +86,43 @@ func ConstructWALWriteMap(wal WAL, attr string) WALWriteMap {
 	}
 	return wm
 }
+
+/*
+builds the WAL of a register database that installed the versions of each key in order,
+e.g. the Versions of a generated history; the register attribute is "rwAttr"
+*/
+func WALFromVersions(versions map[string][]int) WAL {
+	keys := make([]string, 0, len(versions))
+	for k := range versions {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var wal WAL
+	for _, k := range keys {
+		for _, v := range versions[k] {
+			tick := strconv.Itoa(len(wal) + 1)
+			wal = append(wal, WALEntry{
+				Tick: tick,
+				Type: WALTypeInsertDoc,
+				DB:   "rwRegister",
+				TID:  tick,
+				// numbers read from JSON are float64
+				Data: map[string]interface{}{"_key": k, "rwAttr": float64(v)},
+			})
+		}
+	}
+	return wal
+}
+
+/*
+writes a WAL as ParseWAL reads it, one JSON entry per line
+*/
+func WriteWAL(w io.Writer, wal WAL) error {
+	enc := json.NewEncoder(w)
+	for _, entry := range wal {
+		if err := enc.Encode(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
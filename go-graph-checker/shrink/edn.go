@@ -0,0 +1,70 @@
+package shrink
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+)
+
+/*
+WriteEDN writes a history as EDN, one op per line as core.ParseHistory reads it
+*/
+func WriteEDN(w io.Writer, history core.History) error {
+	bw := bufio.NewWriter(w)
+	for _, op := range history {
+		bw.WriteString(op.String())
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+/*
+WriteViolationEDN writes a violation as an EDN map, the cycle as a vector of edges:
+
+	{:level "si", :class "G-single", :g1 [],
+	 :cycle [{:from "txn/1", :to "txn/2", :type :wr, :key "x", :from-evt "a_evt/1,0", :to-evt "r_evt/2,0"} ...]}
+*/
+func WriteViolationEDN(w io.Writer, v Violation) error {
+	bw := bufio.NewWriter(w)
+	g1 := make([]string, len(v.G1))
+	for i, a := range v.G1 {
+		g1[i] = ":" + a
+	}
+	fmt.Fprintf(bw, "{:level %s, :class %s, :g1 [%s],\n :cycle [", strconv.Quote(v.Level), strconv.Quote(v.Class), strings.Join(g1, " "))
+	for i, e := range v.Cycle {
+		if i > 0 {
+			bw.WriteString("\n         ")
+		}
+		fmt.Fprintf(bw, "{:from %s, :to %s, :type :%s, :key %s, :from-evt %s, :to-evt %s}",
+			strconv.Quote(e.From), strconv.Quote(e.To), e.Type, strconv.Quote(e.Obj), strconv.Quote(e.FromEvt), strconv.Quote(e.ToEvt))
+	}
+	bw.WriteString("]}\n")
+	return bw.Flush()
+}
+
+/*
+WriteFiles writes the minimal history to <dir>/<name>.edn and its violation to <dir>/<name>.cycle.edn
+*/
+func WriteFiles(dir string, name string, r Result) error {
+	write := func(fileName string, f func(w io.Writer) error) error {
+		file, err := os.Create(filepath.Join(dir, fileName))
+		if err != nil {
+			return err
+		}
+		if err := f(file); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+	if err := write(name+".edn", func(w io.Writer) error { return WriteEDN(w, r.History) }); err != nil {
+		return err
+	}
+	return write(name+".cycle.edn", func(w io.Writer) error { return WriteViolationEDN(w, r.Violation) })
+}
@@ -0,0 +1,366 @@
+/*
+Package shrink minimizes a history violating an isolation level by delta debugging:
+transactions, keys and micro-ops are removed while the same violation (same level,
+same anti-pattern class, same G1 anomalies) still reproduces, and the minimal history
+is emitted as EDN together with its cycle.
+
+	oracle := listappend.ShrinkOracle("si", "sv", listappend.DefaultDBConsts())
+	result, err := shrink.Shrink(history, oracle, shrink.Options{})
+	err = shrink.WriteFiles("shrunk", "10", result)
+*/
+package shrink
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/antipattern"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+)
+
+/*
+Violation is what a checker found in a history: the violated level, the anti-pattern class of
+the cycle (see antipattern.Class), the G1 anomalies found on the way ("G1a", "G1b") and the cycle
+*/
+type Violation struct {
+	Level string
+	Class string
+	G1    []string
+	Cycle []export.Edge
+}
+
+/*
+NewViolation classifies a cycle found for a level
+*/
+func NewViolation(level string, cycle []export.Edge, g1 []string) Violation {
+	types := make([]string, 0, len(cycle))
+	for _, e := range cycle {
+		types = append(types, e.Type)
+	}
+	sorted := append([]string{}, g1...)
+	sort.Strings(sorted)
+	return Violation{Level: level, Class: antipattern.Class(types), G1: sorted, Cycle: cycle}
+}
+
+/*
+Same reports whether two violations are the same bug, the cycles themselves may differ
+*/
+func (v Violation) Same(o Violation) bool {
+	if v.Level != o.Level || v.Class != o.Class || len(v.G1) != len(o.G1) {
+		return false
+	}
+	for i := range v.G1 {
+		if v.G1[i] != o.G1[i] {
+			return false
+		}
+	}
+	return true
+}
+
+/*
+Oracle checks a candidate history with a checker configuration, it returns false if the level holds
+*/
+type Oracle func(history core.History) (Violation, bool)
+
+/*
+Options bound the search, MaxChecks is the number of oracle calls (0 for no bound);
+Verbose logs every reduction. TxnNode is the txn collection of the checker, the txn ids
+of the cycles are in it (DBConsts.TxnNode, "txn" if empty)
+*/
+type Options struct {
+	MaxChecks int
+	Verbose   bool
+	TxnNode   string
+}
+
+/*
+Result is the minimal history, its violation and the number of oracle calls it took
+*/
+type Result struct {
+	History   core.History
+	Violation Violation
+	Checks    int
+}
+
+// a txn: its invocation and completion, or a single op
+type unit []core.Op
+
+type shrinker struct {
+	oracle Oracle
+	opts   Options
+	target Violation
+	checks int
+}
+
+/*
+Shrink minimizes history while oracle reports the violation it reports for the whole history,
+it fails if the history does not violate the level
+*/
+func Shrink(history core.History, oracle Oracle, opts Options) (Result, error) {
+	units := txnUnits(history)
+	target, violated := oracle(flatten(units))
+	if !violated {
+		return Result{}, fmt.Errorf("the history does not violate the level, nothing to shrink")
+	}
+	s := &shrinker{oracle: oracle, opts: opts, target: target, checks: 1}
+
+	// most of the time the txns of the cycle alone reproduce it
+	if cycleUnits := s.cycleUnits(units, target.Cycle); len(cycleUnits) < len(units) && s.test(cycleUnits) {
+		units = cycleUnits
+	}
+
+	for changed := true; changed && !s.exhausted(); {
+		changed = false
+		for _, phase := range []func([]unit) []unit{s.shrinkTxns, s.shrinkKeys, s.shrinkMops} {
+			shrunk := phase(units)
+			if mopCount(shrunk) < mopCount(units) {
+				units, changed = shrunk, true
+			}
+		}
+	}
+
+	// the final check also yields the cycle of the minimal history
+	minimal := flatten(units)
+	violation, _ := oracle(minimal)
+	return Result{History: minimal, Violation: violation, Checks: s.checks + 1}, nil
+}
+
+func (s *shrinker) exhausted() bool {
+	return s.opts.MaxChecks > 0 && s.checks >= s.opts.MaxChecks
+}
+
+func (s *shrinker) test(units []unit) bool {
+	if len(units) == 0 || s.exhausted() {
+		return false
+	}
+	s.checks++
+	violation, violated := s.oracle(flatten(units))
+	if !violated || !violation.Same(s.target) {
+		return false
+	}
+	if s.opts.Verbose {
+		log.Printf("shrink: %d txns, %d mops reproduce %s of %s\n", len(units), mopCount(units), violation.Class, violation.Level)
+	}
+	return true
+}
+
+// groups invocations with the completion of the same process, other ops are txns of their own
+func txnUnits(history core.History) []unit {
+	var units []unit
+	open := make(map[int]int)
+	for _, op := range history {
+		if op.Process.Present() {
+			p := op.Process.MustGet()
+			if i, ok := open[p]; ok && op.Type != core.OpTypeInvoke {
+				units[i] = append(units[i], op)
+				delete(open, p)
+				continue
+			}
+			if op.Type == core.OpTypeInvoke {
+				open[p] = len(units)
+			}
+		}
+		units = append(units, unit{op})
+	}
+	return units
+}
+
+// the history of units, indexed in order as the checkers expect
+func flatten(units []unit) core.History {
+	var history core.History
+	for _, u := range units {
+		for _, op := range u {
+			op.Index = core.NewOptInt(len(history))
+			history = append(history, op)
+		}
+	}
+	return history
+}
+
+func mopCount(units []unit) int {
+	n := 0
+	for _, u := range units {
+		for _, op := range u {
+			n += op.ValueLength()
+		}
+	}
+	return n
+}
+
+func docId(collection string, key string) string {
+	return fmt.Sprintf("%s/%s", collection, key)
+}
+
+// the units of the txns of a cycle, txn ids are the indexes of flatten
+func (s *shrinker) cycleUnits(units []unit, cycle []export.Edge) []unit {
+	txnNode := s.opts.TxnNode
+	if txnNode == "" {
+		txnNode = "txn"
+	}
+	inCycle := make(map[string]bool)
+	for _, e := range cycle {
+		inCycle[e.From], inCycle[e.To] = true, true
+	}
+	var kept []unit
+	i := 0
+	for _, u := range units {
+		keep := false
+		for range u {
+			if inCycle[docId(txnNode, strconv.Itoa(i))] {
+				keep = true
+			}
+			i++
+		}
+		if keep {
+			kept = append(kept, u)
+		}
+	}
+	return kept
+}
+
+func (s *shrinker) shrinkTxns(units []unit) []unit {
+	pick := func(idx []int) []unit {
+		picked := make([]unit, 0, len(idx))
+		for _, i := range idx {
+			picked = append(picked, units[i])
+		}
+		return picked
+	}
+	return pick(ddmin(indexes(len(units)), func(idx []int) bool { return s.test(pick(idx)) }))
+}
+
+func (s *shrinker) shrinkKeys(units []unit) []unit {
+	keySet := make(map[string]bool)
+	for _, u := range units {
+		for _, op := range u {
+			if op.Value != nil {
+				for _, mop := range *op.Value {
+					keySet[mop.GetKey()] = true
+				}
+			}
+		}
+	}
+	keys := make([]string, 0, len(keySet))
+	for k := range keySet {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pick := func(idx []int) []unit {
+		kept := make(map[string]bool)
+		for _, i := range idx {
+			kept[keys[i]] = true
+		}
+		return filterMops(units, func(_ int, _ int, mop core.Mop) bool { return kept[mop.GetKey()] })
+	}
+	return pick(ddmin(indexes(len(keys)), func(idx []int) bool { return s.test(pick(idx)) }))
+}
+
+// micro-ops are removed at the same position from the invocation and the completion of a txn
+func (s *shrinker) shrinkMops(units []unit) []unit {
+	for u := 0; u < len(units) && !s.exhausted(); u++ {
+		n := units[u][0].ValueLength()
+		if n < 2 {
+			continue
+		}
+		pick := func(idx []int) []unit {
+			kept := make(map[int]bool)
+			for _, i := range idx {
+				kept[i] = true
+			}
+			return filterMops(units, func(unitIdx int, mopIdx int, _ core.Mop) bool { return unitIdx != u || kept[mopIdx] })
+		}
+		units = pick(ddmin(indexes(n), func(idx []int) bool { return s.test(pick(idx)) }))
+	}
+	return units
+}
+
+// copies units with the mops keep holds for, units left without mops are dropped
+func filterMops(units []unit, keep func(unitIdx int, mopIdx int, mop core.Mop) bool) []unit {
+	var filtered []unit
+	for ui, u := range units {
+		var fu unit
+		for _, op := range u {
+			var mops []core.Mop
+			if op.Value != nil {
+				for mi, mop := range *op.Value {
+					if keep(ui, mi, mop) {
+						mops = append(mops, mop)
+					}
+				}
+			}
+			op.Value = &mops
+			fu = append(fu, op)
+		}
+		if fu[0].ValueLength() > 0 {
+			filtered = append(filtered, fu)
+		}
+	}
+	return filtered
+}
+
+func indexes(n int) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
+}
+
+/*
+ddmin is the minimizing delta debugging algorithm (Zeller and Hildebrandt): it returns a
+1-minimal subset of items, in order, for which test holds, test(items) is assumed to hold
+*/
+func ddmin(items []int, test func([]int) bool) []int {
+	n := 2
+	for len(items) >= 2 {
+		chunks := split(items, n)
+		reduced := false
+		for _, c := range chunks {
+			if test(c) {
+				items, n, reduced = c, 2, true
+				break
+			}
+		}
+		// with two chunks the complements are the chunks themselves
+		for i := 0; !reduced && n > 2 && i < len(chunks); i++ {
+			if c := complement(chunks, i); test(c) {
+				items, n, reduced = c, n-1, true
+			}
+		}
+		if !reduced {
+			if n >= len(items) {
+				break
+			}
+			n *= 2
+			if n > len(items) {
+				n = len(items)
+			}
+		}
+	}
+	return items
+}
+
+func split(items []int, n int) [][]int {
+	chunks := make([][]int, 0, n)
+	start := 0
+	for i := 0; i < n; i++ {
+		end := start + (len(items)-start)/(n-i)
+		chunks = append(chunks, items[start:end])
+		start = end
+	}
+	return chunks
+}
+
+func complement(chunks [][]int, skip int) []int {
+	var c []int
+	for i, chunk := range chunks {
+		if i != skip {
+			c = append(c, chunk...)
+		}
+	}
+	return c
+}
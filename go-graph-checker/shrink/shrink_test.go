@@ -0,0 +1,92 @@
+package shrink
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/grail/anti-pattern-graph-checker-single/go-elle/core"
+	"github.com/grail/anti-pattern-graph-checker-single/go-graph-checker/export"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDDMin(t *testing.T) {
+	minimal := ddmin(indexes(100), func(items []int) bool {
+		has := make(map[int]bool)
+		for _, i := range items {
+			has[i] = true
+		}
+		return has[3] && has[42] && has[97]
+	})
+	require.Equal(t, []int{3, 42, 97}, minimal)
+}
+
+func mustParseHistory(t *testing.T, content string) core.History {
+	history, err := core.ParseHistory(content)
+	require.NoError(t, err)
+	return history
+}
+
+func TestShrink(t *testing.T) {
+	history := mustParseHistory(t, `{:type :invoke, :process 0, :value [[:append x 1] [:append y 1]]}
+{:type :invoke, :process 1, :value [[:r z nil]]}
+{:type :ok, :process 1, :value [[:r z [1]]]}
+{:type :ok, :process 0, :value [[:append x 1] [:append y 1]]}
+{:type :ok, :process 2, :value [[:append z 1] [:r x [1]] [:r y [1]]]}
+{:type :ok, :process 3, :value [[:append w 1]]}`)
+
+	// violated while some op appends to x and another one reads x
+	oracle := func(h core.History) (Violation, bool) {
+		var appends, reads bool
+		for _, op := range h {
+			if op.Type != core.OpTypeOk {
+				continue
+			}
+			for _, mop := range *op.Value {
+				appends = appends || (mop.IsAppend() && mop.GetKey() == "x")
+				reads = reads || (mop.IsRead() && mop.GetKey() == "x")
+			}
+		}
+		return NewViolation("ser", []export.Edge{{From: "txn/0", To: "txn/1", Type: "wr"}, {From: "txn/1", To: "txn/0", Type: "rw"}}, nil), appends && reads
+	}
+
+	r, err := Shrink(history, oracle, Options{})
+	require.NoError(t, err)
+	require.Equal(t, "G-single", r.Violation.Class)
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteEDN(&buf, r.History))
+	// the invocation stays with its completion
+	require.Equal(t, `{:type :invoke, :value [[:append x 1]], :process 0, :index 0}
+{:type :ok, :value [[:append x 1]], :process 0, :index 1}
+{:type :ok, :value [[:r x [1]]], :process 2, :index 2}
+`, buf.String())
+	require.Equal(t, r.History, mustParseHistory(t, buf.String()))
+
+	_, err = Shrink(history[5:], oracle, Options{})
+	require.Error(t, err)
+}
+
+func TestCycleUnitsPrefixed(t *testing.T) {
+	units := txnUnits(mustParseHistory(t, `{:type :ok, :value [[:append x 1]]}
+{:type :invoke, :process 1, :value [[:r x nil]]}
+{:type :ok, :process 1, :value [[:r x [1]]]}
+{:type :ok, :value [[:append y 1]]}`))
+	cycle := []export.Edge{{From: "nightly_txn/0", To: "nightly_txn/2", Type: "wr"}, {From: "nightly_txn/2", To: "nightly_txn/0", Type: "rw"}}
+
+	// the txn ids are in the collection of the checker
+	s := &shrinker{opts: Options{TxnNode: "nightly_txn"}}
+	require.Equal(t, units[:2], s.cycleUnits(units, cycle))
+	s = &shrinker{}
+	require.Empty(t, s.cycleUnits(units, cycle))
+}
+
+func TestWriteViolationEDN(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteViolationEDN(&buf, NewViolation("si", []export.Edge{
+		{From: "txn/1", To: "txn/2", FromEvt: "a_evt/1,0", ToEvt: "r_evt/2,0", Obj: "x", Type: "wr"},
+		{From: "txn/2", To: "txn/1", FromEvt: "r_evt/2,1", ToEvt: "a_evt/1,1", Obj: "y", Type: "rw"},
+	}, []string{"G1b", "G1a"})))
+	require.True(t, strings.HasPrefix(buf.String(), `{:level "si", :class "G-single", :g1 [:G1a :G1b],`), buf.String())
+	require.Contains(t, buf.String(), `{:from "txn/2", :to "txn/1", :type :rw, :key "y", :from-evt "r_evt/2,1", :to-evt "a_evt/1,1"}]}`)
+}
@@ -6,9 +6,11 @@ require (
 	github.com/arangodb/go-driver v1.5.0
 	github.com/goccy/go-graphviz v0.1.0
 	github.com/juju/errors v1.0.0
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826
 	github.com/ngaut/log v0.0.0-20221012222132-f3329cba28a5
 	github.com/stretchr/testify v1.8.2
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/apimachinery v0.26.1
 )
 
@@ -20,5 +22,4 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	golang.org/x/image v0.0.0-20200119044424-58c23975cae1 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -19,6 +19,8 @@ public class Edge {
     private String from_evt;
     @JsonProperty
     private String to_evt;
+    @JsonProperty
+    private String obj;
     private String type;
 
     public String getType() {
@@ -41,6 +43,10 @@ public class Edge {
         return to_evt;
     }
 
+    public String getObj() {
+        return obj;
+    }
+
     @JsonAnySetter
     public void set_key(String _key) {
         this._key = _key;
@@ -66,6 +72,11 @@ public class Edge {
         this.to_evt = to_evt;
     }
 
+    @JsonAnySetter
+    public void setObj(String obj) {
+        this.obj = obj;
+    }
+
     @JsonAnySetter
     public void setType(String type) {
         this.type = type;